@@ -2,15 +2,20 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"cloud.google.com/go/storage"
 	"cloud.google.com/go/vertexai/genai"
 	"github.com/joho/godotenv"
 	"ivmanto.com/backend/internal/analytics"
 	"ivmanto.com/backend/internal/articles"
+	"ivmanto.com/backend/internal/audit"
 	"ivmanto.com/backend/internal/blog"
 	"ivmanto.com/backend/internal/booking"
 	"ivmanto.com/backend/internal/config"
@@ -18,7 +23,10 @@ import (
 	"ivmanto.com/backend/internal/email"
 	"ivmanto.com/backend/internal/gcal"
 	"ivmanto.com/backend/internal/ideas"
+	"ivmanto.com/backend/internal/idempotency"
 	"ivmanto.com/backend/internal/middleware"
+	"ivmanto.com/backend/internal/waitlist"
+	"ivmanto.com/backend/internal/webhook"
 )
 
 func main() {
@@ -46,15 +54,29 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Client addresses, for the audit log and rate limits, are read from
+	// X-Forwarded-For behind the load balancer.
+	audit.SetTrustedProxies(cfg.Service.TrustedProxies)
+
+	if len(cfg.Booking.Tokens.Keys) == 0 {
+		slog.Warn("BOOKING_TOKEN_KEYS is not set; booking management links are not signed and never expire")
+	}
+
 	// 3. Initialize services
 	emailService := email.NewSmtpService(&cfg.Email, logger)
 	ctx := context.Background()
 
 	// For GCal, we use Application Default Credentials (ADC).
 	// On Cloud Run, this uses the attached service account's identity.
-	// For local development, run `gcloud auth application-default login`.
+	// For local development, run `gcloud auth application-default login`,
+	// or set GCAL_BACKEND=memory to book against an offline fake calendar.
 
-	gcalSvc, err := gcal.NewService(ctx, cfg)
+	var gcalSvc gcal.Service
+	if cfg.GCal.Backend == config.GCalBackendMemory {
+		gcalSvc, err = gcal.NewMemoryService(ctx, cfg)
+	} else {
+		gcalSvc, err = gcal.NewService(ctx, cfg)
+	}
 	if err != nil {
 		slog.Error("Failed to create Google Calendar service", "error", err)
 		os.Exit(1)
@@ -93,20 +115,123 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Initialize the booking waitlist. The bucket store is shared by all
+	// instances, so every instance sees the entries and the slots their
+	// offers reserve; the file store is for local development. Without
+	// either the waitlist is disabled.
+	var waitlistStore waitlist.Store
+	switch {
+	case cfg.Booking.WaitlistBucket != "":
+		waitlistStore = waitlist.NewGCSStore(storageClient, cfg.Booking.WaitlistBucket, "waitlist/")
+	case cfg.Booking.WaitlistFile != "":
+		waitlistStore, err = waitlist.NewFileStore(cfg.Booking.WaitlistFile)
+		if err != nil {
+			slog.Error("Failed to open waitlist store", "error", err)
+			os.Exit(1)
+		}
+	default:
+		slog.Warn("BOOKING_WAITLIST_BUCKET is not set; the waitlist is disabled")
+	}
+	var bookingWaitlist *booking.Waitlist
+	if waitlistStore != nil {
+		bookingWaitlist = booking.NewWaitlist(logger, gcalSvc, emailService, waitlistStore, &cfg.Booking)
+		bookingWaitlist.Start()
+		defer bookingWaitlist.Stop()
+	}
+
+	// The booking audit log. By default records go to the structured logs;
+	// AUDIT_SINK=jsonl keeps them in a file the admin API can query.
+	var auditLog audit.Sink
+	if cfg.Audit.Sink == config.AuditSinkFile {
+		auditLog, err = audit.NewFileSink(cfg.Audit.File)
+		if err != nil {
+			slog.Error("Failed to open audit log", "error", err)
+			os.Exit(1)
+		}
+	} else {
+		auditLog = audit.NewLogSink(logger)
+	}
+
+	// Outgoing webhooks for new leads and booking changes. Without
+	// WEBHOOK_TARGETS the dispatcher is nil and sends nothing.
+	webhooks, err := webhook.NewDispatcher(logger, cfg.Webhooks)
+	if err != nil {
+		slog.Error("Failed to set up webhooks", "error", err)
+		os.Exit(1)
+	}
+	defer webhooks.Stop()
+
 	// 4. Initialize handlers, passing dependencies
-	contactHandler := contact.NewHandler(logger, emailService)
-	bookingHandler := booking.NewHandler(logger, gcalSvc, emailService, trackerSvc)
-	ideasHandler := ideas.NewHandler(logger, genaiClient, emailService, cfg.Ideas.GenerateIdeasPromptTemplate)
+	contactHandler := contact.NewHandler(logger, emailService, webhooks)
+	bookingHandler := booking.NewHandler(logger, gcalSvc, emailService, trackerSvc, &cfg.Booking, booking.Options{
+		Waitlist: bookingWaitlist,
+		AuditLog: auditLog,
+		Webhooks: webhooks,
+	})
+	ideasHandler := ideas.NewHandler(logger, genaiClient, emailService, cfg.Ideas.GenerateIdeasPromptTemplate, webhooks)
 	articlesHandler := articles.NewHandler(logger)
 	blogHandler := blog.NewHandler(logger, blogCache, cfg.Blog.PubSubPushToken, cfg.Blog.FrontendRebuildWebhookURL)
 
+	// Send reminder emails ahead of booked consultations in the background.
+	// The ticker needs CPU outside requests; cloudbuild.yaml deploys with
+	// --no-cpu-throttling and --min-instances=1.
+	bookingReminders := booking.NewReminders(bookingHandler)
+	bookingReminders.Start()
+	defer bookingReminders.Stop()
+
+	// Thank clients and ask for feedback after their consultations. Like the
+	// reminders, this runs on a ticker that needs CPU outside requests.
+	bookingFollowUps := booking.NewFollowUps(bookingHandler)
+	bookingFollowUps.Start()
+	defer bookingFollowUps.Stop()
+
+	// Responses to requests sent with an Idempotency-Key header are kept for
+	// replay. The bucket store is shared by all instances; like the waitlist,
+	// the file and memory stores are meant for a single instance.
+	var idempotencyStore idempotency.Store
+	if cfg.Service.IdempotencyBucket != "" {
+		idempotencyStore = idempotency.NewGCSStore(storageClient, cfg.Service.IdempotencyBucket, "idempotency/", cfg.Service.IdempotencyTTL)
+	} else if cfg.Service.IdempotencyFile != "" {
+		idempotencyStore, err = idempotency.NewFileStore(cfg.Service.IdempotencyFile, cfg.Service.IdempotencyTTL)
+		if err != nil {
+			slog.Error("Failed to open idempotency store", "error", err)
+			os.Exit(1)
+		}
+	} else {
+		idempotencyStore = idempotency.NewMemoryStore(cfg.Service.IdempotencyTTL)
+	}
+
+	// Erase the personal data of past bookings after BOOKING_RETENTION_DAYS,
+	// and of anyone who asks through the admin API. Like the reminders, the
+	// retention ticker needs CPU outside requests.
+	bookingRetention := booking.NewRetention(bookingHandler, idempotencyStore)
+	bookingRetention.Start()
+	defer bookingRetention.Stop()
+
 	// 5. Register routes
+	routes := http.NewServeMux()
+	contactHandler.RegisterRoutes(routes)
+	bookingHandler.RegisterRoutes(routes)
+	ideasHandler.RegisterRoutes(routes)
+	articlesHandler.RegisterRoutes(routes)
+	blogHandler.RegisterRoutes(routes)
+
+	// POSTs that send email or book a slot honour an Idempotency-Key header,
+	// so double-clicks and retries are answered with the first response.
 	mux := http.NewServeMux()
-	contactHandler.RegisterRoutes(mux)
-	bookingHandler.RegisterRoutes(mux)
-	ideasHandler.RegisterRoutes(mux)
-	articlesHandler.RegisterRoutes(mux)
-	blogHandler.RegisterRoutes(mux)
+	mux.Handle("/", routes)
+	idempotent := middleware.Idempotent(idempotencyStore, routes)
+	for _, pattern := range []string{"POST /api/booking/book", "POST /api/contact", "POST /api/ideas/email"} {
+		mux.Handle(pattern, idempotent)
+	}
+
+	// The admin API is only served when ADMIN_API_TOKEN is set.
+	if cfg.Admin.Token != "" {
+		adminMux := http.NewServeMux()
+		bookingHandler.RegisterAdminRoutes(adminMux)
+		bookingRetention.RegisterAdminRoutes(adminMux)
+		mux.Handle("/api/admin/", middleware.RequireBearerToken(cfg.Admin.Token, adminMux))
+	}
 
 	// 6. Apply middleware
 	var finalHandler http.Handler = mux
@@ -119,8 +244,22 @@ func main() {
 		Addr:    ":" + port,
 		Handler: finalHandler,
 	}
-	if err := server.ListenAndServe(); err != nil {
+	// Cloud Run sends SIGTERM before stopping an instance. Shutting down the
+	// server lets the deferred Stop calls run, so webhook retries still
+	// waiting are dead-lettered rather than lost with the instance.
+	shutdown, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stopSignals()
+	go func() {
+		<-shutdown.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Error("could not shut down server", "error", err)
+		}
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		slog.Error("could not start server", "error", err)
 		os.Exit(1)
 	}
+	slog.Info("Server stopped")
 }
@@ -0,0 +1,247 @@
+// Package audit keeps an append-only history of what happened to bookings,
+// so that disputes can be settled after the calendar event has been released
+// and its client details are gone.
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/gcal"
+)
+
+// Action is a step in the lifecycle of a booking.
+type Action string
+
+const (
+	ActionHold       Action = "hold"       // a visitor held a slot while filling in the form
+	ActionBook       Action = "book"       // a slot was booked
+	ActionReschedule Action = "reschedule" // the client moved their booking to another slot
+	ActionCancel     Action = "cancel"     // the client cancelled their booking
+	// ActionAdminCancel is the admin cancelling on the client's behalf. The
+	// "late" detail is set when it overrode the cancellation cutoff.
+	ActionAdminCancel  Action = "admin_cancel"
+	ActionNoShow       Action = "no_show"       // the admin flagged or unflagged a no-show
+	ActionReminderSent Action = "reminder_sent" // a reminder email went out
+	ActionFeedback     Action = "feedback"      // the client gave feedback
+	ActionRemoveGuest  Action = "remove_guest"  // the admin took a guest off the booking
+	// ActionErase is the personal data of a booking being erased, by the
+	// retention job or on request. Its records name no one.
+	ActionErase Action = "erase"
+)
+
+// Who caused a record.
+const (
+	ActorClient = "client"
+	ActorAdmin  = "admin"
+	ActorSystem = "system" // background jobs, e.g. reminders
+)
+
+// Record is one entry of the audit log.
+type Record struct {
+	Time   time.Time `json:"time"`
+	Action Action    `json:"action"`
+	// EventID is the calendar event the record is about. For a reschedule it
+	// is the new event and PreviousEventID the one the booking moved from.
+	EventID         string `json:"eventId"`
+	PreviousEventID string `json:"previousEventId,omitempty"`
+	Actor           string `json:"actor"`
+	// IP is the client address of the request that caused the record, as
+	// reported by the proxy in front of the service. Empty for ActorSystem.
+	IP string `json:"ip,omitempty"`
+	// Before and After are the booking before and after the action. Before
+	// is nil for a new booking and After for a released slot.
+	Before  *Snapshot         `json:"before,omitempty"`
+	After   *Snapshot         `json:"after,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// About reports whether the record concerns the calendar event eventID.
+func (r Record) About(eventID string) bool {
+	return r.EventID == eventID || r.PreviousEventID == eventID
+}
+
+// Snapshot is the state of a booked event that matters in a dispute. Secrets
+// stored on the event, such as its management token, are left out.
+type Snapshot struct {
+	Summary         string   `json:"summary"`
+	Start           string   `json:"start"`
+	End             string   `json:"end"`
+	SessionType     string   `json:"sessionType,omitempty"`
+	ClientName      string   `json:"clientName,omitempty"`
+	ClientEmail     string   `json:"clientEmail,omitempty"`
+	VisitorTimezone string   `json:"visitorTimezone,omitempty"`
+	Guests          []string `json:"guests,omitempty"`
+}
+
+// SnapshotOf captures event, which may be a booked event or the snapshot of
+// one returned by gcal.Service when it was cancelled or moved. It returns nil
+// for a nil event.
+func SnapshotOf(event *calendar.Event) *Snapshot {
+	if event == nil {
+		return nil
+	}
+	s := &Snapshot{Summary: event.Summary}
+	if event.Start != nil {
+		s.Start = event.Start.DateTime
+	}
+	if event.End != nil {
+		s.End = event.End.DateTime
+	}
+	if event.ExtendedProperties != nil {
+		private := event.ExtendedProperties.Private
+		s.SessionType = private["session_type"]
+		s.ClientName = private["client_name"]
+		s.ClientEmail = private["client_email"]
+		s.VisitorTimezone = private["visitor_timezone"]
+	}
+	s.Guests = gcal.Guests(event)
+	// Snapshots of released bookings carry the client as the attendee.
+	if s.ClientEmail == "" && len(event.Attendees) > 0 {
+		s.ClientName = event.Attendees[0].DisplayName
+		s.ClientEmail = event.Attendees[0].Email
+	}
+	return s
+}
+
+// names reports whether email is the client or one of the guests of s.
+func (s *Snapshot) names(email string) bool {
+	return s != nil && (strings.EqualFold(s.ClientEmail, email) ||
+		slices.ContainsFunc(s.Guests, func(g string) bool { return strings.EqualFold(g, email) }))
+}
+
+// erase removes email from s. If it is the client's, the summary goes too, as
+// it carries the client's name.
+func (s *Snapshot) erase(email string) {
+	if strings.EqualFold(s.ClientEmail, email) {
+		s.Summary = ""
+		s.ClientName = ""
+		s.ClientEmail = ""
+		s.VisitorTimezone = ""
+	}
+	s.Guests = slices.DeleteFunc(s.Guests, func(g string) bool { return strings.EqualFold(g, email) })
+}
+
+// erase removes email from the snapshots of r and reports whether it named
+// them. The IP of a client request goes along, as it may identify the client.
+func (r *Record) erase(email string) bool {
+	if !r.Before.names(email) && !r.After.names(email) {
+		return false
+	}
+	for _, s := range []*Snapshot{r.Before, r.After} {
+		if s != nil {
+			s.erase(email)
+		}
+	}
+	if r.Actor == ActorClient {
+		r.IP = ""
+	}
+	return true
+}
+
+// expire removes the clients and guests from r once the bookings it is about
+// ended before cutoff, or, for a record without bookings, once it was made
+// before cutoff. It reports whether r named anyone.
+func (r *Record) expire(cutoff time.Time) bool {
+	ended := time.Time{}
+	for _, s := range []*Snapshot{r.Before, r.After} {
+		if s == nil {
+			continue
+		}
+		if end, err := time.Parse(time.RFC3339, s.End); err == nil && end.After(ended) {
+			ended = end
+		}
+	}
+	if ended.IsZero() {
+		ended = r.Time
+	}
+	if !ended.Before(cutoff) {
+		return false
+	}
+	changed := false
+	for _, s := range []*Snapshot{r.Before, r.After} {
+		if s != nil && (s.ClientEmail != "" || s.ClientName != "" || len(s.Guests) > 0) {
+			s.Summary = ""
+			s.ClientName = ""
+			s.ClientEmail = ""
+			s.VisitorTimezone = ""
+			s.Guests = nil
+			changed = true
+		}
+	}
+	if r.Actor == ActorClient && r.IP != "" {
+		r.IP = ""
+		changed = true
+	}
+	return changed
+}
+
+// Sink receives the records of the audit log.
+type Sink interface {
+	// Append adds rec to the log. Records are never changed or removed,
+	// except by an Eraser on an erasure request and by an Expirer once the
+	// retention period is over.
+	Append(ctx context.Context, rec Record) error
+}
+
+// Querier is implemented by sinks that can read their records back.
+type Querier interface {
+	// History returns the records about eventID, oldest first.
+	History(ctx context.Context, eventID string) ([]Record, error)
+}
+
+// Eraser is implemented by sinks that can erase personal data from their
+// records when a client asks to be forgotten.
+type Eraser interface {
+	// Erase removes the client or guest with email from the records naming
+	// them and returns how many records it changed. The records themselves,
+	// and what happened when, are kept.
+	Erase(ctx context.Context, email string) (int, error)
+}
+
+// Expirer is implemented by sinks that can erase personal data from their
+// records once the retention period is over.
+type Expirer interface {
+	// Expire removes the clients and guests from the records about bookings
+	// that ended before cutoff, and from other records made before it, and
+	// returns how many records it changed.
+	Expire(ctx context.Context, cutoff time.Time) (int, error)
+}
+
+// trustedProxies is how many proxies in front of the service append an
+// address to X-Forwarded-For. See SetTrustedProxies.
+var trustedProxies = 1
+
+// SetTrustedProxies sets how many proxies in front of the service append an
+// address to X-Forwarded-For, e.g. 1 for the external HTTPS load balancer,
+// which appends "<client-ip>, <lb-ip>". It is meant to be called once at
+// startup, before requests are served.
+func SetTrustedProxies(n int) {
+	trustedProxies = n
+}
+
+// ClientIP returns the address of the client that sent r. Each trusted proxy
+// appends to X-Forwarded-For, so the client is the entry trustedProxies from
+// the right; the entries before it are sent by the client and cannot be
+// trusted. Without the header, or if it has too few entries, it is the remote
+// address of the connection.
+func ClientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		entries := strings.Split(forwarded, ",")
+		if i := len(entries) - 1 - trustedProxies; i >= 0 {
+			if ip := strings.TrimSpace(entries[i]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
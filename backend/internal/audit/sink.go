@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileSink appends records to a JSON Lines file, one record per line.
+type fileSink struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink returns a Sink that appends to the JSON Lines file at path,
+// creating it if needed. It also answers History by reading the file back.
+func NewFileSink(path string) (Sink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit file: %w", err)
+	}
+	return &fileSink{path: path, file: file}, nil
+}
+
+// Append writes rec as one line. The line is written in a single call, so
+// a crash leaves at most the last record incomplete.
+func (s *fileSink) Append(ctx context.Context, rec Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding audit record: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing audit file: %w", err)
+	}
+	return nil
+}
+
+// History scans the file for the records about eventID. Lines that cannot be
+// parsed, e.g. one cut short by a crash, are skipped.
+func (s *fileSink) History(ctx context.Context, eventID string) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading audit file: %w", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		if rec.About(eventID) {
+			records = append(records, rec)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading audit file: %w", err)
+	}
+	return records, nil
+}
+
+// Erase rewrites the file with email removed from the records naming it.
+func (s *fileSink) Erase(ctx context.Context, email string) (int, error) {
+	return s.rewrite(func(rec *Record) bool { return rec.erase(email) })
+}
+
+// Expire rewrites the file with the personal data removed from the records
+// that expired before cutoff.
+func (s *fileSink) Expire(ctx context.Context, cutoff time.Time) (int, error) {
+	return s.rewrite(func(rec *Record) bool { return rec.expire(cutoff) })
+}
+
+// rewrite applies update to every record and rewrites the file if update
+// reported a change to any of them. The new file replaces the old one in a
+// single rename, so a crash leaves either.
+func (s *fileSink) rewrite(update func(rec *Record) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return 0, fmt.Errorf("reading audit file: %w", err)
+	}
+
+	var out bytes.Buffer
+	changed := 0
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		var rec Record
+		// Lines that cannot be parsed are kept as they are.
+		if json.Unmarshal(line, &rec) != nil || !update(&rec) {
+			out.Write(line)
+			continue
+		}
+		updated, err := json.Marshal(rec)
+		if err != nil {
+			return 0, fmt.Errorf("encoding audit record: %w", err)
+		}
+		out.Write(append(updated, '\n'))
+		changed++
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, out.Bytes(), 0o600); err != nil {
+		return 0, fmt.Errorf("writing audit file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return 0, fmt.Errorf("replacing audit file: %w", err)
+	}
+	// Append to the new file from now on.
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("reopening audit file: %w", err)
+	}
+	s.file.Close()
+	s.file = file
+	return changed, nil
+}
+
+// logSink writes records as structured log entries, e.g. to Cloud Logging,
+// where they are kept and searched. It cannot answer History.
+type logSink struct {
+	logger *slog.Logger
+}
+
+// NewLogSink returns a Sink that writes each record to logger.
+func NewLogSink(logger *slog.Logger) Sink {
+	return logSink{logger: logger}
+}
+
+func (s logSink) Append(ctx context.Context, rec Record) error {
+	s.logger.LogAttrs(ctx, slog.LevelInfo, "Booking audit record",
+		slog.String("audit_action", string(rec.Action)),
+		slog.String("event_id", rec.EventID),
+		slog.String("previous_event_id", rec.PreviousEventID),
+		slog.String("actor", rec.Actor),
+		slog.String("ip", rec.IP),
+		slog.Any("before", rec.Before),
+		slog.Any("after", rec.After),
+		slog.Any("details", rec.Details),
+	)
+	return nil
+}
@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFileSink_History appends records, including a reschedule, and reads the
+// history of each event back, skipping a line cut short by a crash.
+func TestFileSink_History(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+	ctx := t.Context()
+	at := time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, rec := range []Record{
+		{Time: at, Action: ActionBook, EventID: "e1", Actor: ActorClient, After: &Snapshot{ClientEmail: "ada@example.com"}},
+		{Time: at.Add(time.Hour), Action: ActionBook, EventID: "e3", Actor: ActorClient},
+		{Time: at.Add(2 * time.Hour), Action: ActionReschedule, EventID: "e2", PreviousEventID: "e1", Actor: ActorClient},
+	} {
+		if err := sink.Append(ctx, rec); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("could not open the audit file: %v", err)
+	}
+	file.WriteString(`{"time":"2030-06-01T15:00:00Z","action":"cancel","eventId":"e2"` + "\n")
+	file.Close()
+	if err := sink.Append(ctx, Record{Time: at.Add(4 * time.Hour), Action: ActionCancel, EventID: "e2", Actor: ActorAdmin}); err != nil {
+		t.Fatalf("Append after the broken line failed: %v", err)
+	}
+
+	history, err := sink.(Querier).History(ctx, "e1")
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != 2 || history[0].Action != ActionBook || history[1].Action != ActionReschedule {
+		t.Errorf("expected the booking and the reschedule away from e1, got %+v", history)
+	}
+	if history[0].After == nil || history[0].After.ClientEmail != "ada@example.com" || !history[0].Time.Equal(at) {
+		t.Errorf("expected the record to round-trip, got %+v", history[0])
+	}
+
+	history, _ = sink.(Querier).History(ctx, "e2")
+	if len(history) != 2 || history[1].Actor != ActorAdmin {
+		t.Errorf("expected the reschedule and the admin cancellation of e2, got %+v", history)
+	}
+}
+
+// TestFileSink_Erase removes a client from the records naming them, keeps
+// the other records as they were and appends to the rewritten file.
+func TestFileSink_Erase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+	ctx := t.Context()
+	ada := &Snapshot{Summary: "Intro Call: Ada", ClientName: "Ada", ClientEmail: "ada@example.com", Guests: []string{"bob@example.com"}}
+	for _, rec := range []Record{
+		{Action: ActionBook, EventID: "e1", Actor: ActorClient, IP: "203.0.113.7", After: ada},
+		{Action: ActionBook, EventID: "e2", Actor: ActorClient, IP: "203.0.113.8", After: &Snapshot{ClientEmail: "cy@example.com"}},
+		{Action: ActionNoShow, EventID: "e1", Actor: ActorAdmin},
+	} {
+		if err := sink.Append(ctx, rec); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	if n, err := sink.(Eraser).Erase(ctx, "ADA@example.com"); err != nil || n != 1 {
+		t.Fatalf("expected one record to be erased, got %d (%v)", n, err)
+	}
+	if err := sink.Append(ctx, Record{Action: ActionCancel, EventID: "e1", Actor: ActorAdmin}); err != nil {
+		t.Fatalf("Append after Erase failed: %v", err)
+	}
+	history, err := sink.(Querier).History(ctx, "e1")
+	if err != nil || len(history) != 3 {
+		t.Fatalf("expected the three records of e1, got %+v (%v)", history, err)
+	}
+	if after := history[0].After; after.ClientEmail != "" || after.ClientName != "" || after.Summary != "" || history[0].IP != "" || len(after.Guests) != 1 {
+		t.Errorf("expected the client to be erased and the guest kept, got %+v (IP %q)", after, history[0].IP)
+	}
+	if other, _ := sink.(Querier).History(ctx, "e2"); len(other) != 1 || other[0].After.ClientEmail != "cy@example.com" || other[0].IP == "" {
+		t.Errorf("expected the other client's record to be kept, got %+v", other)
+	}
+}
+
+// TestFileSink_Expire removes the clients and guests from the records about
+// bookings that ended before the cutoff, and from older records without a
+// booking, and leaves the rest alone.
+func TestFileSink_Expire(t *testing.T) {
+	sink, err := NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+	ctx := t.Context()
+	booked := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	for _, rec := range []Record{
+		{Time: booked, Action: ActionHold, EventID: "old", Actor: ActorClient, IP: "203.0.113.7"},
+		{Time: booked, Action: ActionBook, EventID: "old", Actor: ActorClient, IP: "203.0.113.7",
+			After: &Snapshot{Summary: "Intro Call: Ada", End: "2026-03-02T09:30:00Z", ClientName: "Ada", ClientEmail: "ada@example.com", Guests: []string{"bob@example.com"}}},
+		{Time: booked, Action: ActionBook, EventID: "recent", Actor: ActorClient, IP: "203.0.113.8",
+			After: &Snapshot{End: "2026-05-25T09:30:00Z", ClientEmail: "cy@example.com"}},
+	} {
+		if err := sink.Append(ctx, rec); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	if n, err := sink.(Expirer).Expire(ctx, time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)); err != nil || n != 2 {
+		t.Fatalf("expected two records to expire, got %d (%v)", n, err)
+	}
+	old, _ := sink.(Querier).History(ctx, "old")
+	if len(old) != 2 || old[0].IP != "" || old[1].IP != "" || old[1].After.ClientEmail != "" || old[1].After.Summary != "" || len(old[1].After.Guests) != 0 {
+		t.Errorf("expected the old records to lose the client and guests, got %+v", old)
+	}
+	if old[1].After.End != "2026-03-02T09:30:00Z" || old[1].Action != ActionBook {
+		t.Errorf("expected what happened when to be kept, got %+v", old[1])
+	}
+	if recent, _ := sink.(Querier).History(ctx, "recent"); len(recent) != 1 || recent[0].After.ClientEmail != "cy@example.com" || recent[0].IP == "" {
+		t.Errorf("expected the recent record to be kept, got %+v", recent)
+	}
+}
+
+// TestClientIP reads the client address from X-Forwarded-For headers as the
+// external HTTPS load balancer sends them.
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.0.2.1:4321"
+	if got := ClientIP(r); got != "192.0.2.1" {
+		t.Errorf("expected the remote address, got %q", got)
+	}
+	// The load balancer appends "<client-ip>, <lb-ip>".
+	r.Header.Set("X-Forwarded-For", "203.0.113.7, 198.51.100.1")
+	if got := ClientIP(r); got != "203.0.113.7" {
+		t.Errorf("expected the client address before the load balancer's, got %q", got)
+	}
+	// A client can send its own header; the load balancer appends to it, so
+	// only the entry before the load balancer's is trusted.
+	r.Header.Set("X-Forwarded-For", "198.51.100.66, 203.0.113.7, 198.51.100.1")
+	if got := ClientIP(r); got != "203.0.113.7" {
+		t.Errorf("expected the forged address to be ignored, got %q", got)
+	}
+	// Too few entries for the proxies in front of the service.
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	if got := ClientIP(r); got != "192.0.2.1" {
+		t.Errorf("expected the remote address for a short header, got %q", got)
+	}
+
+	SetTrustedProxies(0)
+	defer SetTrustedProxies(1)
+	r.Header.Set("X-Forwarded-For", "198.51.100.66, 203.0.113.7")
+	if got := ClientIP(r); got != "203.0.113.7" {
+		t.Errorf("expected the last entry without a load balancer, got %q", got)
+	}
+}
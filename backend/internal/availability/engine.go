@@ -0,0 +1,90 @@
+// Package availability computes bookable consultation slots from weekly
+// working hours and the calendar's busy time, for the rules availability mode.
+package availability
+
+import (
+	"sort"
+	"time"
+
+	"ivmanto.com/backend/internal/config"
+)
+
+// Interval is a half-open time range [Start, End).
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// overlaps reports whether i and o share any instant.
+func (i Interval) overlaps(o Interval) bool {
+	return i.Start.Before(o.End) && o.Start.Before(i.End)
+}
+
+// Slots returns the bookable slots that start in [from, to), ordered by start.
+//
+// Candidate slots are laid out back to back from the start of each working
+// hours block, in loc, and must fit inside the block. A candidate is dropped
+// when it falls on a blackout date, starts earlier than now+MinNotice or later
+// than now+MaxHorizon, or when the slot padded with BufferBefore/BufferAfter
+// overlaps any busy interval.
+func Slots(rules config.AvailabilityRules, loc *time.Location, from, to, now time.Time, busy []Interval) []Interval {
+	if rules.SlotLength <= 0 || !from.Before(to) {
+		return nil
+	}
+
+	blackout := make(map[string]bool, len(rules.BlackoutDates))
+	for _, day := range rules.BlackoutDates {
+		blackout[day] = true
+	}
+	earliest := now.Add(rules.MinNotice)
+	latest := now.Add(rules.MaxHorizon)
+
+	var slots []Interval
+	// Start one day early so a block that began the previous local day is not
+	// missed when from is not aligned to local midnight.
+	first := from.In(loc).AddDate(0, 0, -1)
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if blackout[day.Format("2006-01-02")] {
+			continue
+		}
+		for _, block := range rules.WorkingHours {
+			if block.Weekday != day.Weekday() {
+				continue
+			}
+			blockEnd := wallClock(day, block.End)
+			for start := wallClock(day, block.Start); !start.Add(rules.SlotLength).After(blockEnd); start = start.Add(rules.SlotLength) {
+				slot := Interval{Start: start, End: start.Add(rules.SlotLength)}
+				if start.Before(from) || !start.Before(to) || start.Before(earliest) || start.After(latest) {
+					continue
+				}
+				padded := Interval{Start: slot.Start.Add(-rules.BufferBefore), End: slot.End.Add(rules.BufferAfter)}
+				if OverlapsAny(padded, busy) {
+					continue
+				}
+				slots = append(slots, slot)
+			}
+		}
+	}
+
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Start.Before(slots[j].Start) })
+	return slots
+}
+
+// wallClock returns the instant offset from midnight of day, resolving the
+// wall-clock time in day's location so DST transitions are respected.
+func wallClock(day time.Time, offset time.Duration) time.Time {
+	h := int(offset / time.Hour)
+	m := int((offset % time.Hour) / time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+}
+
+// OverlapsAny reports whether slot overlaps any of the busy intervals.
+func OverlapsAny(slot Interval, busy []Interval) bool {
+	for _, b := range busy {
+		if slot.overlaps(b) {
+			return true
+		}
+	}
+	return false
+}
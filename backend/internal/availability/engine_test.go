@@ -0,0 +1,120 @@
+package availability
+
+import (
+	"testing"
+	"time"
+
+	"ivmanto.com/backend/internal/config"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("tzdata not available for %s: %v", name, err)
+	}
+	return loc
+}
+
+func weekdayHours(start, end time.Duration) []config.WorkingHours {
+	var hours []config.WorkingHours
+	for d := time.Monday; d <= time.Friday; d++ {
+		hours = append(hours, config.WorkingHours{Weekday: d, Start: start, End: end})
+	}
+	return hours
+}
+
+func starts(slots []Interval, loc *time.Location) []string {
+	out := make([]string, len(slots))
+	for i, s := range slots {
+		out[i] = s.Start.In(loc).Format("01-02 15:04")
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestSlots_WorkingHoursAndBusyTime lays out 30-minute slots in a morning
+// block and checks that a busy meeting plus its buffers removes the
+// overlapping slots.
+func TestSlots_WorkingHoursAndBusyTime(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	rules := config.AvailabilityRules{
+		WorkingHours: weekdayHours(9*time.Hour, 12*time.Hour),
+		SlotLength:   30 * time.Minute,
+		BufferBefore: 15 * time.Minute,
+		BufferAfter:  15 * time.Minute,
+		MaxHorizon:   30 * 24 * time.Hour,
+	}
+	// Monday, June 15, 2026.
+	from := time.Date(2026, 6, 15, 0, 0, 0, 0, berlin)
+	now := from.AddDate(0, 0, -1)
+	busy := []Interval{{
+		Start: time.Date(2026, 6, 15, 10, 0, 0, 0, berlin),
+		End:   time.Date(2026, 6, 15, 10, 30, 0, 0, berlin),
+	}}
+
+	got := starts(Slots(rules, berlin, from, from.AddDate(0, 0, 1), now, busy), berlin)
+	// 09:30 and 10:30 touch the meeting only through the 15-minute buffers.
+	want := []string{"06-15 09:00", "06-15 11:00", "06-15 11:30"}
+	if !equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestSlots_NoticeHorizonAndBlackout checks the minimum notice, the maximum
+// horizon and blackout dates.
+func TestSlots_NoticeHorizonAndBlackout(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	rules := config.AvailabilityRules{
+		WorkingHours:  weekdayHours(9*time.Hour, 10*time.Hour),
+		SlotLength:    30 * time.Minute,
+		MinNotice:     24 * time.Hour,
+		MaxHorizon:    72 * time.Hour,
+		BlackoutDates: []string{"2026-06-17"},
+	}
+	// Now is Monday 09:15, so Monday's slots are inside the notice window,
+	// Tuesday 09:00 is 23h45m away (too soon) but 09:30 is fine, Wednesday
+	// is blacked out, Thursday 09:00 is inside the 72h horizon (which ends
+	// at 09:15) and 09:30 is beyond it.
+	now := time.Date(2026, 6, 15, 9, 15, 0, 0, berlin)
+	from := time.Date(2026, 6, 15, 0, 0, 0, 0, berlin)
+
+	got := starts(Slots(rules, berlin, from, from.AddDate(0, 0, 7), now, nil), berlin)
+	want := []string{"06-16 09:30", "06-18 09:00"}
+	if !equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestSlots_DSTSpringForward makes sure a working day on the DST change
+// keeps its wall-clock hours: on March 29, 2026 Berlin skips 02:00-03:00,
+// but a 09:00-10:00 block still yields 09:00 and 09:30 CEST.
+func TestSlots_DSTSpringForward(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	rules := config.AvailabilityRules{
+		WorkingHours: []config.WorkingHours{{Weekday: time.Sunday, Start: 9 * time.Hour, End: 10 * time.Hour}},
+		SlotLength:   30 * time.Minute,
+		MaxHorizon:   30 * 24 * time.Hour,
+	}
+	from := time.Date(2026, 3, 29, 0, 0, 0, 0, berlin)
+	slots := Slots(rules, berlin, from, from.AddDate(0, 0, 1), from.AddDate(0, 0, -1), nil)
+
+	got := starts(slots, berlin)
+	if want := []string{"03-29 09:00", "03-29 09:30"}; !equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if utc := slots[0].Start.UTC().Format("15:04"); utc != "07:00" {
+		t.Errorf("expected 09:00 CEST to be 07:00 UTC, got %s", utc)
+	}
+}
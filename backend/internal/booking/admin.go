@@ -0,0 +1,281 @@
+package booking
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/audit"
+	"ivmanto.com/backend/internal/conferencing"
+	"ivmanto.com/backend/internal/gcal"
+	"ivmanto.com/backend/internal/webhook"
+)
+
+// Defaults and limits of the admin booking list.
+const (
+	defaultAdminRangeDays = 30
+	maxAdminRangeDays     = 366
+)
+
+// RegisterAdminRoutes sets up the admin booking endpoints. They carry no
+// authentication of their own; the caller mounts mux behind it (see
+// middleware.RequireBearerToken).
+func (h *Handler) RegisterAdminRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/admin/bookings", h.handleAdminListBookings)
+	mux.HandleFunc("GET /api/admin/bookings/{id}", h.handleAdminGetBooking)
+	mux.HandleFunc("POST /api/admin/bookings/{id}/cancel", h.handleAdminCancelBooking)
+	mux.HandleFunc("POST /api/admin/bookings/{id}/resend-confirmation", h.handleAdminResendConfirmation)
+	mux.HandleFunc("POST /api/admin/bookings/{id}/no-show", h.handleAdminSetNoShow)
+	mux.HandleFunc("DELETE /api/admin/bookings/{id}/no-show", h.handleAdminSetNoShow)
+	mux.HandleFunc("GET /api/admin/bookings/{id}/history", h.handleAdminBookingHistory)
+	mux.HandleFunc("DELETE /api/admin/bookings/{id}/guests/{email}", h.handleAdminRemoveGuest)
+}
+
+// adminBooking converts a booked event into the admin API's booking model.
+func (h *Handler) adminBooking(event *calendar.Event, now time.Time) AdminBooking {
+	loc := h.gcalSvc.Location()
+	start, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+	end, _ := time.Parse(time.RFC3339, event.End.DateTime)
+	name, clientEmail, visitorTZ := bookingClient(event)
+	sessionType := h.sessionTypeOf(event)
+
+	status := BookingUpcoming
+	switch {
+	case !now.Before(end):
+		status = BookingPast
+	case !now.Before(start):
+		status = BookingInProgress
+	}
+
+	return AdminBooking{
+		ID:              event.Id,
+		Status:          status,
+		Start:           start.In(loc),
+		End:             end.In(loc),
+		SessionType:     sessionType.ID,
+		SessionName:     sessionType.Name,
+		ClientName:      name,
+		ClientEmail:     clientEmail,
+		VisitorTimezone: visitorTZ,
+		MeetLink:        conferencing.Link(event),
+		ConferenceName:  conferenceName(event),
+		Guests:          gcal.Guests(event),
+		Intake:          gcal.IntakeAnswers(event, sessionType),
+		NoShow:          gcal.NoShow(event),
+		Feedback:        bookingFeedbackOf(event),
+	}
+}
+
+// bookingFeedbackOf returns the feedback the client gave on event, or nil.
+func bookingFeedbackOf(event *calendar.Event) *BookingFeedback {
+	fb, ok := gcal.FeedbackOf(event)
+	if !ok {
+		return nil
+	}
+	return &BookingFeedback{Rating: fb.Rating, Comment: fb.Comment, SubmittedAt: fb.SubmittedAt}
+}
+
+// handleAdminListBookings lists the bookings that start in a date range. The
+// optional `from` and `to` (YYYY-MM-DD, inclusive, in the calendar's
+// timezone) default to the next 30 days; `status` and `email` narrow the list.
+func (h *Handler) handleAdminListBookings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	loc := h.gcalSvc.Location()
+	now := time.Now()
+
+	from := time.Date(now.In(loc).Year(), now.In(loc).Month(), now.In(loc).Day(), 0, 0, 0, 0, loc)
+	if raw := query.Get("from"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, loc)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid from date format, use YYYY-MM-DD")
+			return
+		}
+		from = parsed
+	}
+	to := from.AddDate(0, 0, defaultAdminRangeDays)
+	if raw := query.Get("to"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, loc)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid to date format, use YYYY-MM-DD")
+			return
+		}
+		to = parsed.AddDate(0, 0, 1) // The range is inclusive of the "to" day.
+	}
+	if !to.After(from) {
+		h.respondError(w, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+	if to.After(from.AddDate(0, 0, maxAdminRangeDays)) {
+		h.respondError(w, http.StatusBadRequest, fmt.Sprintf("date range must not exceed %d days", maxAdminRangeDays))
+		return
+	}
+
+	status := query.Get("status")
+	switch status {
+	case "", BookingUpcoming, BookingInProgress, BookingPast:
+	default:
+		h.respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid status, use %s, %s or %s", BookingUpcoming, BookingInProgress, BookingPast))
+		return
+	}
+	clientEmail := strings.TrimSpace(query.Get("email"))
+
+	events, err := h.gcalSvc.ListBookings(from, to)
+	if err != nil {
+		h.logger.Error("Failed to list bookings", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "An internal error occurred while listing bookings.")
+		return
+	}
+
+	bookings := []AdminBooking{}
+	for _, event := range events {
+		booking := h.adminBooking(event, now)
+		if status != "" && booking.Status != status {
+			continue
+		}
+		if clientEmail != "" && !strings.EqualFold(booking.ClientEmail, clientEmail) {
+			continue
+		}
+		bookings = append(bookings, booking)
+	}
+	h.respondJSON(w, http.StatusOK, bookings)
+}
+
+// getAdminBooking loads the booking named by the {id} path value and writes
+// the error response itself when there is none.
+func (h *Handler) getAdminBooking(w http.ResponseWriter, r *http.Request) (*calendar.Event, bool) {
+	id := r.PathValue("id")
+	event, err := h.gcalSvc.GetBooking(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, gcal.ErrSlotNotFound) {
+			h.respondError(w, http.StatusNotFound, "Booking not found.")
+			return nil, false
+		}
+		h.logger.Error("Failed to get booking", "event_id", id, "error", err)
+		h.respondError(w, http.StatusInternalServerError, "An internal error occurred while loading the booking.")
+		return nil, false
+	}
+	return event, true
+}
+
+// handleAdminGetBooking returns one booking.
+func (h *Handler) handleAdminGetBooking(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.getAdminBooking(w, r)
+	if !ok {
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.adminBooking(event, time.Now()))
+}
+
+// handleAdminSetNoShow flags (POST) or unflags (DELETE) the client of a
+// booking that has started as a no-show. No-shows get no follow-up email.
+func (h *Handler) handleAdminSetNoShow(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.getAdminBooking(w, r)
+	if !ok {
+		return
+	}
+	if h.adminBooking(event, time.Now()).Status == BookingUpcoming {
+		h.respondError(w, http.StatusConflict, "This consultation has not started yet.")
+		return
+	}
+
+	noShow := r.Method == http.MethodPost
+	updated, err := h.gcalSvc.SetNoShow(r.Context(), event.Id, noShow)
+	if err != nil {
+		h.logger.Error("Failed to record no-show", "event_id", event.Id, "error", err)
+		switch {
+		case errors.Is(err, gcal.ErrSlotNotFound):
+			h.respondError(w, http.StatusNotFound, "Booking not found.")
+		case errors.Is(err, gcal.ErrBookingChanged):
+			h.respondError(w, http.StatusConflict, "The booking changed while it was being updated. Please reload and try again.")
+		default:
+			h.respondError(w, http.StatusInternalServerError, "An internal error occurred while updating the booking.")
+		}
+		return
+	}
+	h.logger.Info("Updated no-show flag", "event_id", event.Id, "no_show", noShow)
+	h.recordAudit(r.Context(), audit.Record{
+		Action:  audit.ActionNoShow,
+		EventID: event.Id,
+		Actor:   audit.ActorAdmin,
+		IP:      audit.ClientIP(r),
+		Details: map[string]string{"noShow": strconv.FormatBool(noShow)},
+	})
+	h.respondJSON(w, http.StatusOK, h.adminBooking(updated, time.Now()))
+}
+
+// adminCancelResponse confirms a cancellation made by the admin. Late is set
+// when it overrode the cancellation cutoff.
+type adminCancelResponse struct {
+	Message string `json:"message"`
+	Late    bool   `json:"late"`
+}
+
+// handleAdminCancelBooking cancels a booking on the client's behalf. Unlike
+// the client, the admin may cancel inside the cancellation cutoff; such late
+// cancellations are recorded by gcal.Service.OverrideCancellation. The client
+// and the admin are emailed as for a self-service cancellation.
+func (h *Handler) handleAdminCancelBooking(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.getAdminBooking(w, r)
+	if !ok {
+		return
+	}
+	originalEvent, err := h.gcalSvc.OverrideCancellation(r.Context(), event.Id)
+	if err != nil {
+		h.logger.Error("Failed to cancel booking for the admin", "event_id", event.Id, "error", err)
+		switch {
+		case errors.Is(err, gcal.ErrBookingStarted):
+			h.respondError(w, http.StatusConflict, "This consultation has already started and can no longer be cancelled.")
+		case errors.Is(err, gcal.ErrSlotNotFound):
+			h.respondError(w, http.StatusConflict, "The booking changed while it was being cancelled. Please reload and try again.")
+		default:
+			h.respondError(w, http.StatusInternalServerError, "An internal error occurred while cancelling the booking.")
+		}
+		return
+	}
+
+	late := gcal.LateCancellation(originalEvent)
+	h.logger.Info("Booking cancelled by the admin", "event_id", originalEvent.Id, "late", late)
+	h.recordAudit(r.Context(), audit.Record{
+		Action:  audit.ActionAdminCancel,
+		EventID: originalEvent.Id,
+		Actor:   audit.ActorAdmin,
+		IP:      audit.ClientIP(r),
+		Before:  audit.SnapshotOf(originalEvent),
+		Details: map[string]string{"late": strconv.FormatBool(late)},
+	})
+	cancelled := h.bookingWebhookOf(originalEvent)
+	cancelled.CancelledBy = audit.ActorAdmin
+	cancelled.Late = late
+	h.webhooks.Send(webhook.EventBookingCancelled, cancelled)
+	h.notifyCancellation(originalEvent)
+	h.respondJSON(w, http.StatusOK, adminCancelResponse{Message: "Booking cancelled successfully", Late: late})
+}
+
+// handleAdminResendConfirmation emails the confirmation, with its .ics
+// invitation, to the client again.
+func (h *Handler) handleAdminResendConfirmation(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.getAdminBooking(w, r)
+	if !ok {
+		return
+	}
+	name, clientEmail, visitorTZ := bookingClient(event)
+	details := h.confirmationDetails(event, name, clientEmail, visitorTZ)
+	occurrences, err := h.seriesOccurrences(r.Context(), event)
+	if err != nil {
+		h.logger.Error("Failed to list occurrences of series", "event_id", event.Id, "error", err)
+		h.respondError(w, http.StatusInternalServerError, "An internal error occurred while looking up the booking.")
+		return
+	}
+	h.withSeries(&details, event, occurrences)
+	if err := h.emailSvc.SendBookingConfirmation(details); err != nil {
+		h.logger.Error("Failed to resend booking confirmation", "event_id", event.Id, "error", err)
+		h.respondError(w, http.StatusBadGateway, "The confirmation email could not be sent.")
+		return
+	}
+	h.logger.Info("Resent booking confirmation", "event_id", event.Id)
+	h.respondJSON(w, http.StatusOK, map[string]string{"message": "Confirmation sent to " + clientEmail})
+}
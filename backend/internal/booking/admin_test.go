@@ -0,0 +1,98 @@
+package booking
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/gcal"
+	"ivmanto.com/backend/internal/middleware"
+)
+
+// TestAdminBookings lists, reads, cancels and re-confirms bookings through
+// the admin API mounted behind its bearer token.
+func TestAdminBookings(t *testing.T) {
+	cfg := testConfig()
+	cfg.Booking.Cancellation = config.CancellationPolicy{Cutoff: 24 * time.Hour}
+	h, backend, emails := newTestHandler(t, cfg, Options{})
+	soon := time.Now().Add(3 * time.Hour).UTC().Truncate(time.Minute)
+	later := soon.AddDate(0, 0, 7)
+	for id, start := range map[string]time.Time{"soon": soon, "later": later} {
+		backend.AddEvent(slot(id, start.Format(time.RFC3339), start.Add(30*time.Minute).Format(time.RFC3339)))
+	}
+	for id, who := range map[string]string{"soon": "ada", "later": "bob"} {
+		if _, err := h.gcalSvc.BookSlot(gcal.BookingDetails{EventID: id, Name: who, Email: who + "@example.com", VisitorTimezone: "Europe/Athens"}); err != nil {
+			t.Fatalf("BookSlot(%s) failed: %v", id, err)
+		}
+	}
+
+	adminMux := http.NewServeMux()
+	h.RegisterAdminRoutes(adminMux)
+	server := middleware.RequireBearerToken("secret", adminMux)
+	call := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/bookings", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the token, got %d", rec.Code)
+	}
+
+	rec = call(http.MethodGet, "/api/admin/bookings")
+	var all []AdminBooking
+	if err := json.Unmarshal(rec.Body.Bytes(), &all); err != nil {
+		t.Fatalf("could not decode list (%d): %v", rec.Code, err)
+	}
+	if len(all) != 2 || all[0].ID != "soon" || all[1].ID != "later" {
+		t.Fatalf("expected both bookings in start order, got %+v", all)
+	}
+	if all[0].ClientEmail != "ada@example.com" || all[0].VisitorTimezone != "Europe/Athens" || all[0].Status != BookingUpcoming {
+		t.Errorf("unexpected booking model %+v", all[0])
+	}
+
+	rec = call(http.MethodGet, "/api/admin/bookings?email=BOB@example.com")
+	var filtered []AdminBooking
+	_ = json.Unmarshal(rec.Body.Bytes(), &filtered)
+	if len(filtered) != 1 || filtered[0].ID != "later" {
+		t.Errorf("expected only Bob's booking, got %+v", filtered)
+	}
+	if rec := call(http.MethodGet, "/api/admin/bookings?status=cancelled"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown status, got %d", rec.Code)
+	}
+
+	if rec := call(http.MethodGet, "/api/admin/bookings/later"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for one booking, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(http.MethodGet, "/api/admin/bookings/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown booking, got %d", rec.Code)
+	}
+
+	rec = call(http.MethodPost, "/api/admin/bookings/later/resend-confirmation")
+	if rec.Code != http.StatusOK || len(emails.confirmations) != 1 || emails.confirmations[0].ToEmail != "bob@example.com" {
+		t.Errorf("expected the confirmation to be resent to Bob, got %d and %+v", rec.Code, emails.confirmations)
+	}
+
+	// The admin may cancel inside the cutoff; the response says it was late.
+	rec = call(http.MethodPost, "/api/admin/bookings/soon/cancel")
+	var cancelled adminCancelResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &cancelled); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected the admin cancellation to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !cancelled.Late {
+		t.Error("expected the cancellation to be reported as late")
+	}
+	if at, err := time.Parse(time.RFC3339, backend.Event("soon").ExtendedProperties.Private["late_cancelled_at"]); err != nil || time.Since(at) > time.Minute {
+		t.Errorf("expected the late cancellation to be recorded on the released slot, got %v", backend.Event("soon").ExtendedProperties.Private)
+	}
+	if rec := call(http.MethodGet, "/api/admin/bookings/soon"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected the cancelled booking to be gone, got %d", rec.Code)
+	}
+}
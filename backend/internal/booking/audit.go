@@ -0,0 +1,55 @@
+package booking
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"ivmanto.com/backend/internal/audit"
+)
+
+// recordAudit appends rec to the audit log, if there is one. The action it
+// records has already happened, so a failure is logged rather than returned.
+func (h *Handler) recordAudit(ctx context.Context, rec audit.Record) {
+	if h.auditLog == nil {
+		return
+	}
+	if rec.Time.IsZero() {
+		rec.Time = time.Now().UTC()
+	}
+	if err := h.auditLog.Append(ctx, rec); err != nil {
+		h.logger.Error("Failed to write audit record", "audit_action", rec.Action, "event_id", rec.EventID, "error", err)
+	}
+}
+
+// historyResponse is the audit history of one calendar event.
+type historyResponse struct {
+	EventID string         `json:"eventId"`
+	Records []audit.Record `json:"records"`
+}
+
+// handleAdminBookingHistory returns the audit records about a calendar event,
+// oldest first. Unlike the other admin endpoints it does not look the event
+// up, as the history matters most once the booking is gone.
+func (h *Handler) handleAdminBookingHistory(w http.ResponseWriter, r *http.Request) {
+	if h.auditLog == nil {
+		h.respondError(w, http.StatusNotFound, "The audit log is not enabled.")
+		return
+	}
+	querier, ok := h.auditLog.(audit.Querier)
+	if !ok {
+		h.respondError(w, http.StatusNotImplemented, "The audit log cannot be queried here; search the service logs instead.")
+		return
+	}
+	id := r.PathValue("id")
+	records, err := querier.History(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to read audit history", "event_id", id, "error", err)
+		h.respondError(w, http.StatusInternalServerError, "An internal error occurred while loading the history.")
+		return
+	}
+	if records == nil {
+		records = []audit.Record{}
+	}
+	h.respondJSON(w, http.StatusOK, historyResponse{EventID: id, Records: records})
+}
@@ -0,0 +1,86 @@
+package booking
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"ivmanto.com/backend/internal/audit"
+)
+
+// TestAuditLog_BookAndCancel books and cancels a slot over HTTP and reads the
+// history of the released event back through the admin API.
+func TestAuditLog_BookAndCancel(t *testing.T) {
+	sink, err := audit.NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+	h, backend, _ := newTestHandler(t, nil, Options{AuditLog: sink})
+	backend.AddEvent(slot("slot1", "2030-06-17T13:30:00Z", "2030-06-17T14:00:00Z"))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	h.RegisterAdminRoutes(mux)
+	post := func(path string, body any) *httptest.ResponseRecorder {
+		raw, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(raw))
+		req.Header.Set("X-Forwarded-For", "203.0.113.7, 198.51.100.1")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post("/api/booking/book", createBookingRequest{EventID: "slot1", Name: "Ada", Email: "ada@example.com"}); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	token := backend.Event("slot1").ExtendedProperties.Private["cancellation_token"]
+	if rec := post("/api/booking/cancel", cancelRequest{Token: token}); rec.Code != http.StatusOK {
+		t.Fatalf("expected the cancellation to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/bookings/slot1/history", nil))
+	var history historyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected the history, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(history.Records) != 2 || history.Records[0].Action != audit.ActionBook || history.Records[1].Action != audit.ActionCancel {
+		t.Fatalf("expected a booking and a cancellation, got %+v", history.Records)
+	}
+	booked, cancelled := history.Records[0], history.Records[1]
+	if booked.After == nil || booked.After.ClientEmail != "ada@example.com" || booked.IP != "203.0.113.7" || booked.Actor != audit.ActorClient {
+		t.Errorf("unexpected booking record %+v", booked)
+	}
+	if cancelled.Before == nil || cancelled.Before.ClientEmail != "ada@example.com" || cancelled.Before.Start != "2030-06-17T13:30:00Z" {
+		t.Errorf("expected the cancellation to keep the released booking, got %+v", cancelled.Before)
+	}
+	if backend.Event("slot1").ExtendedProperties.Private["client_email"] != "" {
+		t.Error("expected the calendar event to no longer name the client")
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/bookings/other/history", nil))
+	if rec.Code != http.StatusOK || !bytes.Contains(rec.Body.Bytes(), []byte(`"records":[]`)) {
+		t.Errorf("expected an empty history for an unknown event, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestAuditHistory_Unavailable checks the history endpoint without an audit
+// log and with a sink that cannot be queried.
+func TestAuditHistory_Unavailable(t *testing.T) {
+	for sink, want := range map[audit.Sink]int{
+		nil:                             http.StatusNotFound,
+		audit.NewLogSink(discardLogger): http.StatusNotImplemented,
+	} {
+		h := NewHandler(discardLogger, nil, nil, nil, &testConfig().Booking, Options{AuditLog: sink})
+		mux := http.NewServeMux()
+		h.RegisterAdminRoutes(mux)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/bookings/slot1/history", nil))
+		if rec.Code != want {
+			t.Errorf("expected %d, got %d", want, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package booking
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/gcal"
+)
+
+// rangeStubCalendar serves a fixed set of "Available" events and records
+// how many calendar queries the handler made.
+type rangeStubCalendar struct {
+	gcal.Service
+	loc    *time.Location
+	events []*calendar.Event
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (s *rangeStubCalendar) Location() *time.Location { return s.loc }
+
+func (s *rangeStubCalendar) GetAvailabilityRange(from, to time.Time) ([]*calendar.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	var out []*calendar.Event
+	for _, e := range s.events {
+		start, _ := time.Parse(time.RFC3339, e.Start.DateTime)
+		if !start.Before(from) && start.Before(to) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// TestAvailabilityRange_GroupsByDayWithOneQuery verifies that a from/to
+// request is answered with a single calendar query and that every day in
+// the inclusive range is present, including days without slots.
+func TestAvailabilityRange_GroupsByDayWithOneQuery(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("tzdata not available for Europe/Berlin: %v", err)
+	}
+	cal := &rangeStubCalendar{loc: berlin, events: []*calendar.Event{
+		slot("a", "2026-06-15T10:00:00+02:00", "2026-06-15T10:30:00+02:00"),
+		slot("b", "2026-06-15T15:30:00+02:00", "2026-06-15T16:00:00+02:00"),
+		// 00:30 Berlin time belongs to the 17th even though it is the 16th in UTC.
+		slot("c", "2026-06-17T00:30:00+02:00", "2026-06-17T01:00:00+02:00"),
+	}}
+	h := NewHandler(discardLogger, cal, nil, nil, &config.BookingConfig{}, Options{})
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/booking/availability?from=2026-06-15&to=2026-06-17", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if cal.calls != 1 {
+		t.Errorf("expected 1 calendar query, got %d", cal.calls)
+	}
+	var days []availabilityDay
+	if err := json.Unmarshal(rec.Body.Bytes(), &days); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	want := map[string]int{"2026-06-15": 2, "2026-06-16": 0, "2026-06-17": 1}
+	if len(days) != len(want) {
+		t.Fatalf("expected %d days, got %d: %+v", len(want), len(days), days)
+	}
+	for _, d := range days {
+		if len(d.Slots) != want[d.Date] {
+			t.Errorf("day %s: expected %d slots, got %d", d.Date, want[d.Date], len(d.Slots))
+		}
+	}
+}
+
+// TestAvailableDays_ListsOnlyDaysWithSlots verifies the month overview.
+func TestAvailableDays_ListsOnlyDaysWithSlots(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("tzdata not available for Europe/Berlin: %v", err)
+	}
+	cal := &rangeStubCalendar{loc: berlin, events: []*calendar.Event{
+		slot("a", "2026-06-15T10:00:00+02:00", "2026-06-15T10:30:00+02:00"),
+		slot("b", "2026-06-15T15:30:00+02:00", "2026-06-15T16:00:00+02:00"),
+		slot("c", "2026-06-30T09:00:00+02:00", "2026-06-30T09:30:00+02:00"),
+		slot("d", "2026-07-01T09:00:00+02:00", "2026-07-01T09:30:00+02:00"),
+	}}
+	h := NewHandler(discardLogger, cal, nil, nil, &config.BookingConfig{}, Options{})
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/booking/availability/days?month=2026-06", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp availableDaysResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if len(resp.Days) != 2 || resp.Days[0] != "2026-06-15" || resp.Days[1] != "2026-06-30" {
+		t.Errorf("expected [2026-06-15 2026-06-30], got %v", resp.Days)
+	}
+}
+
+// availabilityFor requests the slots of date in the visitor timezone tz.
+func availabilityFor(t *testing.T, cal gcal.Service, date, tz string) []availabilitySlot {
+	t.Helper()
+	h := NewHandler(discardLogger, cal, nil, nil, &config.BookingConfig{}, Options{})
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/booking/availability?date="+date+"&tz="+tz, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var slots []availabilitySlot
+	if err := json.Unmarshal(rec.Body.Bytes(), &slots); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	return slots
+}
+
+// TestAvailability_VisitorDayOnDSTTransition asks for the days clocks change
+// in the visitor's zone: the day is 23 or 25 hours long and each slot is
+// labelled with the abbreviation in force at its own start.
+func TestAvailability_VisitorDayOnDSTTransition(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("tzdata not available for Europe/Berlin: %v", err)
+	}
+	tests := []struct {
+		name       string
+		date, tz   string
+		events     []*calendar.Event
+		wantIDs    []string
+		wantLabels []string
+		wantLocal  string // StartLocal of the first slot
+	}{
+		{
+			// New York springs forward at 02:00 on 8 March: the day runs
+			// from 05:00 to 04:00 UTC the next day.
+			name: "spring forward",
+			date: "2026-03-08", tz: "America/New_York",
+			events: []*calendar.Event{
+				slot("before", "2026-03-08T04:30:00Z", "2026-03-08T05:00:00Z"),
+				slot("night", "2026-03-08T05:30:00Z", "2026-03-08T06:00:00Z"),
+				slot("afternoon", "2026-03-08T18:00:00Z", "2026-03-08T18:30:00Z"),
+				slot("late", "2026-03-09T03:30:00Z", "2026-03-09T04:00:00Z"),
+				slot("after", "2026-03-09T04:30:00Z", "2026-03-09T05:00:00Z"),
+			},
+			wantIDs:    []string{"night", "afternoon", "late"},
+			wantLabels: []string{"EST", "EDT", "EDT"},
+			wantLocal:  "2026-03-08T00:30:00-05:00",
+		},
+		{
+			// Berlin falls back at 03:00 on 25 October: the day runs from
+			// 22:00 UTC the day before to 23:00 UTC.
+			name: "fall back",
+			date: "2026-10-25", tz: "Europe/Berlin",
+			events: []*calendar.Event{
+				slot("before", "2026-10-24T21:30:00Z", "2026-10-24T22:00:00Z"),
+				slot("morning", "2026-10-24T22:30:00Z", "2026-10-24T23:00:00Z"),
+				slot("evening", "2026-10-25T22:30:00Z", "2026-10-25T23:00:00Z"),
+				slot("after", "2026-10-25T23:00:00Z", "2026-10-25T23:30:00Z"),
+			},
+			wantIDs:    []string{"morning", "evening"},
+			wantLabels: []string{"CEST", "CET"},
+			wantLocal:  "2026-10-25T00:30:00+02:00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slots := availabilityFor(t, &rangeStubCalendar{loc: berlin, events: tt.events}, tt.date, tt.tz)
+			if len(slots) != len(tt.wantIDs) {
+				t.Fatalf("expected slots %v, got %+v", tt.wantIDs, slots)
+			}
+			for i, slot := range slots {
+				if slot.ID != tt.wantIDs[i] || slot.TimezoneLabel != tt.wantLabels[i] || slot.Timezone != tt.tz {
+					t.Errorf("slot %d: expected %s in %s (%s), got %s in %s (%s)", i, tt.wantIDs[i], tt.tz, tt.wantLabels[i], slot.ID, slot.Timezone, slot.TimezoneLabel)
+				}
+			}
+			if slots[0].StartLocal != tt.wantLocal {
+				t.Errorf("expected the first slot to start at %s locally, got %s", tt.wantLocal, slots[0].StartLocal)
+			}
+		})
+	}
+}
+
+// TestAvailability_LargeOffset asks for a day in Kiritimati (UTC+14) of a
+// calendar in Los Angeles (UTC-7 in summer): the visitor's day is almost
+// entirely the calendar's previous day.
+func TestAvailability_LargeOffset(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("tzdata not available for America/Los_Angeles: %v", err)
+	}
+	cal := &rangeStubCalendar{loc: la, events: []*calendar.Event{
+		slot("early", "2026-06-15T02:00:00-07:00", "2026-06-15T02:30:00-07:00"),
+		slot("morning", "2026-06-15T09:00:00-07:00", "2026-06-15T09:30:00-07:00"),
+		slot("next", "2026-06-16T09:00:00-07:00", "2026-06-16T09:30:00-07:00"),
+	}}
+
+	slots := availabilityFor(t, cal, "2026-06-16", "Pacific/Kiritimati")
+	if len(slots) != 1 || slots[0].ID != "morning" {
+		t.Fatalf("expected only the calendar's 15 June morning slot, got %+v", slots)
+	}
+	got := slots[0]
+	if got.StartLocal != "2026-06-16T06:00:00+14:00" || got.StartUTC != "2026-06-15T16:00:00Z" || got.EndUTC != "2026-06-15T16:30:00Z" {
+		t.Errorf("unexpected timestamps %+v", got)
+	}
+	if got.Start != "2026-06-15T09:00:00-07:00" {
+		t.Errorf("expected the stored start to be kept, got %s", got.Start)
+	}
+
+	// An unknown zone falls back to the calendar's own days.
+	slots = availabilityFor(t, cal, "2026-06-16", "Mars/Olympus_Mons")
+	if len(slots) != 1 || slots[0].ID != "next" || slots[0].Timezone != "America/Los_Angeles" || slots[0].TimezoneLabel != "PDT" {
+		t.Errorf("expected the calendar's 16 June slot in its own timezone, got %+v", slots)
+	}
+}
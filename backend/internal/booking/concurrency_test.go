@@ -0,0 +1,99 @@
+package booking
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/gcal"
+	"ivmanto.com/backend/internal/gcal/gcaltest"
+)
+
+// TestCreateBooking_ConcurrentRequestsOnlyOneWins fires N parallel bookings
+// for the same "Available" slot against a fake Calendar backend that
+// enforces If-Match the way the real API does. Exactly one request may
+// succeed; every other one must see 409 from /api/booking/book.
+func TestCreateBooking_ConcurrentRequestsOnlyOneWins(t *testing.T) {
+	const parallel = 10
+
+	h, backend, _ := newTestHandler(t, nil, Options{})
+	backend.AddEvent(slot("slot1", "2026-06-15T15:30:00+02:00", "2026-06-15T16:00:00+02:00"))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	var wg sync.WaitGroup
+	codes := make([]int, parallel)
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body, _ := json.Marshal(createBookingRequest{
+				EventID: "slot1",
+				Name:    fmt.Sprintf("Visitor %d", i),
+				Email:   fmt.Sprintf("visitor%d@example.com", i),
+			})
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/booking/book", bytes.NewReader(body)))
+			codes[i] = rec.Code
+		}(i)
+	}
+	wg.Wait()
+
+	var created, conflicts int
+	for _, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+			conflicts++
+		default:
+			t.Errorf("unexpected status %d", code)
+		}
+	}
+	if created != 1 || conflicts != parallel-1 {
+		t.Errorf("expected 1 booking and %d conflicts, got %d and %d (codes %v)", parallel-1, created, conflicts, codes)
+	}
+
+	booked := backend.Event("slot1")
+	if booked.ExtendedProperties == nil || booked.ExtendedProperties.Private["client_email"] == "" {
+		t.Errorf("expected the slot to be booked, got %+v", booked)
+	}
+}
+
+// TestCancelBooking_RevertsSlotOnce verifies a cancellation against the fake
+// backend, which now receives If-Match on the revert: the slot becomes
+// available again and a repeated cancellation finds no booking.
+func TestCancelBooking_RevertsSlotOnce(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	backend.AddEvent(&calendar.Event{
+		Id:      "slot1",
+		Summary: "AfB",
+		Start:   &calendar.EventDateTime{DateTime: "2030-06-17T15:30:00+02:00"},
+		End:     &calendar.EventDateTime{DateTime: "2030-06-17T16:00:00+02:00"},
+	})
+	gcalSvc := gcal.NewServiceWithClient(backend.CalendarService(t), testConfig(), time.UTC)
+
+	booked, err := gcalSvc.BookSlot(gcal.BookingDetails{EventID: "slot1", Name: "Visitor", Email: "visitor@example.com"})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	token := booked.ExtendedProperties.Private["cancellation_token"]
+
+	// Two cancellations of the same booking: the first wins, the second
+	// no longer finds a booking with that token.
+	if _, err := gcalSvc.CancelBooking(t.Context(), token); err != nil {
+		t.Fatalf("first CancelBooking failed: %v", err)
+	}
+	if _, err := gcalSvc.CancelBooking(t.Context(), token); err != gcal.ErrSlotNotFound {
+		t.Errorf("expected ErrSlotNotFound for second cancellation, got %v", err)
+	}
+	if got := backend.Event("slot1").Summary; got != "AfB" {
+		t.Errorf("expected slot to be available again, got summary %q", got)
+	}
+}
@@ -0,0 +1,107 @@
+package booking
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ivmanto.com/backend/internal/audit"
+	"ivmanto.com/backend/internal/email"
+	"ivmanto.com/backend/internal/gcal"
+)
+
+// maxFeedbackComment is the longest feedback comment accepted, in characters.
+// The comment is kept in a private extended property of the booked event.
+const maxFeedbackComment = gcal.MaxPropertyValue
+
+type feedbackRequest struct {
+	// Token is the signed token from the follow-up email's feedback link.
+	Token   string `json:"token"`
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// handleSubmitFeedback records the client's feedback on the booked event of a
+// past consultation and forwards it to the admin. Each booking takes feedback
+// once.
+func (h *Handler) handleSubmitFeedback(w http.ResponseWriter, r *http.Request) {
+	if !h.tokens.Enabled() {
+		h.respondError(w, http.StatusNotFound, "Feedback is not enabled.")
+		return
+	}
+	var req feedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Rating < 1 || req.Rating > 5 {
+		h.respondError(w, http.StatusBadRequest, "rating must be between 1 and 5")
+		return
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if utf8.RuneCountInString(comment) > maxFeedbackComment {
+		h.respondError(w, http.StatusBadRequest, "comment is too long")
+		return
+	}
+
+	event, err := h.gcalSvc.FeedbackBooking(r.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, gcal.ErrSlotNotFound) {
+			h.respondError(w, http.StatusNotFound, "Booking not found. The link may be invalid or expired.")
+			return
+		}
+		h.logger.Error("Failed to get booking for feedback", "token_prefix", tokenPrefix(req.Token), "error", err)
+		h.respondError(w, http.StatusInternalServerError, "An internal error occurred while saving your feedback.")
+		return
+	}
+	start, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+	end, _ := time.Parse(time.RFC3339, event.End.DateTime)
+	if time.Now().Before(end) {
+		h.respondError(w, http.StatusConflict, "Feedback can be given once the consultation has taken place.")
+		return
+	}
+
+	name, clientEmail, _ := bookingClient(event)
+	fb := gcal.Feedback{Rating: req.Rating, Comment: comment, SubmittedAt: time.Now().UTC()}
+	if _, err := h.gcalSvc.RecordFeedback(r.Context(), event, fb); err != nil {
+		switch {
+		case errors.Is(err, gcal.ErrFeedbackExists):
+			h.respondError(w, http.StatusConflict, "We have already received your feedback on this consultation.")
+		case errors.Is(err, gcal.ErrBookingChanged):
+			h.respondError(w, http.StatusConflict, "Your feedback could not be saved. Please try again.")
+		default:
+			h.logger.Error("Failed to record feedback", "event_id", event.Id, "error", err)
+			h.respondError(w, http.StatusInternalServerError, "An internal error occurred while saving your feedback.")
+		}
+		return
+	}
+	h.logger.Info("Feedback received", "event_id", event.Id, "rating", req.Rating)
+	h.recordAudit(r.Context(), audit.Record{
+		Action:  audit.ActionFeedback,
+		EventID: event.Id,
+		Actor:   audit.ActorClient,
+		IP:      audit.ClientIP(r),
+		Details: map[string]string{"rating": strconv.Itoa(req.Rating)},
+	})
+
+	details := email.FeedbackNotificationDetails{
+		EventID:     event.Id,
+		ClientName:  name,
+		ClientEmail: clientEmail,
+		StartTime:   start.In(h.gcalSvc.Location()),
+		SessionName: h.sessionTypeOf(event).Name,
+		Rating:      fb.Rating,
+		Comment:     fb.Comment,
+	}
+	go func() {
+		if err := h.emailSvc.SendFeedbackToAdmin(details); err != nil {
+			h.logger.Error("Failed to send feedback notification to admin", "event_id", details.EventID, "error", err)
+		}
+	}()
+
+	h.respondJSON(w, http.StatusCreated, map[string]string{"message": "Thank you for your feedback!"})
+}
@@ -0,0 +1,136 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"ivmanto.com/backend/internal/bookingtoken"
+	"ivmanto.com/backend/internal/email"
+	"ivmanto.com/backend/internal/gcal"
+)
+
+// feedbackLinkTTL is how long the feedback link in a follow-up email works.
+const feedbackLinkTTL = 30 * 24 * time.Hour
+
+// followUpLookback bounds how late a follow-up may still go out. Bookings
+// whose follow-up was due longer ago are skipped, so enabling follow-ups, or
+// an outage, does not thank clients for consultations long past.
+const followUpLookback = 48 * time.Hour
+
+// FollowUps thanks clients by email a while after their consultation ended,
+// BookingConfig.FollowUpDelay, and links to the feedback form. Clients the
+// admin flagged as no-shows are skipped.
+//
+// As for reminders, the follow-up is recorded on the calendar event with a
+// compare-and-swap before the email goes out, so it is sent at most once
+// across restarts and instances.
+type FollowUps struct {
+	h        *Handler
+	delay    time.Duration
+	interval time.Duration
+	now      func() time.Time
+	stopCh   chan struct{}
+}
+
+// NewFollowUps creates the follow-up job for the bookings managed by h.
+func NewFollowUps(h *Handler) *FollowUps {
+	return &FollowUps{
+		h:        h,
+		delay:    h.cfg.FollowUpDelay,
+		interval: h.cfg.ReminderInterval,
+		now:      time.Now,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start looks for due follow-ups every interval in a background goroutine
+// until Stop is called. It does nothing when follow-ups are disabled or there
+// are no booking token keys to sign the feedback links with.
+func (f *FollowUps) Start() {
+	if f.delay == 0 || !f.h.tokens.Enabled() {
+		f.h.logger.Info("Booking follow-ups are disabled")
+		return
+	}
+	f.h.logger.Info("Starting booking follow-ups", "delay", f.delay, "interval", f.interval)
+	go func() {
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), f.interval)
+				f.sendDue(ctx)
+				cancel()
+			case <-f.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop shuts down the background goroutine started by Start.
+func (f *FollowUps) Stop() {
+	close(f.stopCh)
+}
+
+// sendDue sends the follow-ups that are due now.
+func (f *FollowUps) sendDue(ctx context.Context) {
+	now := f.now()
+	dueBy := now.Add(-f.delay)
+	// A booking starts before it ends, so every due one started before dueBy.
+	bookings, err := f.h.gcalSvc.ListBookings(dueBy.Add(-followUpLookback), dueBy)
+	if err != nil {
+		f.h.logger.Error("Failed to list past bookings for follow-ups", "error", err)
+		return
+	}
+
+	for _, event := range bookings {
+		end, err := time.Parse(time.RFC3339, event.End.DateTime)
+		if err != nil {
+			f.h.logger.Error("Could not parse end time of booking for follow-up", "event_id", event.Id, "error", err)
+			continue
+		}
+		if end.After(dueBy) || gcal.FollowUpSent(event) || gcal.NoShow(event) {
+			continue
+		}
+
+		marked, err := f.h.gcalSvc.MarkFollowUpSent(ctx, event)
+		if errors.Is(err, gcal.ErrBookingChanged) {
+			f.h.logger.Info("Booking changed while sending follow-up, skipping", "event_id", event.Id)
+			continue
+		}
+		if err != nil {
+			f.h.logger.Error("Failed to record follow-up, not sending it", "event_id", event.Id, "error", err)
+			continue
+		}
+
+		token, err := f.h.tokens.Sign(bookingtoken.Claims{
+			EventID: marked.Id,
+			Binding: bookingtoken.Binding(marked.ExtendedProperties.Private["cancellation_token"]),
+			Scopes:  []bookingtoken.Scope{bookingtoken.ScopeFeedback},
+			Expires: now.Add(feedbackLinkTTL),
+		})
+		if err != nil {
+			f.h.logger.Error("Could not sign feedback token", "event_id", event.Id, "error", err)
+			continue
+		}
+		name, clientEmail, visitorTZ := bookingClient(marked)
+		start, _ := time.Parse(time.RFC3339, marked.Start.DateTime)
+		details := email.BookingFollowUpDetails{
+			ToName:      name,
+			ToEmail:     clientEmail,
+			StartTime:   start.In(resolveVisitorTimezone(visitorTZ, f.h.gcalSvc.Location())),
+			SessionName: f.h.sessionTypeOf(marked).Name,
+			FeedbackURL: fmt.Sprintf("https://ivmanto.com/booking/feedback?token=%s", url.QueryEscape(token)),
+		}
+		if err := f.h.emailSvc.SendBookingFollowUp(details); err != nil {
+			f.h.logger.Error("Failed to send booking follow-up", "event_id", event.Id, "client_email", clientEmail, "error", err)
+			continue
+		}
+		f.h.logger.Info("Sent booking follow-up", "event_id", event.Id)
+	}
+}
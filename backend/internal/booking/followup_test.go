@@ -0,0 +1,130 @@
+package booking
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/bookingtoken"
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/middleware"
+)
+
+// TestFollowUps_FeedbackAndNoShows runs the follow-up job over bookings that
+// ended at different times, one of them flagged as a no-show through the
+// admin API, and submits feedback through the link the client was sent.
+func TestFollowUps_FeedbackAndNoShows(t *testing.T) {
+	cfg := testConfig()
+	cfg.Booking.FollowUpDelay = 2 * time.Hour
+	cfg.Booking.Tokens = config.TokenConfig{Keys: []config.TokenKey{{ID: "k1", Secret: strings.Repeat("s", 32)}}}
+	cfg.Booking.ReminderInterval = time.Minute
+	h, backend, emails := newTestHandler(t, cfg, Options{})
+	now := time.Now().UTC().Truncate(time.Minute)
+	for id, end := range map[string]time.Time{
+		"done":   now.Add(-3 * time.Hour),
+		"noshow": now.Add(-3 * time.Hour),
+		"recent": now.Add(-30 * time.Minute),
+		"soon":   now.Add(5 * time.Hour),
+	} {
+		backend.AddEvent(&calendar.Event{
+			Id:      id,
+			Summary: "Consultation with " + id,
+			Start:   &calendar.EventDateTime{DateTime: end.Add(-30 * time.Minute).Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: end.Format(time.RFC3339)},
+			ExtendedProperties: &calendar.EventExtendedProperties{Private: map[string]string{
+				"client_name":        id,
+				"client_email":       id + "@example.com",
+				"visitor_timezone":   "Europe/Athens",
+				"cancellation_token": id + "-secret",
+			}},
+		})
+	}
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	adminMux := http.NewServeMux()
+	h.RegisterAdminRoutes(adminMux)
+	admin := middleware.RequireBearerToken("admin", adminMux)
+	callAdmin := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer admin")
+		rec := httptest.NewRecorder()
+		admin.ServeHTTP(rec, req)
+		return rec
+	}
+	submit := func(token string, rating int) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(feedbackRequest{Token: token, Rating: rating, Comment: "Very helpful."})
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/booking/feedback", bytes.NewReader(body)))
+		return rec
+	}
+
+	if rec := callAdmin(http.MethodPost, "/api/admin/bookings/soon/no-show"); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 flagging an upcoming booking, got %d", rec.Code)
+	}
+	rec := callAdmin(http.MethodPost, "/api/admin/bookings/noshow/no-show")
+	var flagged AdminBooking
+	if err := json.Unmarshal(rec.Body.Bytes(), &flagged); err != nil || !flagged.NoShow {
+		t.Fatalf("expected the booking to be flagged as a no-show, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// A restarted job does not send the follow-up again.
+	for range 2 {
+		NewFollowUps(h).sendDue(t.Context())
+	}
+	if len(emails.followUps) != 1 || emails.followUps[0].ToEmail != "done@example.com" {
+		t.Fatalf("expected a single follow-up for the finished booking, got %+v", emails.followUps)
+	}
+	sent := emails.followUps[0]
+	if sent.StartTime.Location().String() != "Europe/Athens" {
+		t.Errorf("expected the follow-up in the visitor's timezone, got %v", sent.StartTime.Location())
+	}
+	link, err := url.Parse(sent.FeedbackURL)
+	if err != nil {
+		t.Fatalf("invalid feedback URL %q: %v", sent.FeedbackURL, err)
+	}
+	token := link.Query().Get("token")
+
+	if rec := submit(token, 0); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a rating of 0, got %d", rec.Code)
+	}
+	if rec := submit(token+"x", 5); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a tampered token, got %d", rec.Code)
+	}
+	signer := bookingtoken.NewSigner(cfg.Booking.Tokens)
+	sign := func(eventID string, scope bookingtoken.Scope) string {
+		token, _ := signer.Sign(bookingtoken.Claims{EventID: eventID, Binding: bookingtoken.Binding(eventID + "-secret"), Scopes: []bookingtoken.Scope{scope}, Expires: now.Add(time.Hour)})
+		return token
+	}
+	if rec := submit(sign("done", bookingtoken.ScopeView), 5); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a token without the feedback scope, got %d", rec.Code)
+	}
+	if rec := submit(sign("soon", bookingtoken.ScopeFeedback), 5); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a consultation that has not taken place, got %d", rec.Code)
+	}
+	if rec := submit(token, 5); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := submit(token, 1); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for repeated feedback, got %d", rec.Code)
+	}
+
+	emails.wait(t, func() bool { return len(emails.feedback) > 0 })
+	if notified := emails.feedback[0]; notified.EventID != "done" || notified.Rating != 5 || notified.Comment != "Very helpful." {
+		t.Errorf("unexpected admin notification %+v", notified)
+	}
+
+	rec = callAdmin(http.MethodGet, "/api/admin/bookings/done")
+	var done AdminBooking
+	if err := json.Unmarshal(rec.Body.Bytes(), &done); err != nil {
+		t.Fatalf("could not decode booking: %v", err)
+	}
+	if done.Feedback == nil || done.Feedback.Rating != 5 || done.Feedback.Comment != "Very helpful." || done.ClientEmail != "done@example.com" {
+		t.Errorf("expected the feedback on the booking, got %+v", done.Feedback)
+	}
+}
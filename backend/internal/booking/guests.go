@@ -0,0 +1,84 @@
+package booking
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ivmanto.com/backend/internal/audit"
+	"ivmanto.com/backend/internal/gcal"
+)
+
+// cleanGuests checks the guest list of a booking request and returns it with
+// duplicates and the client's own address dropped. Each guest must be a bare
+// email address; at most max guests are allowed, and together they must fit
+// into the event property they are kept in.
+func cleanGuests(guests []string, clientEmail string, max int) ([]string, error) {
+	var cleaned []string
+	for _, guest := range guests {
+		guest = strings.TrimSpace(guest)
+		if guest == "" {
+			continue
+		}
+		addr, err := mail.ParseAddress(guest)
+		// Guests are stored comma-separated on the event.
+		if err != nil || addr.Address != guest || strings.Contains(guest, ",") {
+			return nil, fmt.Errorf("Invalid guest email address: %q", guest)
+		}
+		if strings.EqualFold(guest, clientEmail) || slices.ContainsFunc(cleaned, func(g string) bool { return strings.EqualFold(g, guest) }) {
+			continue
+		}
+		cleaned = append(cleaned, guest)
+	}
+	if len(cleaned) > max {
+		if max == 0 {
+			return nil, errors.New("Guests cannot be added to bookings")
+		}
+		return nil, fmt.Errorf("At most %d guests can be added to a booking", max)
+	}
+	if utf8.RuneCountInString(strings.Join(cleaned, ",")) > gcal.MaxPropertyValue {
+		return nil, errors.New("The guest email addresses are too long")
+	}
+	return cleaned, nil
+}
+
+// handleAdminRemoveGuest takes a guest off a booking, e.g. when the client
+// asks for a colleague to be uninvited. Only the admin can do this; guests
+// have no link to manage the booking.
+func (h *Handler) handleAdminRemoveGuest(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.getAdminBooking(w, r)
+	if !ok {
+		return
+	}
+	guest := r.PathValue("email")
+	updated, err := h.gcalSvc.RemoveGuest(r.Context(), event.Id, guest)
+	if err != nil {
+		h.logger.Error("Failed to remove guest", "event_id", event.Id, "error", err)
+		switch {
+		case errors.Is(err, gcal.ErrSlotNotFound):
+			h.respondError(w, http.StatusNotFound, "Booking not found.")
+		case errors.Is(err, gcal.ErrGuestNotFound):
+			h.respondError(w, http.StatusNotFound, "This guest is not invited to the booking.")
+		case errors.Is(err, gcal.ErrBookingChanged):
+			h.respondError(w, http.StatusConflict, "The booking changed while it was being updated. Please reload and try again.")
+		default:
+			h.respondError(w, http.StatusInternalServerError, "An internal error occurred while updating the booking.")
+		}
+		return
+	}
+	h.logger.Info("Removed guest from booking", "event_id", event.Id)
+	h.recordAudit(r.Context(), audit.Record{
+		Action:  audit.ActionRemoveGuest,
+		EventID: event.Id,
+		Actor:   audit.ActorAdmin,
+		IP:      audit.ClientIP(r),
+		Before:  audit.SnapshotOf(event),
+		After:   audit.SnapshotOf(updated),
+	})
+	h.respondJSON(w, http.StatusOK, h.adminBooking(updated, time.Now()))
+}
@@ -0,0 +1,92 @@
+package booking
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ivmanto.com/backend/internal/gcal"
+)
+
+func TestCleanGuests(t *testing.T) {
+	tests := []struct {
+		name    string
+		guests  []string
+		max     int
+		want    string
+		wantErr string
+	}{
+		{name: "none", max: 2},
+		{name: "dedupes and drops the client", guests: []string{" bob@example.com", "BOB@example.com", "ada@example.com", ""}, max: 1, want: "bob@example.com"},
+		{name: "too many", guests: []string{"bob@example.com", "cy@example.com"}, max: 1, wantErr: "At most 1 guests"},
+		{name: "disabled", guests: []string{"bob@example.com"}, max: 0, wantErr: "cannot be added"},
+		{name: "display name", guests: []string{"Bob <bob@example.com>"}, max: 2, wantErr: "Invalid guest"},
+		{name: "not an address", guests: []string{"bob"}, max: 2, wantErr: "Invalid guest"},
+		{name: "too long together", guests: []string{strings.Repeat("b", 500) + "@example.com", strings.Repeat("c", 500) + "@example.com"}, max: 2, wantErr: "too long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cleanGuests(tt.guests, "ada@example.com", tt.max)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("expected %q, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestGuests_BookAndRemove books a slot with a guest over HTTP, then has the
+// admin take the guest off again.
+func TestGuests_BookAndRemove(t *testing.T) {
+	cfg := testConfig()
+	cfg.Booking.MaxGuests = 2
+	h, backend, _ := newTestHandler(t, cfg, Options{})
+	backend.AddEvent(slot("slot1", "2030-06-17T13:30:00Z", "2030-06-17T14:00:00Z"))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	h.RegisterAdminRoutes(mux)
+	book := func(guests ...string) *httptest.ResponseRecorder {
+		raw, _ := json.Marshal(createBookingRequest{EventID: "slot1", Name: "Ada", Email: "ada@example.com", Guests: guests})
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/booking/book", bytes.NewReader(raw)))
+		return rec
+	}
+
+	if rec := book("bob@example.com", "cy@example.com", "dee@example.com"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected too many guests to be rejected, got %d", rec.Code)
+	}
+	rec := book("bob@example.com")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var booked Booking
+	if err := json.Unmarshal(rec.Body.Bytes(), &booked); err != nil || len(booked.Guests) != 1 || booked.Guests[0] != "bob@example.com" {
+		t.Fatalf("expected the guest in the response, got %s", rec.Body.String())
+	}
+
+	remove := func(guest string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/admin/bookings/slot1/guests/"+guest, nil))
+		return rec
+	}
+	if rec := remove("bob@example.com"); rec.Code != http.StatusOK {
+		t.Fatalf("expected the guest to be removed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if guests := gcal.Guests(backend.Event("slot1")); len(guests) != 0 {
+		t.Errorf("expected no guests left, got %v", guests)
+	}
+	if rec := remove("bob@example.com"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a guest not on the booking, got %d", rec.Code)
+	}
+}
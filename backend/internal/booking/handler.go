@@ -6,13 +6,21 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"google.golang.org/api/calendar/v3"
 	"ivmanto.com/backend/internal/analytics"
+	"ivmanto.com/backend/internal/audit"
+	"ivmanto.com/backend/internal/bookingtoken"
+	"ivmanto.com/backend/internal/conferencing"
+	"ivmanto.com/backend/internal/config"
 	"ivmanto.com/backend/internal/email"
 	"ivmanto.com/backend/internal/gcal"
+	"ivmanto.com/backend/internal/middleware"
+	"ivmanto.com/backend/internal/webhook"
 )
 
 // Handler manages booking-related HTTP requests.
@@ -21,46 +29,93 @@ type Handler struct {
 	gcalSvc    gcal.Service
 	emailSvc   email.Service
 	trackerSvc *analytics.Tracker
+	cfg        *config.BookingConfig
+	waitlist   *Waitlist // nil disables the waitlist
+	tokens     *bookingtoken.Signer
+	auditLog   audit.Sink // nil disables the audit log
+	webhooks   *webhook.Dispatcher
+	joinLimit  *middleware.RateLimiter
+	holdLimit  *middleware.RateLimiter
+	holds      *clientHolds
+}
+
+// Options are the optional dependencies of a Handler. A nil field disables
+// the feature it provides.
+type Options struct {
+	// Waitlist offers slots that open up to visitors waiting for them.
+	Waitlist *Waitlist
+	// AuditLog records the history of each booking.
+	AuditLog audit.Sink
+	// Webhooks tells external systems about booking changes.
+	Webhooks *webhook.Dispatcher
 }
 
 // NewHandler creates a new booking handler.
-func NewHandler(logger *slog.Logger, gcalSvc gcal.Service, emailSvc email.Service, trackerSvc *analytics.Tracker) *Handler {
+func NewHandler(logger *slog.Logger, gcalSvc gcal.Service, emailSvc email.Service, trackerSvc *analytics.Tracker, cfg *config.BookingConfig, opts Options) *Handler {
 	return &Handler{
 		logger:     logger,
 		gcalSvc:    gcalSvc,
 		emailSvc:   emailSvc,
 		trackerSvc: trackerSvc,
+		cfg:        cfg,
+		waitlist:   opts.Waitlist,
+		tokens:     bookingtoken.NewSigner(cfg.Tokens),
+		auditLog:   opts.AuditLog,
+		webhooks:   opts.Webhooks,
+		joinLimit:  middleware.NewRateLimiter(waitlistJoinLimit, time.Hour),
+		holdLimit:  middleware.NewRateLimiter(holdLimit, time.Hour),
+		holds:      newClientHolds(),
 	}
 }
 
 // RegisterRoutes sets up the routing for booking endpoints.
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle("POST /api/booking/hold", middleware.RateLimit(h.holdLimit, audit.ClientIP, http.HandlerFunc(h.handleHoldSlot)))
 	mux.HandleFunc("POST /api/booking/book", h.handleCreateBooking)
 	mux.HandleFunc("GET /api/booking/availability", h.handleGetAvailability)
+	mux.HandleFunc("GET /api/booking/availability/days", h.handleGetAvailableDays)
+	mux.HandleFunc("GET /api/booking/session-types", h.handleGetSessionTypes)
+	mux.HandleFunc("GET /api/booking/conferencing", h.handleGetConferencing)
+	mux.HandleFunc("GET /api/booking/manage", h.handleGetBooking)
+	mux.HandleFunc("GET /api/booking/manage/ics", h.handleGetBookingICS)
 	mux.HandleFunc("POST /api/booking/cancel", h.handleCancelBooking)
+	mux.HandleFunc("POST /api/booking/reschedule", h.handleRescheduleBooking)
+	mux.Handle("POST /api/booking/waitlist", middleware.RateLimit(h.joinLimit, audit.ClientIP, http.HandlerFunc(h.handleJoinWaitlist)))
+	mux.HandleFunc("POST /api/booking/feedback", h.handleSubmitFeedback)
 }
 
-// getMeetLink robustly extracts the Google Meet link from a calendar event.
-// It first checks the primary `HangoutLink` field. If that is empty, it iterates
-// through the `ConferenceData` entry points to find the video link. This is
-// necessary because the `HangoutLink` is not always populated immediately.
-func getMeetLink(event *calendar.Event) string {
-	if event.HangoutLink != "" {
-		return event.HangoutLink
-	}
-
-	if event.ConferenceData != nil {
-		for _, entryPoint := range event.ConferenceData.EntryPoints {
-			if entryPoint.EntryPointType == "video" {
-				return entryPoint.Uri
-			}
-		}
+// conferenceName names the provider hosting the video conference of event,
+// e.g. "Zoom", or returns "" if the event has no conference link.
+func conferenceName(event *calendar.Event) string {
+	if conferencing.Link(event) == "" {
+		return ""
 	}
-	return "" // Return empty if no link is found
+	return conferencing.Name(event)
 }
 
 type cancelRequest struct {
 	Token string `json:"token"`
+	// EventID optionally names another occurrence of the recurring series
+	// of the booking to cancel; the token of any occurrence allows it.
+	EventID string `json:"eventId,omitempty"`
+	// Series cancels every occurrence of the booking's recurring series that
+	// can still be cancelled.
+	Series bool `json:"series,omitempty"`
+}
+
+// cancelResponse confirms a cancellation along with the policy that applied.
+type cancelResponse struct {
+	Message      string           `json:"message"`
+	Cancellation cancellationInfo `json:"cancellation"`
+}
+
+type rescheduleRequest struct {
+	Token string `json:"token"`
+	// EventID is the ID of the new "Available" slot, as returned by the availability endpoint.
+	EventID string `json:"eventId"`
+	// WaitlistToken is the token of a waitlist priority booking link. It is
+	// needed to move to a slot that is reserved for waitlisted visitors.
+	WaitlistToken string `json:"waitlistToken,omitempty"`
 }
 
 // respondJSON is a helper to write a JSON response.
@@ -113,11 +168,24 @@ func (h *Handler) handleCancelBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.logger.Info("Received cancellation request", "token_prefix", req.Token[:8])
+	h.logger.Info("Received cancellation request", "token_prefix", tokenPrefix(req.Token), "event_id", req.EventID, "series", req.Series)
+	if req.Series {
+		h.handleCancelSeries(w, r, req.Token)
+		return
+	}
 
-	originalEvent, err := h.gcalSvc.CancelBooking(r.Context(), req.Token)
+	var originalEvent *calendar.Event
+	var err error
+	if req.EventID != "" {
+		originalEvent, err = h.gcalSvc.CancelOccurrence(r.Context(), req.Token, req.EventID)
+	} else {
+		originalEvent, err = h.gcalSvc.CancelBooking(r.Context(), req.Token)
+	}
 	if err != nil {
-		h.logger.Error("Failed to cancel booking", "token_prefix", req.Token[:8], "error", err)
+		h.logger.Error("Failed to cancel booking", "token_prefix", tokenPrefix(req.Token), "error", err)
+		if h.respondPolicyError(w, r, req.Token, err, "cancelled") {
+			return
+		}
 		if errors.Is(err, gcal.ErrSlotNotFound) {
 			h.respondError(w, http.StatusNotFound, "Booking not found. The link may be invalid or expired.")
 			return
@@ -127,6 +195,31 @@ func (h *Handler) handleCancelBooking(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.logger.Info("Booking cancelled successfully", "event_id", originalEvent.Id)
+	h.recordAudit(r.Context(), audit.Record{
+		Action:  audit.ActionCancel,
+		EventID: originalEvent.Id,
+		Actor:   audit.ActorClient,
+		IP:      audit.ClientIP(r),
+		Before:  audit.SnapshotOf(originalEvent),
+	})
+	cancelled := h.bookingWebhookOf(originalEvent)
+	cancelled.CancelledBy = audit.ActorClient
+	h.webhooks.Send(webhook.EventBookingCancelled, cancelled)
+	startTime, visitorLoc := h.notifyCancellation(originalEvent)
+
+	h.respondJSON(w, http.StatusOK, cancelResponse{
+		Message:      "Booking cancelled successfully",
+		Cancellation: h.cancellationInfo(startTime, visitorLoc),
+	})
+}
+
+// notifyCancellation tells the waitlist and emails the client and the admin
+// about a cancelled booking. It returns the start of the booking and the
+// visitor's timezone it was rendered in.
+func (h *Handler) notifyCancellation(originalEvent *calendar.Event) (time.Time, *time.Location) {
+	if h.waitlist != nil {
+		h.waitlist.SlotsChanged()
+	}
 
 	// Extract details for notifications
 	var clientName, clientEmail string
@@ -164,50 +257,241 @@ func (h *Handler) handleCancelBooking(w http.ResponseWriter, r *http.Request) {
 			h.logger.Error("Failed to send cancellation notification to admin", "error", err)
 		}
 	}()
+	return startTime, visitorLoc
+}
+
+// maxAvailabilityRangeDays caps the from/to window of a range query so a
+// single request cannot make us page through the whole calendar.
+const maxAvailabilityRangeDays = 62
+
+// availabilitySlot is the shape of a single slot the frontend expects. Start
+// and End are as stored in the calendar; StartUTC and EndUTC are the slot in
+// UTC and StartLocal and EndLocal the same instants in the visitor's Timezone.
+type availabilitySlot struct {
+	Start           string `json:"start"`
+	ID              string `json:"id"`
+	End             string `json:"end"`
+	StartUTC        string `json:"startUtc"`
+	EndUTC          string `json:"endUtc"`
+	StartLocal      string `json:"startLocal"`
+	EndLocal        string `json:"endLocal"`
+	Timezone        string `json:"timezone"`      // IANA name, e.g. "Asia/Tokyo"
+	TimezoneLabel   string `json:"timezoneLabel"` // abbreviation at the slot start, e.g. "JST"
+	SessionType     string `json:"sessionType"`
+	SessionName     string `json:"sessionName"`
+	DurationMinutes int    `json:"durationMinutes"`
+}
+
+// availabilityDay groups the slots that start on one calendar day.
+type availabilityDay struct {
+	Date  string             `json:"date"`
+	Slots []availabilitySlot `json:"slots"`
+}
+
+// availableDaysResponse lists the days of a month with at least one free slot.
+type availableDaysResponse struct {
+	Month string   `json:"month"`
+	Days  []string `json:"days"`
+}
+
+// toAvailabilitySlot describes an available event to a visitor in loc. The
+// timezone label is taken at the slot's own start, so it follows DST.
+func (h *Handler) toAvailabilitySlot(event *calendar.Event, loc *time.Location) availabilitySlot {
+	st := h.sessionTypeOf(event)
+	start, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+	end, _ := time.Parse(time.RFC3339, event.End.DateTime)
+	return availabilitySlot{
+		Start:           event.Start.DateTime,
+		ID:              event.Id,
+		End:             event.End.DateTime,
+		StartUTC:        start.UTC().Format(time.RFC3339),
+		EndUTC:          end.UTC().Format(time.RFC3339),
+		StartLocal:      start.In(loc).Format(time.RFC3339),
+		EndLocal:        end.In(loc).Format(time.RFC3339),
+		Timezone:        loc.String(),
+		TimezoneLabel:   start.In(loc).Format("MST"),
+		SessionType:     st.ID,
+		SessionName:     st.Name,
+		DurationMinutes: st.DurationMinutes,
+	}
+}
+
+// sessionTypeOf returns the session type recorded on an event by gcal.Service,
+// falling back to the default session type for events that predate the catalog.
+func (h *Handler) sessionTypeOf(event *calendar.Event) config.SessionType {
+	var id string
+	if event.ExtendedProperties != nil && event.ExtendedProperties.Private != nil {
+		id = event.ExtendedProperties.Private["session_type"]
+	}
+	if st, ok := h.cfg.SessionType(id); ok {
+		return st
+	}
+	st, _ := h.cfg.SessionType("")
+	return st
+}
+
+// filterSessionType keeps only the slots of the session type requested with
+// the optional `type` query parameter.
+func (h *Handler) filterSessionType(events []*calendar.Event, r *http.Request) []*calendar.Event {
+	want := r.URL.Query().Get("type")
+	if want == "" {
+		return events
+	}
+	var filtered []*calendar.Event
+	for _, event := range events {
+		if h.sessionTypeOf(event).ID == want {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// visitorLocation returns the timezone named by the optional `tz` query
+// parameter, falling back to the calendar's own timezone.
+func (h *Handler) visitorLocation(r *http.Request) *time.Location {
+	return resolveVisitorTimezone(r.URL.Query().Get("tz"), h.gcalSvc.Location())
+}
 
-	h.respondJSON(w, http.StatusOK, map[string]string{"message": "Booking cancelled successfully"})
+// slotDate returns the YYYY-MM-DD day an event starts on in loc.
+func slotDate(event *calendar.Event, loc *time.Location) string {
+	start, err := time.Parse(time.RFC3339, event.Start.DateTime)
+	if err != nil {
+		return ""
+	}
+	return start.In(loc).Format("2006-01-02")
 }
 
-// handleGetAvailability handles requests for available time slots.
+// handleGetAvailability handles requests for available time slots. It accepts
+// either a single `date` or a `from`/`to` range (both YYYY-MM-DD, inclusive);
+// the range form returns the slots grouped by day. Days are those of the
+// visitor's timezone, given as an IANA name in the optional `tz` parameter.
 func (h *Handler) handleGetAvailability(w http.ResponseWriter, r *http.Request) {
-	dateStr := r.URL.Query().Get("date")
+	query := r.URL.Query()
+	if query.Get("from") != "" || query.Get("to") != "" {
+		h.handleGetAvailabilityRange(w, r)
+		return
+	}
+
+	dateStr := query.Get("date")
 	if dateStr == "" {
 		h.respondError(w, http.StatusBadRequest, "date query parameter is required")
 		return
 	}
 
-	// The frontend sends date in YYYY-MM-DD format.
-	// We parse it in the calendar's location to correctly handle timezones.
-	day, err := time.ParseInLocation("2006-01-02", dateStr, h.gcalSvc.Location())
+	// The frontend sends date in YYYY-MM-DD format. It is the visitor's day,
+	// which can start hours before or after the calendar owner's; on DST
+	// transition days it is 23 or 25 hours long.
+	loc := h.visitorLocation(r)
+	day, err := time.ParseInLocation("2006-01-02", dateStr, loc)
 	if err != nil {
 		h.respondError(w, http.StatusBadRequest, "invalid date format, use YYYY-MM-DD")
 		return
 	}
 
-	events, err := h.gcalSvc.GetAvailability(day)
+	events, err := h.gcalSvc.GetAvailabilityRange(day, day.AddDate(0, 0, 1))
 	if err != nil {
 		h.logger.Error("Failed to get availability from Google Calendar", "error", err)
 		h.respondError(w, http.StatusInternalServerError, "Failed to get availability")
 		return
 	}
+	events = h.filterSessionType(events, r)
 
-	// The frontend expects a specific format.
-	type availabilityResponse struct {
-		Start string `json:"start"`
-		ID    string `json:"id"`
-		End   string `json:"end"`
+	responseSlots := make([]availabilitySlot, len(events))
+	for i, event := range events {
+		responseSlots[i] = h.toAvailabilitySlot(event, loc)
 	}
 
-	responseSlots := make([]availabilityResponse, len(events))
-	for i, event := range events {
-		responseSlots[i] = availabilityResponse{
-			Start: event.Start.DateTime,
-			ID:    event.Id,
-			End:   event.End.DateTime,
+	h.respondJSON(w, http.StatusOK, responseSlots)
+}
+
+// handleGetAvailabilityRange answers ?from=YYYY-MM-DD&to=YYYY-MM-DD with one
+// calendar query and returns every day in the range, each with its slots.
+func (h *Handler) handleGetAvailabilityRange(w http.ResponseWriter, r *http.Request) {
+	loc := h.visitorLocation(r)
+	from, err := time.ParseInLocation("2006-01-02", r.URL.Query().Get("from"), loc)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid from date format, use YYYY-MM-DD")
+		return
+	}
+	to, err := time.ParseInLocation("2006-01-02", r.URL.Query().Get("to"), loc)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid to date format, use YYYY-MM-DD")
+		return
+	}
+	if to.Before(from) {
+		h.respondError(w, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+	end := to.AddDate(0, 0, 1) // The range is inclusive of the "to" day.
+	if end.After(from.AddDate(0, 0, maxAvailabilityRangeDays)) {
+		h.respondError(w, http.StatusBadRequest, fmt.Sprintf("date range must not exceed %d days", maxAvailabilityRangeDays))
+		return
+	}
+
+	events, err := h.gcalSvc.GetAvailabilityRange(from, end)
+	if err != nil {
+		h.logger.Error("Failed to get availability range from Google Calendar", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "Failed to get availability")
+		return
+	}
+	events = h.filterSessionType(events, r)
+
+	byDate := make(map[string][]availabilitySlot)
+	for _, event := range events {
+		date := slotDate(event, loc)
+		byDate[date] = append(byDate[date], h.toAvailabilitySlot(event, loc))
+	}
+
+	var days []availabilityDay
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		slots := byDate[date]
+		if slots == nil {
+			slots = []availabilitySlot{}
 		}
+		days = append(days, availabilityDay{Date: date, Slots: slots})
 	}
 
-	h.respondJSON(w, http.StatusOK, responseSlots)
+	h.respondJSON(w, http.StatusOK, days)
+}
+
+// handleGetAvailableDays answers ?month=YYYY-MM with the days of that month
+// that have at least one free slot, so the date picker can grey out the rest.
+// Like the slots, the days are those of the optional `tz` timezone.
+func (h *Handler) handleGetAvailableDays(w http.ResponseWriter, r *http.Request) {
+	monthStr := r.URL.Query().Get("month")
+	if monthStr == "" {
+		h.respondError(w, http.StatusBadRequest, "month query parameter is required")
+		return
+	}
+
+	loc := h.visitorLocation(r)
+	month, err := time.ParseInLocation("2006-01", monthStr, loc)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid month format, use YYYY-MM")
+		return
+	}
+
+	events, err := h.gcalSvc.GetAvailabilityRange(month, month.AddDate(0, 1, 0))
+	if err != nil {
+		h.logger.Error("Failed to get monthly availability from Google Calendar", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "Failed to get availability")
+		return
+	}
+	events = h.filterSessionType(events, r)
+
+	days := []string{}
+	seen := make(map[string]bool)
+	for _, event := range events {
+		date := slotDate(event, loc)
+		if date == "" || seen[date] {
+			continue
+		}
+		seen[date] = true
+		days = append(days, date)
+	}
+
+	h.respondJSON(w, http.StatusOK, availableDaysResponse{Month: monthStr, Days: days})
 }
 
 type createBookingRequest struct {
@@ -219,11 +503,137 @@ type createBookingRequest struct {
 	// The backend localises the confirmation email and .ics to this zone. Optional;
 	// when empty or unrecognised, the calendar's own timezone is used as a fallback.
 	VisitorTimezone string `json:"visitorTimezone,omitempty"`
+	// SessionType is the session type the visitor saw for the slot. Optional;
+	// when set, the booking fails if the slot offers a different type.
+	SessionType string `json:"sessionType,omitempty"`
+	// Intake holds the answers to the session type's intake questionnaire, by
+	// field ID (see GET /api/booking/session-types).
+	Intake map[string]string `json:"intake,omitempty"`
+	// Guests are the email addresses of colleagues the client brings along,
+	// at most BookingConfig.MaxGuests. They are invited to the event but get no
+	// management link; only the client can change or cancel the booking.
+	Guests []string `json:"guests,omitempty"`
+	// Conferencing is the ID of the video-conferencing provider the visitor
+	// chose (see GET /api/booking/conferencing). Optional; the session type's
+	// provider or the configured default is used when it is empty.
+	Conferencing string `json:"conferencing,omitempty"`
+	// Recurrence books a series of consultations at the time of the slot,
+	// all or none. Optional; without it the one slot is booked.
+	Recurrence *recurrenceRequest `json:"recurrence,omitempty"`
+	// HoldToken is the token returned by POST /api/booking/hold. It is needed
+	// to book the slot while the hold lasts.
+	HoldToken string `json:"holdToken,omitempty"`
+	// WaitlistToken is the token of a waitlist priority booking link. It is
+	// needed to book a slot that is reserved for waitlisted visitors.
+	WaitlistToken string `json:"waitlistToken,omitempty"`
 	// GaClientID captures the Google Analytics Client ID for server-side conversion tracking.
 	GaClientID  string `json:"ga_client_id,omitempty"`
 	GaSessionID string `json:"ga_session_id,omitempty"`
 }
 
+type holdRequest struct {
+	// EventID is the ID of the "Available" slot, as returned by the availability endpoint.
+	EventID string `json:"eventId"`
+	// WaitlistToken is the token of a waitlist priority booking link. It is
+	// needed to hold a slot that is reserved for waitlisted visitors.
+	WaitlistToken string `json:"waitlistToken,omitempty"`
+}
+
+type holdResponse struct {
+	HoldToken string    `json:"holdToken"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// handleHoldSlot reserves a slot while the visitor fills in the booking form.
+// The returned token is passed to POST /api/booking/book; the hold lapses on
+// its own at expiresAt. Each client address holds one slot at a time, and may
+// hold holdLimit times an hour; a new hold releases the client's earlier one.
+func (h *Handler) handleHoldSlot(w http.ResponseWriter, r *http.Request) {
+	var req holdRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.EventID == "" {
+		h.respondError(w, http.StatusBadRequest, "eventId is required")
+		return
+	}
+	if h.waitlist != nil && h.respondReserved(w, r, req.WaitlistToken, req.EventID) {
+		return
+	}
+
+	token, expires, err := h.gcalSvc.HoldSlot(r.Context(), req.EventID)
+	if err != nil {
+		if errors.Is(err, gcal.ErrSlotNotFound) {
+			h.respondError(w, http.StatusConflict, "This time slot is no longer available. Please select another time.")
+			return
+		}
+		h.logger.Error("Failed to hold slot", "event_id", req.EventID, "error", err)
+		h.respondError(w, http.StatusInternalServerError, "An internal error occurred while holding the slot.")
+		return
+	}
+
+	h.logger.Info("Slot held", "event_id", req.EventID, "expires_at", expires)
+	hold := clientHold{eventID: req.EventID, token: token, expires: expires}
+	if previous, ok := h.holds.replace(audit.ClientIP(r), hold, time.Now()); ok {
+		if err := h.gcalSvc.ReleaseHold(r.Context(), previous.eventID, previous.token); err != nil {
+			h.logger.Warn("Failed to release earlier hold", "event_id", previous.eventID, "error", err)
+		}
+	}
+	h.recordAudit(r.Context(), audit.Record{
+		Action:  audit.ActionHold,
+		EventID: req.EventID,
+		Actor:   audit.ActorClient,
+		IP:      audit.ClientIP(r),
+		Details: map[string]string{"expiresAt": expires.UTC().Format(time.RFC3339)},
+	})
+	h.respondJSON(w, http.StatusCreated, holdResponse{HoldToken: token, ExpiresAt: expires})
+}
+
+// respondReserved responds with a conflict and returns true if one of
+// eventIDs is reserved for waitlisted visitors and token is not one of their
+// priority tokens. h.waitlist must not be nil.
+func (h *Handler) respondReserved(w http.ResponseWriter, r *http.Request, token string, eventIDs ...string) bool {
+	err := h.waitlist.checkReservation(r.Context(), token, eventIDs...)
+	if errors.Is(err, errSlotReserved) {
+		h.respondError(w, http.StatusConflict, "This time slot is reserved for visitors on the waitlist for a short time. Please select another time.")
+		return true
+	}
+	if err != nil {
+		// The waitlist is best-effort; it must not block bookings.
+		h.logger.Error("Failed to check waitlist reservations", "error", err)
+	}
+	return false
+}
+
+// intakeErrorResponse reports invalid intake answers field by field.
+type intakeErrorResponse struct {
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields"`
+}
+
+// handleGetSessionTypes returns the session type catalog, including each
+// type's intake questionnaire, so the booking form can render the questions.
+func (h *Handler) handleGetSessionTypes(w http.ResponseWriter, r *http.Request) {
+	h.respondJSON(w, http.StatusOK, h.cfg.Catalog())
+}
+
+// conferencingOption is a video-conferencing provider visitors can choose.
+type conferencingOption struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// handleGetConferencing lists the video-conferencing providers a booking can
+// ask for.
+func (h *Handler) handleGetConferencing(w http.ResponseWriter, r *http.Request) {
+	options := []conferencingOption{}
+	for _, p := range conferencing.Providers(h.cfg.Conferencing) {
+		options = append(options, conferencingOption{ID: p.ID(), Name: p.Name()})
+	}
+	h.respondJSON(w, http.StatusOK, options)
+}
+
 // handleCreateBooking handles a new booking request.
 func (h *Handler) handleCreateBooking(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("Received POST /api/booking/book request")
@@ -238,6 +648,22 @@ func (h *Handler) handleCreateBooking(w http.ResponseWriter, r *http.Request) {
 		h.respondError(w, http.StatusBadRequest, "Bad Request: Name, email, and eventId are required")
 		return
 	}
+	if req.Conferencing != "" && !h.cfg.Conferencing.Has(req.Conferencing) {
+		h.respondError(w, http.StatusBadRequest, "Unknown conferencing provider")
+		return
+	}
+	guests, err := cleanGuests(req.Guests, req.Email, h.cfg.MaxGuests)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var recurrence gcal.Recurrence
+	if req.Recurrence != nil {
+		if recurrence, err = req.Recurrence.rule(h.gcalSvc.Location()); err != nil {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
 	bookingDetails := gcal.BookingDetails{
 		EventID:         req.EventID,
@@ -245,73 +671,100 @@ func (h *Handler) handleCreateBooking(w http.ResponseWriter, r *http.Request) {
 		Email:           req.Email,
 		Notes:           req.Notes,
 		VisitorTimezone: req.VisitorTimezone,
+		SessionType:     req.SessionType,
+		Intake:          req.Intake,
+		Conferencing:    req.Conferencing,
+		HoldToken:       req.HoldToken,
+		Guests:          guests,
+	}
+
+	if h.waitlist != nil {
+		// Every occurrence of a series must be free of reservations. If the
+		// series cannot be resolved, booking it fails with the reason below.
+		eventIDs := []string{req.EventID}
+		if req.Recurrence != nil {
+			if ids, err := h.gcalSvc.SeriesEventIDs(r.Context(), req.EventID, recurrence); err == nil {
+				eventIDs = ids
+			}
+		}
+		if h.respondReserved(w, r, req.WaitlistToken, eventIDs...) {
+			return
+		}
 	}
 
-	event, err := h.gcalSvc.BookSlot(bookingDetails)
+	// A series is booked as one booking per occurrence; the first one stands
+	// for the series in the confirmation.
+	var events []*calendar.Event
+	if req.Recurrence != nil {
+		events, err = h.gcalSvc.BookSeries(r.Context(), bookingDetails, recurrence)
+	} else {
+		var event *calendar.Event
+		event, err = h.gcalSvc.BookSlot(bookingDetails)
+		events = []*calendar.Event{event}
+	}
 	if err != nil {
 		h.logger.Error("BookSlot service call failed", "error", err)
+		if h.respondSeriesError(w, err, req.VisitorTimezone) {
+			return
+		}
+		var intakeErrs config.IntakeErrors
+		if errors.As(err, &intakeErrs) {
+			h.respondJSON(w, http.StatusBadRequest, intakeErrorResponse{
+				Message: "Please check your answers to the intake questions.",
+				Fields:  intakeErrs,
+			})
+			return
+		}
 		if errors.Is(err, gcal.ErrSlotNotFound) {
 			h.respondError(w, http.StatusConflict, "This time slot is no longer available. Please select another time.")
 			return
 		}
+		if errors.Is(err, gcal.ErrUnknownConferencing) {
+			h.respondError(w, http.StatusBadRequest, "Unknown conferencing provider")
+			return
+		}
 		h.logger.Error("Failed to create booking in Google Calendar", "error", err)
 		h.respondError(w, http.StatusInternalServerError, "An internal error occurred while creating the booking.")
 		return
 	}
 
-	h.logger.Info("Booking created successfully", "event_id", event.Id)
+	event := events[0]
+	sessionType := h.sessionTypeOf(event)
+	h.logger.Info("Booking created successfully", "event_id", event.Id, "session_type", sessionType.ID, "occurrences", len(events))
+	for _, booked := range events {
+		h.recordAudit(r.Context(), audit.Record{
+			Action:  audit.ActionBook,
+			EventID: booked.Id,
+			Actor:   audit.ActorClient,
+			IP:      audit.ClientIP(r),
+			After:   audit.SnapshotOf(booked),
+		})
+		created := h.bookingWebhookOf(booked)
+		created.Notes = req.Notes
+		h.webhooks.Send(webhook.EventBookingCreated, created)
+	}
+	if h.waitlist != nil && req.WaitlistToken != "" {
+		if err := h.waitlist.redeem(r.Context(), req.WaitlistToken); err != nil {
+			h.logger.Error("Failed to remove booked visitor from the waitlist", "error", err)
+		}
+	}
 
 	// Fire the server-side analytics event in a goroutine so it doesn't block the response.
 	go func() {
-		// These values should ideally be configurable.
-		const bookingValue = 250.0 // Example value for a consultation
-		const currency = "USD"
-
+		// The conversion value and currency come from the booked session type.
 		h.trackerSvc.TrackBookingConfirmed(r.Context(), analytics.BookingConfirmedEvent{
 			ClientID:      req.GaClientID,
 			SessionID:     req.GaSessionID,
 			TransactionID: event.Id, // The unique calendar event ID is a perfect transaction ID.
-			Value:         bookingValue,
-			Currency:      currency,
+			Value:         sessionType.Price * float64(len(events)),
+			Currency:      sessionType.Currency,
 		})
 	}()
 
 	// Send confirmation emails in the background
 	go func() {
-		startTime, _ := time.Parse(time.RFC3339, event.Start.DateTime)
-		endTime, _ := time.Parse(time.RFC3339, event.End.DateTime)
-
-		// Localise to the visitor's timezone if provided. The calendar's
-		// own zone is the fallback when the field is empty or unrecognised,
-		// so a malformed client value can never fail the booking.
-		visitorLoc := resolveVisitorTimezone(req.VisitorTimezone, h.gcalSvc.Location())
-		// The display label follows DST because we format the actual
-		// event start time, not a fixed probe instant.
-		visitorTZLabel := startTime.In(visitorLoc).Format("MST")
-		h.logger.Info("Rendering booking confirmation in visitor timezone",
-			"event_id", event.Id, "visitor_timezone", req.VisitorTimezone,
-			"resolved_location", visitorLoc.String(), "display_label", visitorTZLabel)
-
-		var cancellationURL string
-		if event.ExtendedProperties != nil && event.ExtendedProperties.Private != nil {
-			if token, ok := event.ExtendedProperties.Private["cancellation_token"]; ok && token != "" {
-				cancellationURL = fmt.Sprintf("https://ivmanto.com/booking/cancel?token=%s", token)
-			}
-		}
-
-		emailDetails := email.BookingConfirmationDetails{
-			ToName:          req.Name,
-			ToEmail:         req.Email,
-			StartTime:       startTime.In(visitorLoc),
-			EndTime:         endTime.In(visitorLoc),
-			Timezone:        visitorTZLabel,
-			MeetLink:        getMeetLink(event),
-			CancellationURL: cancellationURL,
-			IcsUID:          event.ICalUID,
-			IcsSummary:      event.Summary,
-			IcsDescription:  event.Description,
-			IcsTimezone:     req.VisitorTimezone,
-		}
+		emailDetails := h.confirmationDetails(event, req.Name, req.Email, req.VisitorTimezone)
+		h.withSeries(&emailDetails, event, events)
 		if err := h.emailSvc.SendBookingConfirmation(emailDetails); err != nil {
 			h.logger.Error("Failed to send booking confirmation to client", "client_email", req.Email, "error", err)
 		}
@@ -330,10 +783,299 @@ func (h *Handler) handleCreateBooking(w http.ResponseWriter, r *http.Request) {
 		if calLoc := h.gcalSvc.Location(); calLoc != nil {
 			startTime = startTime.In(calLoc)
 		}
-		if err := h.emailSvc.SendBookingNotificationToAdmin(req.Name, req.Email, startTime, req.Notes); err != nil {
+		notification := email.BookingNotificationDetails{
+			Name:        req.Name,
+			Email:       req.Email,
+			StartTime:   startTime,
+			Notes:       req.Notes,
+			SessionName: sessionType.Name,
+			Guests:      guests,
+		}
+		if len(events) > 1 {
+			for _, booked := range events {
+				start, _ := time.Parse(time.RFC3339, booked.Start.DateTime)
+				notification.Occurrences = append(notification.Occurrences, start.In(startTime.Location()))
+			}
+		}
+		for _, answer := range gcal.IntakeAnswers(event, sessionType) {
+			notification.Intake = append(notification.Intake, email.IntakeAnswer{Label: answer.Label, Value: answer.Value})
+		}
+		// Tell the admin when the conflict-calendar check hid slots on the
+		// booked day, so a forgotten block on the booking calendar shows up.
+		dayStart := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, startTime.Location())
+		if conflicting, err := h.gcalSvc.ConflictingSlots(dayStart, dayStart.AddDate(0, 0, 1)); err != nil {
+			h.logger.Warn("Could not check conflict calendars for admin email", "error", err)
+		} else {
+			notification.HiddenConflicts = len(conflicting)
+		}
+		if err := h.emailSvc.SendBookingNotificationToAdmin(notification); err != nil {
 			h.logger.Error("Failed to send booking notification to admin", "error", err)
 		}
 	}()
 
-	h.respondJSON(w, http.StatusCreated, event)
+	booking := h.publicBooking(event, req.Name, req.Email, req.VisitorTimezone)
+	h.withOccurrences(&booking, events, req.VisitorTimezone)
+	h.respondJSON(w, http.StatusCreated, booking)
+}
+
+// publicBooking builds the response to a booking request from the booked event.
+func (h *Handler) publicBooking(event *calendar.Event, name, clientEmail, visitorTZ string) Booking {
+	loc := resolveVisitorTimezone(visitorTZ, h.gcalSvc.Location())
+	start, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+	end, _ := time.Parse(time.RFC3339, event.End.DateTime)
+	sessionType := h.sessionTypeOf(event)
+	return Booking{
+		ID:              event.Id,
+		StartTime:       start.In(loc),
+		EndTime:         end.In(loc),
+		Timezone:        loc.String(),
+		SessionType:     sessionType.ID,
+		SessionName:     sessionType.Name,
+		DurationMinutes: sessionType.DurationMinutes,
+		MeetLink:        conferencing.Link(event),
+		ConferenceName:  conferenceName(event),
+		Name:            name,
+		Email:           clientEmail,
+		Guests:          gcal.Guests(event),
+	}
+}
+
+type joinWaitlistRequest struct {
+	Email string `json:"email"`
+	// From and To are the preferred days (YYYY-MM-DD, inclusive) in the visitor's timezone.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Timezone is the IANA timezone of the visitor's browser. Optional; the
+	// calendar's own timezone is used when it is empty or unrecognised.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// handleJoinWaitlist puts a visitor on the waitlist for a date range. They are
+// emailed a priority booking link when a slot opens up in that range. Each
+// client address may join waitlistJoinLimit times an hour.
+func (h *Handler) handleJoinWaitlist(w http.ResponseWriter, r *http.Request) {
+	if h.waitlist == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "The waitlist is not available.")
+		return
+	}
+	var req joinWaitlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" || !strings.Contains(req.Email, "@") {
+		h.respondError(w, http.StatusBadRequest, "A valid email is required")
+		return
+	}
+	loc := resolveVisitorTimezone(req.Timezone, h.gcalSvc.Location())
+	from, err1 := time.ParseInLocation("2006-01-02", req.From, loc)
+	to, err2 := time.ParseInLocation("2006-01-02", req.To, loc)
+	if err1 != nil || err2 != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid from or to date format, use YYYY-MM-DD")
+		return
+	}
+	if to.Before(from) {
+		h.respondError(w, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+	end := to.AddDate(0, 0, 1)
+	if end.After(from.AddDate(0, 0, maxAvailabilityRangeDays)) {
+		h.respondError(w, http.StatusBadRequest, fmt.Sprintf("date range must not exceed %d days", maxAvailabilityRangeDays))
+		return
+	}
+	if !end.After(time.Now()) {
+		h.respondError(w, http.StatusBadRequest, "date range is in the past")
+		return
+	}
+
+	entry, err := h.waitlist.Join(r.Context(), req.Email, req.From, req.To, loc.String())
+	if err != nil {
+		h.logger.Error("Failed to join the waitlist", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "An internal error occurred while joining the waitlist.")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, map[string]string{
+		"message": "You are on the waitlist. We will email you when a slot opens up.",
+		"id":      entry.ID,
+	})
+}
+
+// confirmationDetails builds the client-facing email details for a booked event.
+// The ICS UID/sequence are read from the private properties stored on the
+// event by gcal.Service; see manageURL for the cancellation link.
+func (h *Handler) confirmationDetails(event *calendar.Event, name, clientEmail, visitorTZ string) email.BookingConfirmationDetails {
+	startTime, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+	endTime, _ := time.Parse(time.RFC3339, event.End.DateTime)
+
+	// Localise to the visitor's timezone if provided. The calendar's
+	// own zone is the fallback when the field is empty or unrecognised,
+	// so a malformed client value can never fail the booking.
+	visitorLoc := resolveVisitorTimezone(visitorTZ, h.gcalSvc.Location())
+	// The display label follows DST because we format the actual
+	// event start time, not a fixed probe instant.
+	visitorTZLabel := startTime.In(visitorLoc).Format("MST")
+	h.logger.Info("Rendering booking confirmation in visitor timezone",
+		"event_id", event.Id, "visitor_timezone", visitorTZ,
+		"resolved_location", visitorLoc.String(), "display_label", visitorTZLabel)
+
+	icsUID := event.ICalUID
+	var icsSequence int
+	if event.ExtendedProperties != nil && event.ExtendedProperties.Private != nil {
+		private := event.ExtendedProperties.Private
+		if uid := private["ics_uid"]; uid != "" {
+			icsUID = uid
+		}
+		icsSequence, _ = strconv.Atoi(private["ics_sequence"])
+	}
+
+	return email.BookingConfirmationDetails{
+		ToName:          name,
+		ToEmail:         clientEmail,
+		StartTime:       startTime.In(visitorLoc),
+		EndTime:         endTime.In(visitorLoc),
+		Timezone:        visitorTZLabel,
+		SessionName:     h.sessionTypeOf(event).Name,
+		MeetLink:        conferencing.Link(event),
+		ConferenceName:  conferenceName(event),
+		CancellationURL: h.manageURL(event),
+		IcsUID:          icsUID,
+		IcsSummary:      event.Summary,
+		IcsDescription:  event.Description,
+		IcsTimezone:     visitorTZ,
+		IcsSequence:     icsSequence,
+		Guests:          gcal.Guests(event),
+	}
+}
+
+// manageURL returns the link to the page where the client views, cancels or
+// reschedules the booked event, or "" if it cannot be managed online. The token
+// in it is signed and expires cfg.Tokens.Grace after the booking ends. Without
+// signing keys it is the unsigned legacy token stored on the event.
+func (h *Handler) manageURL(event *calendar.Event) string {
+	var secret string
+	if event.ExtendedProperties != nil {
+		secret = event.ExtendedProperties.Private["cancellation_token"]
+	}
+	if secret == "" {
+		return ""
+	}
+	token := secret
+	if h.tokens.Enabled() {
+		end, err := time.Parse(time.RFC3339, event.End.DateTime)
+		if err != nil {
+			h.logger.Error("Could not parse end time of booking for its management link", "event_id", event.Id, "error", err)
+			return ""
+		}
+		token, err = h.tokens.Sign(bookingtoken.Claims{
+			EventID: event.Id,
+			Binding: bookingtoken.Binding(secret),
+			Scopes:  bookingtoken.AllScopes,
+			Expires: end.Add(h.cfg.Tokens.Grace),
+		})
+		if err != nil {
+			h.logger.Error("Could not sign management token", "event_id", event.Id, "error", err)
+			return ""
+		}
+	}
+	return fmt.Sprintf("https://ivmanto.com/booking/cancel?token=%s", url.QueryEscape(token))
+}
+
+// handleRescheduleBooking moves an existing booking, identified by its
+// management token, to another available slot in one step.
+func (h *Handler) handleRescheduleBooking(w http.ResponseWriter, r *http.Request) {
+	var req rescheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Token == "" || req.EventID == "" {
+		h.respondError(w, http.StatusBadRequest, "Cancellation token and eventId are required")
+		return
+	}
+
+	h.logger.Info("Received reschedule request", "token_prefix", tokenPrefix(req.Token), "new_event_id", req.EventID)
+	if h.waitlist != nil && h.respondReserved(w, r, req.WaitlistToken, req.EventID) {
+		return
+	}
+
+	previous, event, err := h.gcalSvc.RescheduleBooking(r.Context(), req.Token, req.EventID)
+	if err != nil {
+		h.logger.Error("Failed to reschedule booking", "token_prefix", tokenPrefix(req.Token), "error", err)
+		if h.respondPolicyError(w, r, req.Token, err, "rescheduled") {
+			return
+		}
+		if errors.Is(err, gcal.ErrSeriesOccurrence) {
+			h.respondError(w, http.StatusConflict, "Sessions of a recurring series cannot be rescheduled. Please cancel this session and book a new time instead.")
+			return
+		}
+		if errors.Is(err, gcal.ErrSlotNotFound) {
+			h.respondError(w, http.StatusConflict, "The booking could not be found or the new time slot is no longer available. Please select another time.")
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "An internal error occurred while rescheduling the booking.")
+		return
+	}
+
+	h.logger.Info("Booking rescheduled successfully", "from_event_id", previous.Id, "to_event_id", event.Id)
+	h.recordAudit(r.Context(), audit.Record{
+		Action:          audit.ActionReschedule,
+		EventID:         event.Id,
+		PreviousEventID: previous.Id,
+		Actor:           audit.ActorClient,
+		IP:              audit.ClientIP(r),
+		Before:          audit.SnapshotOf(previous),
+		After:           audit.SnapshotOf(event),
+	})
+	rescheduled := h.bookingWebhookOf(event)
+	rescheduled.PreviousEventID = previous.Id
+	rescheduled.PreviousStart = previous.Start.DateTime
+	rescheduled.PreviousEnd = previous.End.DateTime
+	h.webhooks.Send(webhook.EventBookingRescheduled, rescheduled)
+
+	var clientName, clientEmail, visitorTZ string
+	if event.ExtendedProperties != nil && event.ExtendedProperties.Private != nil {
+		clientName = event.ExtendedProperties.Private["client_name"]
+		clientEmail = event.ExtendedProperties.Private["client_email"]
+		visitorTZ = event.ExtendedProperties.Private["visitor_timezone"]
+	}
+	previousStart, _ := time.Parse(time.RFC3339, previous.Start.DateTime)
+	previousEnd, _ := time.Parse(time.RFC3339, previous.End.DateTime)
+
+	go func() {
+		details := email.BookingRescheduleDetails{
+			BookingConfirmationDetails: h.confirmationDetails(event, clientName, clientEmail, visitorTZ),
+			PreviousStartTime:          previousStart,
+			PreviousEndTime:            previousEnd,
+		}
+		if err := h.emailSvc.SendBookingRescheduled(details); err != nil {
+			h.logger.Error("Failed to send reschedule email to client", "client_email", clientEmail, "error", err)
+		}
+	}()
+
+	go func() {
+		newStart, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+		calLoc := h.gcalSvc.Location()
+		if err := h.emailSvc.SendBookingRescheduleToAdmin(clientName, clientEmail, previousStart.In(calLoc), newStart.In(calLoc)); err != nil {
+			h.logger.Error("Failed to send reschedule notification to admin", "error", err)
+		}
+	}()
+
+	h.respondJSON(w, http.StatusOK, map[string]string{
+		"message": "Booking rescheduled successfully",
+		"eventId": event.Id,
+		"start":   event.Start.DateTime,
+		"end":     event.End.DateTime,
+	})
+}
+
+// tokenPrefix returns a short, log-safe prefix of a token.
+func tokenPrefix(token string) string {
+	if len(token) > 8 {
+		return token[:8]
+	}
+	return token
 }
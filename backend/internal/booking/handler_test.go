@@ -1,6 +1,12 @@
 package booking
 
 import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -121,3 +127,53 @@ func TestVisitorLabelFollowsDST(t *testing.T) {
 		t.Errorf("winter Berlin label: expected CET, got %q", got)
 	}
 }
+
+// TestCreateBooking_ReturnsOnlyPublicFields pins the response of
+// POST /api/booking/book: the visitor gets the booking in their timezone and
+// none of the calendar event behind it, in particular not its cancellation
+// token, which is only ever sent by email.
+func TestCreateBooking_ReturnsOnlyPublicFields(t *testing.T) {
+	h, backend, _ := newTestHandler(t, nil, Options{})
+	backend.AddEvent(slot("slot1", "2030-06-17T13:30:00Z", "2030-06-17T14:00:00Z"))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	body, _ := json.Marshal(createBookingRequest{
+		EventID:         "slot1",
+		Name:            "Ada",
+		Email:           "ada@example.com",
+		VisitorTimezone: "Europe/Athens",
+	})
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/booking/book", bytes.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &fields); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	want := []string{"conferenceName", "durationMinutes", "email", "endTime", "id", "meetLink", "name", "sessionName", "sessionType", "startTime", "timezone"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("expected exactly the fields %v, got %v", want, keys)
+	}
+	if fields["startTime"] != "2030-06-17T16:30:00+03:00" || fields["timezone"] != "Europe/Athens" {
+		t.Errorf("expected the start in the visitor's timezone, got %v in %v", fields["startTime"], fields["timezone"])
+	}
+
+	token := backend.Event("slot1").ExtendedProperties.Private["cancellation_token"]
+	if token == "" {
+		t.Fatal("expected the booked event to carry a cancellation token")
+	}
+	for _, secret := range []string{token, "cancellation_token", "extendedProperties", "organizer", "conferenceData", "etag"} {
+		if strings.Contains(rec.Body.String(), secret) {
+			t.Errorf("expected the response not to contain %q: %s", secret, rec.Body.String())
+		}
+	}
+}
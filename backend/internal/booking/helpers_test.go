@@ -0,0 +1,148 @@
+package booking
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/analytics"
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/email"
+	"ivmanto.com/backend/internal/gcal"
+	"ivmanto.com/backend/internal/gcal/gcaltest"
+)
+
+// discardLogger drops everything the code under test logs.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// testConfig returns the configuration the booking tests run against: a
+// calendar whose placeholders are titled "AfB" and the default session type.
+func testConfig() *config.Config {
+	return &config.Config{
+		GCal: config.GCalConfig{CalendarID: "primary", AvailableSlotSummary: "AfB"},
+	}
+}
+
+// slot returns an "Available" placeholder event from start to end.
+func slot(id, start, end string) *calendar.Event {
+	return &calendar.Event{
+		Id:      id,
+		Summary: "AfB",
+		Start:   &calendar.EventDateTime{DateTime: start},
+		End:     &calendar.EventDateTime{DateTime: end},
+	}
+}
+
+// newTestHandler returns a Handler for cfg, or testConfig if cfg is nil, on
+// top of a fake Calendar backend, with the optional dependencies in opts and
+// an email service that records what it is asked to send.
+func newTestHandler(t *testing.T, cfg *config.Config, opts Options) (*Handler, *gcaltest.Server, *testEmails) {
+	t.Helper()
+	if cfg == nil {
+		cfg = testConfig()
+	}
+	backend := gcaltest.NewServer(t)
+	gcalSvc := gcal.NewServiceWithClient(backend.CalendarService(t), cfg, time.UTC)
+	tracker, err := analytics.NewTracker("test-secret", "G-TEST", discardLogger)
+	if err != nil {
+		t.Fatalf("could not create tracker: %v", err)
+	}
+	emails := &testEmails{}
+	return NewHandler(discardLogger, gcalSvc, emails, tracker, &cfg.Booking, opts), backend, emails
+}
+
+// testEmails is the email.Service of the booking tests. It accepts the
+// emails of a booking's lifecycle and records the ones the tests look at.
+// The remaining methods panic, which flags unexpected emails.
+type testEmails struct {
+	email.Service
+
+	mu                  sync.Mutex
+	confirmations       []email.BookingConfirmationDetails
+	reminders           []email.BookingConfirmationDetails
+	followUps           []email.BookingFollowUpDetails
+	feedback            []email.FeedbackNotificationDetails
+	offers              []email.WaitlistOfferDetails
+	seriesCancellations [][]time.Time
+}
+
+// wait polls until cond, called with e locked, holds, and fails the test
+// if it does not within five seconds. The handler sends most emails in the
+// background.
+func (e *testEmails) wait(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		e.mu.Lock()
+		ok := cond()
+		e.mu.Unlock()
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for an email")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (e *testEmails) SendBookingConfirmation(details email.BookingConfirmationDetails) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.confirmations = append(e.confirmations, details)
+	return nil
+}
+
+func (e *testEmails) SendBookingNotificationToAdmin(email.BookingNotificationDetails) error {
+	return nil
+}
+
+func (e *testEmails) SendBookingCancellationToClient(string, string, time.Time, *time.Location, string) error {
+	return nil
+}
+
+func (e *testEmails) SendBookingSeriesCancellationToClient(_, _ string, startTimes []time.Time, _ *time.Location) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.seriesCancellations = append(e.seriesCancellations, startTimes)
+	return nil
+}
+
+func (e *testEmails) SendBookingCancellationToAdmin(string, string, time.Time) error { return nil }
+
+func (e *testEmails) SendBookingRescheduled(email.BookingRescheduleDetails) error { return nil }
+
+func (e *testEmails) SendBookingRescheduleToAdmin(string, string, time.Time, time.Time) error {
+	return nil
+}
+
+func (e *testEmails) SendBookingReminder(details email.BookingConfirmationDetails) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.reminders = append(e.reminders, details)
+	return nil
+}
+
+func (e *testEmails) SendBookingFollowUp(details email.BookingFollowUpDetails) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.followUps = append(e.followUps, details)
+	return nil
+}
+
+func (e *testEmails) SendFeedbackToAdmin(details email.FeedbackNotificationDetails) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.feedback = append(e.feedback, details)
+	return nil
+}
+
+func (e *testEmails) SendWaitlistOffer(details email.WaitlistOfferDetails) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.offers = append(e.offers, details)
+	return nil
+}
@@ -0,0 +1,43 @@
+package booking
+
+import (
+	"sync"
+	"time"
+)
+
+// holdLimit is how many times an hour a client address may hold a slot.
+const holdLimit = 10
+
+// clientHold is the hold a client address placed last.
+type clientHold struct {
+	eventID string
+	token   string
+	expires time.Time
+}
+
+// clientHolds tracks the last hold of each client address, so a client has
+// at most one active hold: holding another slot releases the earlier one.
+// Like the rate limits it is kept in memory, per instance of the service.
+type clientHolds struct {
+	mu    sync.Mutex
+	holds map[string]clientHold
+}
+
+func newClientHolds() *clientHolds {
+	return &clientHolds{holds: make(map[string]clientHold)}
+}
+
+// replace records hold as the active hold of client and returns the hold it
+// replaces, if that one is still active and on another slot.
+func (c *clientHolds) replace(client string, hold clientHold, now time.Time) (clientHold, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, h := range c.holds {
+		if !now.Before(h.expires) {
+			delete(c.holds, key)
+		}
+	}
+	previous, ok := c.holds[client]
+	c.holds[client] = hold
+	return previous, ok && previous.eventID != hold.eventID
+}
@@ -0,0 +1,92 @@
+package booking
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHoldSlot_OnePerClient holds two slots from one address behind the load
+// balancer and checks that the second hold releases the first, that another
+// address holds on its own, and that the address is rate limited.
+func TestHoldSlot_OnePerClient(t *testing.T) {
+	cfg := testConfig()
+	cfg.Booking.HoldTTL = 5 * time.Minute
+	h, backend, _ := newTestHandler(t, cfg, Options{})
+	backend.AddEvent(slot("slot1", "2030-06-17T13:30:00Z", "2030-06-17T14:00:00Z"))
+	backend.AddEvent(slot("slot2", "2030-06-17T14:00:00Z", "2030-06-17T14:30:00Z"))
+	backend.AddEvent(slot("slot3", "2030-06-17T14:30:00Z", "2030-06-17T15:00:00Z"))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	hold := func(ip, eventID string) int {
+		t.Helper()
+		raw, _ := json.Marshal(holdRequest{EventID: eventID})
+		req := httptest.NewRequest(http.MethodPost, "/api/booking/hold", bytes.NewReader(raw))
+		// As sent by the external HTTPS load balancer.
+		req.Header.Set("X-Forwarded-For", ip+", 198.51.100.1")
+		req.RemoteAddr = "169.254.1.1:1234"
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	held := func(eventID string) bool {
+		_, ok := backend.Event(eventID).ExtendedProperties.Private["hold_token"]
+		return ok
+	}
+
+	if code := hold("192.0.2.1", "slot1"); code != http.StatusCreated {
+		t.Fatalf("expected slot1 to be held, got %d", code)
+	}
+	if code := hold("192.0.2.1", "slot2"); code != http.StatusCreated {
+		t.Fatalf("expected slot2 to be held, got %d", code)
+	}
+	if held("slot1") || !held("slot2") {
+		t.Errorf("expected the second hold to release the first, slot1 held %v, slot2 held %v", held("slot1"), held("slot2"))
+	}
+	if code := hold("192.0.2.2", "slot1"); code != http.StatusCreated {
+		t.Fatalf("expected another client to hold the released slot, got %d", code)
+	}
+	if code := hold("192.0.2.1", "slot3"); code != http.StatusCreated || held("slot2") || !held("slot1") {
+		t.Errorf("expected only the client's own hold to be released, got %d", code)
+	}
+
+	for range holdLimit - 3 {
+		hold("192.0.2.1", "slot3")
+	}
+	if code := hold("192.0.2.1", "slot3"); code != http.StatusTooManyRequests {
+		t.Errorf("expected the client to be rate limited, got %d", code)
+	}
+}
+
+// TestHoldSlot_ClientsBehindLoadBalancer holds a slot for each of two clients
+// whose requests arrive through the same load balancer and checks that
+// neither hold releases the other.
+func TestHoldSlot_ClientsBehindLoadBalancer(t *testing.T) {
+	cfg := testConfig()
+	cfg.Booking.HoldTTL = 5 * time.Minute
+	h, backend, _ := newTestHandler(t, cfg, Options{})
+	backend.AddEvent(slot("slot1", "2030-06-17T13:30:00Z", "2030-06-17T14:00:00Z"))
+	backend.AddEvent(slot("slot2", "2030-06-17T14:00:00Z", "2030-06-17T14:30:00Z"))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	for eventID, forwarded := range map[string]string{"slot1": "203.0.113.7, 198.51.100.1", "slot2": "203.0.113.8, 198.51.100.1"} {
+		raw, _ := json.Marshal(holdRequest{EventID: eventID})
+		req := httptest.NewRequest(http.MethodPost, "/api/booking/hold", bytes.NewReader(raw))
+		req.Header.Set("X-Forwarded-For", forwarded)
+		req.RemoteAddr = "169.254.1.1:1234"
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected %s to be held, got %d", eventID, rec.Code)
+		}
+	}
+	for _, eventID := range []string{"slot1", "slot2"} {
+		if _, ok := backend.Event(eventID).ExtendedProperties.Private["hold_token"]; !ok {
+			t.Errorf("expected the hold on %s to be kept", eventID)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package booking
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/conferencing"
+	"ivmanto.com/backend/internal/email"
+	"ivmanto.com/backend/internal/gcal"
+	"ivmanto.com/backend/internal/ical"
+)
+
+// manageBookingResponse describes a booking to the visitor holding its
+// cancellation token, so the cancel/manage page can show what they are about
+// to change.
+type manageBookingResponse struct {
+	Name string `json:"name"`
+	// Start and End are RFC 3339 times in the visitor's timezone.
+	Start           string   `json:"start"`
+	End             string   `json:"end"`
+	Timezone        string   `json:"timezone"`      // IANA name, e.g. "Europe/Athens"
+	TimezoneLabel   string   `json:"timezoneLabel"` // abbreviation at the start time, e.g. "EEST"
+	SessionType     string   `json:"sessionType"`
+	SessionName     string   `json:"sessionName"`
+	DurationMinutes int      `json:"durationMinutes"`
+	MeetLink        string   `json:"meetLink,omitempty"`
+	ConferenceName  string   `json:"conferenceName,omitempty"` // e.g. "Zoom"
+	Guests          []string `json:"guests,omitempty"`
+	// Cancellable reports whether the visitor can still cancel or reschedule
+	// the booking themselves, i.e. its cancellation deadline has not passed.
+	Cancellable  bool             `json:"cancellable"`
+	Cancellation cancellationInfo `json:"cancellation"`
+	// Series lists the booked occurrences of the recurring series of the
+	// booking, this one included, so each can be cancelled on its own.
+	Series []manageOccurrence `json:"series,omitempty"`
+}
+
+// manageOccurrence is a booked occurrence of a recurring series.
+type manageOccurrence struct {
+	EventID     string `json:"eventId"`
+	Start       string `json:"start"` // RFC 3339 in the visitor's timezone
+	End         string `json:"end"`
+	Cancellable bool   `json:"cancellable"`
+}
+
+// cancellationInfo tells the frontend how the cancellation policy applies to
+// a booking, so it can explain it to the visitor.
+type cancellationInfo struct {
+	// Deadline is when self-service cancellation and rescheduling close, as
+	// an RFC 3339 time in the visitor's timezone.
+	Deadline      string `json:"deadline"`
+	CutoffMinutes int    `json:"cutoffMinutes"`
+	// Contact is the email address to ask for changes after the deadline.
+	Contact string `json:"contact,omitempty"`
+}
+
+// cancellationInfo applies the cancellation policy to a booking starting at
+// start, rendering the deadline in loc.
+func (h *Handler) cancellationInfo(start time.Time, loc *time.Location) cancellationInfo {
+	policy := h.cfg.Cancellation
+	return cancellationInfo{
+		Deadline:      policy.Deadline(start).In(loc).Format(time.RFC3339),
+		CutoffMinutes: int(policy.Cutoff / time.Minute),
+		Contact:       policy.Contact,
+	}
+}
+
+// bookingCancellationInfo is cancellationInfo for a booked event, in the
+// visitor's timezone stored on it.
+func (h *Handler) bookingCancellationInfo(event *calendar.Event) cancellationInfo {
+	_, _, visitorTZ := bookingClient(event)
+	start, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+	return h.cancellationInfo(start, resolveVisitorTimezone(visitorTZ, h.gcalSvc.Location()))
+}
+
+// policyErrorResponse is the 409 body for a change the cancellation policy
+// does not allow.
+type policyErrorResponse struct {
+	Message      string            `json:"message"`
+	Cancellation *cancellationInfo `json:"cancellation,omitempty"`
+}
+
+// respondPolicyError writes the response for gcal.ErrCancellationClosed and
+// gcal.ErrBookingStarted and reports whether err was one of them. action is
+// what the visitor tried, e.g. "cancelled".
+func (h *Handler) respondPolicyError(w http.ResponseWriter, r *http.Request, token string, err error, action string) bool {
+	switch {
+	case errors.Is(err, gcal.ErrBookingStarted):
+		h.respondError(w, http.StatusConflict, fmt.Sprintf("This consultation has already started or taken place and can no longer be %s.", action))
+		return true
+	case errors.Is(err, gcal.ErrCancellationClosed):
+		policy := h.cfg.Cancellation
+		message := fmt.Sprintf("This booking starts in less than %s and can no longer be %s online.", formatCutoff(policy.Cutoff), action)
+		if policy.Contact != "" {
+			message += fmt.Sprintf(" Please contact us at %s if you cannot attend.", policy.Contact)
+		}
+		resp := policyErrorResponse{Message: message}
+		if event, err := h.gcalSvc.FindBooking(r.Context(), token); err == nil {
+			info := h.bookingCancellationInfo(event)
+			resp.Cancellation = &info
+		}
+		h.respondJSON(w, http.StatusConflict, resp)
+		return true
+	}
+	return false
+}
+
+// formatCutoff renders a cancellation cutoff for visitors, e.g. "24 hours".
+func formatCutoff(d time.Duration) string {
+	switch {
+	case d == time.Hour:
+		return "1 hour"
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	default:
+		return fmt.Sprintf("%d minutes", d/time.Minute)
+	}
+}
+
+// findBooking looks up the booking for the token query parameter and writes
+// the error response itself when there is none.
+func (h *Handler) findBooking(w http.ResponseWriter, r *http.Request) (*calendar.Event, bool) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		h.respondError(w, http.StatusBadRequest, "Cancellation token is required")
+		return nil, false
+	}
+
+	event, err := h.gcalSvc.FindBooking(r.Context(), token)
+	if err != nil {
+		h.logger.Error("Failed to look up booking", "token_prefix", tokenPrefix(token), "error", err)
+		if errors.Is(err, gcal.ErrSlotNotFound) {
+			h.respondError(w, http.StatusNotFound, "Booking not found. The link may be invalid or expired.")
+			return nil, false
+		}
+		h.respondError(w, http.StatusInternalServerError, "An internal error occurred while looking up the booking.")
+		return nil, false
+	}
+	return event, true
+}
+
+// bookingClient returns the client details stored on a booked event.
+func bookingClient(event *calendar.Event) (name, clientEmail, visitorTZ string) {
+	if event.ExtendedProperties == nil || event.ExtendedProperties.Private == nil {
+		return "", "", ""
+	}
+	private := event.ExtendedProperties.Private
+	return private["client_name"], private["client_email"], private["visitor_timezone"]
+}
+
+// handleGetBooking returns the booking identified by its cancellation token
+// without changing it.
+func (h *Handler) handleGetBooking(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.findBooking(w, r)
+	if !ok {
+		return
+	}
+
+	name, _, visitorTZ := bookingClient(event)
+	loc := resolveVisitorTimezone(visitorTZ, h.gcalSvc.Location())
+	start, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+	end, _ := time.Parse(time.RFC3339, event.End.DateTime)
+	sessionType := h.sessionTypeOf(event)
+
+	var series []manageOccurrence
+	occurrences, err := h.seriesOccurrences(r.Context(), event)
+	if err != nil {
+		h.logger.Error("Failed to list occurrences of series", "event_id", event.Id, "error", err)
+	}
+	for _, occurrence := range occurrences {
+		occurrenceStart, _ := time.Parse(time.RFC3339, occurrence.Start.DateTime)
+		occurrenceEnd, _ := time.Parse(time.RFC3339, occurrence.End.DateTime)
+		series = append(series, manageOccurrence{
+			EventID:     occurrence.Id,
+			Start:       occurrenceStart.In(loc).Format(time.RFC3339),
+			End:         occurrenceEnd.In(loc).Format(time.RFC3339),
+			Cancellable: time.Now().Before(h.cfg.Cancellation.Deadline(occurrenceStart)),
+		})
+	}
+
+	h.respondJSON(w, http.StatusOK, manageBookingResponse{
+		Name:            name,
+		Start:           start.In(loc).Format(time.RFC3339),
+		End:             end.In(loc).Format(time.RFC3339),
+		Timezone:        loc.String(),
+		TimezoneLabel:   start.In(loc).Format("MST"),
+		SessionType:     sessionType.ID,
+		SessionName:     sessionType.Name,
+		DurationMinutes: sessionType.DurationMinutes,
+		MeetLink:        conferencing.Link(event),
+		ConferenceName:  conferenceName(event),
+		Guests:          gcal.Guests(event),
+		Cancellable:     time.Now().Before(h.cfg.Cancellation.Deadline(start)),
+		Cancellation:    h.cancellationInfo(start, loc),
+		Series:          series,
+	})
+}
+
+// handleGetBookingICS lets the visitor download the calendar invitation of
+// their booking again. It carries the UID and sequence of the last email, so
+// importing it updates the entry the visitor already has. The invitation to a
+// recurring series leaves out the occurrences cancelled since.
+func (h *Handler) handleGetBookingICS(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.findBooking(w, r)
+	if !ok {
+		return
+	}
+
+	name, clientEmail, visitorTZ := bookingClient(event)
+	details := h.confirmationDetails(event, name, clientEmail, visitorTZ)
+	occurrences, err := h.seriesOccurrences(r.Context(), event)
+	if err != nil {
+		h.logger.Error("Failed to list occurrences of series", "event_id", event.Id, "error", err)
+		h.respondError(w, http.StatusInternalServerError, "An internal error occurred while looking up the booking.")
+		return
+	}
+	h.withSeries(&details, event, occurrences)
+	ics := ical.Generate(email.InviteDetails(details))
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="consultation.ics"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(ics)); err != nil {
+		h.logger.Error("could not write ICS response", "error", err)
+	}
+}
@@ -0,0 +1,201 @@
+package booking
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/gcal"
+)
+
+// TestManageBooking_ShowsBookingWithoutChangingIt books a slot, looks it up by
+// its token in the visitor's timezone and downloads the invitation again,
+// checking that neither request touches the event.
+func TestManageBooking_ShowsBookingWithoutChangingIt(t *testing.T) {
+	h, backend, _ := newTestHandler(t, nil, Options{})
+	backend.AddEvent(slot("slot1", "2030-06-17T13:30:00Z", "2030-06-17T14:00:00Z"))
+	booked, err := h.gcalSvc.BookSlot(gcal.BookingDetails{
+		EventID:         "slot1",
+		Name:            "Ada",
+		Email:           "ada@example.com",
+		VisitorTimezone: "Europe/Athens",
+	})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	token := booked.ExtendedProperties.Private["cancellation_token"]
+	etag := backend.Event("slot1").Etag
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/booking/manage?token="+token, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got manageBookingResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if got.Start != "2030-06-17T16:30:00+03:00" || got.Timezone != "Europe/Athens" || got.TimezoneLabel != "EEST" {
+		t.Errorf("expected the start in the visitor's timezone, got %+v", got)
+	}
+	if got.Name != "Ada" || !got.Cancellable {
+		t.Errorf("expected Ada's cancellable booking, got %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/booking/manage/ics?token="+token, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for the ICS, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Errorf("unexpected Content-Type %q", ct)
+	}
+	ics := rec.Body.String()
+	for _, want := range []string{"UID:" + booked.ExtendedProperties.Private["ics_uid"], "DTSTART:20300617T133000Z", "SEQUENCE:0", "mailto:ada@example.com"} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("expected the ICS to contain %q:\n%s", want, ics)
+		}
+	}
+
+	if backend.Event("slot1").Etag != etag {
+		t.Error("expected the lookups to leave the event unchanged")
+	}
+}
+
+// TestManageBooking_UnknownOrMissingToken checks the error responses.
+func TestManageBooking_UnknownOrMissingToken(t *testing.T) {
+	h, _, _ := newTestHandler(t, nil, Options{})
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	for path, want := range map[string]int{
+		"/api/booking/manage":                   http.StatusBadRequest,
+		"/api/booking/manage?token=unknown":     http.StatusNotFound,
+		"/api/booking/manage/ics?token=unknown": http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+}
+
+// TestManageBooking_SignedLink checks that with signing keys configured the
+// emailed link carries a signed token instead of the one stored on the event,
+// and that it manages the booking.
+func TestManageBooking_SignedLink(t *testing.T) {
+	cfg := testConfig()
+	cfg.Booking.Tokens = config.TokenConfig{Keys: []config.TokenKey{{ID: "k1", Secret: strings.Repeat("s", 32)}}, Grace: time.Hour}
+	h, backend, _ := newTestHandler(t, cfg, Options{})
+	backend.AddEvent(slot("slot1", "2030-06-17T13:30:00Z", "2030-06-17T14:00:00Z"))
+	booked, err := h.gcalSvc.BookSlot(gcal.BookingDetails{EventID: "slot1", Name: "Ada", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	link, err := url.Parse(h.confirmationDetails(booked, "Ada", "ada@example.com", "").CancellationURL)
+	if err != nil {
+		t.Fatalf("could not parse the management link: %v", err)
+	}
+	token := link.Query().Get("token")
+	if !strings.HasPrefix(token, "v1.k1.") || strings.Contains(token, booked.ExtendedProperties.Private["cancellation_token"]) {
+		t.Fatalf("expected a signed token in the link, got %q", token)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/booking/manage?token="+url.QueryEscape(token), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/booking/cancel", strings.NewReader(`{"token":"`+token+`"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the signed token to cancel, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestCancellationPolicy books one slot inside a 24h cutoff and one that has
+// already started: the visitor can cancel neither, the manage page explains
+// why, and only the admin override cancels the late one.
+func TestCancellationPolicy(t *testing.T) {
+	cfg := testConfig()
+	cfg.Booking.Cancellation = config.CancellationPolicy{Cutoff: 24 * time.Hour, Contact: "office@example.com"}
+	h, backend, _ := newTestHandler(t, cfg, Options{})
+	gcalSvc := h.gcalSvc
+	soon := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Minute)
+	past := time.Now().Add(-10 * time.Minute).UTC().Truncate(time.Minute)
+	for id, start := range map[string]time.Time{"soon": soon, "past": past} {
+		backend.AddEvent(slot(id, start.Format(time.RFC3339), start.Add(30*time.Minute).Format(time.RFC3339)))
+	}
+	tokens := map[string]string{}
+	for _, id := range []string{"soon", "past"} {
+		booked, err := gcalSvc.BookSlot(gcal.BookingDetails{EventID: id, Name: "Ada", Email: "ada@example.com"})
+		if err != nil {
+			t.Fatalf("BookSlot(%s) failed: %v", id, err)
+		}
+		tokens[id] = booked.ExtendedProperties.Private["cancellation_token"]
+	}
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	cancel := func(token string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		body := strings.NewReader(`{"token":"` + token + `"}`)
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/booking/cancel", body))
+		return rec
+	}
+
+	rec := cancel(tokens["soon"])
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 inside the cutoff, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var late policyErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &late); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if !strings.Contains(late.Message, "24 hours") || !strings.Contains(late.Message, "office@example.com") {
+		t.Errorf("expected the message to name the cutoff and the contact, got %q", late.Message)
+	}
+	if late.Cancellation == nil || late.Cancellation.Deadline != soon.Add(-24*time.Hour).Format(time.RFC3339) {
+		t.Errorf("expected the deadline a day before the start, got %+v", late.Cancellation)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/booking/manage?token="+tokens["soon"], nil))
+	var manage manageBookingResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &manage); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if manage.Cancellable || manage.Cancellation.CutoffMinutes != 24*60 {
+		t.Errorf("expected a booking that can no longer be cancelled online, got %+v", manage)
+	}
+
+	if rec := cancel(tokens["past"]); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "already started") {
+		t.Errorf("expected 409 for a started booking, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := gcalSvc.OverrideCancellation(t.Context(), "past"); err != gcal.ErrBookingStarted {
+		t.Errorf("expected the admin override to refuse a started booking, got %v", err)
+	}
+
+	cancelled, err := gcalSvc.OverrideCancellation(t.Context(), "soon")
+	if err != nil {
+		t.Fatalf("OverrideCancellation failed: %v", err)
+	}
+	if !gcal.LateCancellation(cancelled) {
+		t.Error("expected the overridden cancellation to be flagged as late")
+	}
+	if got := backend.Event("soon").Summary; got != "AfB" {
+		t.Errorf("expected the slot to be available again, got %q", got)
+	}
+}
@@ -1,6 +1,10 @@
 package booking
 
-import "time"
+import (
+	"time"
+
+	"ivmanto.com/backend/internal/gcal"
+)
 
 // TimeSlot represents an available time for a booking.
 type TimeSlot struct {
@@ -8,14 +12,35 @@ type TimeSlot struct {
 	EndTime   time.Time `json:"endTime"`
 }
 
-// Booking represents a confirmed appointment.
+// Booking represents a confirmed appointment. It is the public response to
+// POST /api/booking/book and holds only what the confirmation page shows: the
+// calendar event behind it, with its cancellation token, organizer and
+// conference details, never leaves the server.
 type Booking struct {
+	ID string `json:"id"`
+	// StartTime and EndTime are in the visitor's Timezone.
+	StartTime       time.Time `json:"startTime"`
+	EndTime         time.Time `json:"endTime"`
+	Timezone        string    `json:"timezone"`
+	SessionType     string    `json:"sessionType"`
+	SessionName     string    `json:"sessionName"`
+	DurationMinutes int       `json:"durationMinutes"`
+	MeetLink        string    `json:"meetLink,omitempty"`
+	ConferenceName  string    `json:"conferenceName,omitempty"` // e.g. "Zoom"
+	Name            string    `json:"name"`
+	Email           string    `json:"email"`
+	Guests          []string  `json:"guests,omitempty"`
+	// SeriesID and Occurrences are set for a recurring series: Occurrences
+	// are all of its bookings, this one included, in the visitor's Timezone.
+	SeriesID    string             `json:"seriesId,omitempty"`
+	Occurrences []SeriesOccurrence `json:"occurrences,omitempty"`
+}
+
+// SeriesOccurrence is one booking of a recurring series.
+type SeriesOccurrence struct {
 	ID        string    `json:"id"`
 	StartTime time.Time `json:"startTime"`
 	EndTime   time.Time `json:"endTime"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Notes     string    `json:"notes"`
 }
 
 // BookingRequest is the payload for creating a new booking.
@@ -25,3 +50,43 @@ type BookingRequest struct {
 	Email     string    `json:"email" validate:"required,email"`
 	Notes     string    `json:"notes"`
 }
+
+// Booking statuses reported by the admin API.
+const (
+	BookingUpcoming   = "upcoming"
+	BookingInProgress = "in_progress"
+	BookingPast       = "past"
+)
+
+// AdminBooking is the admin API's view of a booked consultation. It is built
+// from the event's private extended properties, so changes to how bookings
+// are stored on the calendar do not change the API.
+type AdminBooking struct {
+	ID     string `json:"id"`
+	Status string `json:"status"` // BookingUpcoming, BookingInProgress or BookingPast
+	// Start and End are in the booking calendar's timezone.
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	SessionType     string    `json:"sessionType"`
+	SessionName     string    `json:"sessionName"`
+	ClientName      string    `json:"clientName"`
+	ClientEmail     string    `json:"clientEmail"`
+	VisitorTimezone string    `json:"visitorTimezone,omitempty"`
+	MeetLink        string    `json:"meetLink,omitempty"`
+	ConferenceName  string    `json:"conferenceName,omitempty"`
+	Guests          []string  `json:"guests,omitempty"`
+	// Intake holds the client's answers to the session type's questionnaire.
+	Intake []gcal.IntakeAnswer `json:"intake,omitempty"`
+	// NoShow is set when the admin flagged that the client did not attend.
+	NoShow bool `json:"noShow"`
+	// Feedback is what the client said after the consultation, if anything.
+	Feedback *BookingFeedback `json:"feedback,omitempty"`
+}
+
+// BookingFeedback is the feedback a client gave on a consultation through the
+// link in the follow-up email.
+type BookingFeedback struct {
+	Rating      int       `json:"rating"`
+	Comment     string    `json:"comment,omitempty"`
+	SubmittedAt time.Time `json:"submittedAt"`
+}
@@ -0,0 +1,131 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"ivmanto.com/backend/internal/audit"
+	"ivmanto.com/backend/internal/gcal"
+)
+
+// Reminders sends reminder emails to clients ahead of their booked
+// consultations, at the offsets configured in BookingConfig.ReminderOffsets.
+//
+// Which reminders were sent is recorded on the calendar event itself, and the
+// record is written before the email goes out with a compare-and-swap on the
+// event's ETag. A restart therefore does not repeat reminders, and when several
+// instances poll at once only one of them sends each reminder. A reminder whose
+// email then fails to send is logged and not retried.
+type Reminders struct {
+	h        *Handler
+	offsets  []time.Duration
+	interval time.Duration
+	now      func() time.Time
+	stopCh   chan struct{}
+}
+
+// NewReminders creates the reminder job for the bookings managed by h.
+func NewReminders(h *Handler) *Reminders {
+	return &Reminders{
+		h:        h,
+		offsets:  h.cfg.ReminderOffsets,
+		interval: h.cfg.ReminderInterval,
+		now:      time.Now,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start looks for due reminders every interval in a background goroutine
+// until Stop is called. It does nothing when no offsets are configured.
+func (r *Reminders) Start() {
+	if len(r.offsets) == 0 {
+		r.h.logger.Info("Booking reminders are disabled")
+		return
+	}
+	r.h.logger.Info("Starting booking reminders", "offsets", r.offsets, "interval", r.interval)
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+				r.sendDue(ctx)
+				cancel()
+			case <-r.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop shuts down the background goroutine started by Start.
+func (r *Reminders) Stop() {
+	close(r.stopCh)
+}
+
+// sendDue sends the reminders that are due now. When several offsets of a
+// booking are due at once, e.g. for a booking made an hour before it starts,
+// they are recorded together and the client gets a single email.
+func (r *Reminders) sendDue(ctx context.Context) {
+	var horizon time.Duration
+	for _, offset := range r.offsets {
+		horizon = max(horizon, offset)
+	}
+	now := r.now()
+	bookings, err := r.h.gcalSvc.ListBookings(now, now.Add(horizon))
+	if err != nil {
+		r.h.logger.Error("Failed to list upcoming bookings for reminders", "error", err)
+		return
+	}
+
+	for _, event := range bookings {
+		start, err := time.Parse(time.RFC3339, event.Start.DateTime)
+		if err != nil {
+			r.h.logger.Error("Could not parse start time of booking for reminder", "event_id", event.Id, "error", err)
+			continue
+		}
+		var due []time.Duration
+		for _, offset := range r.offsets {
+			if start.Sub(now) <= offset && !gcal.ReminderSent(event, offset) {
+				due = append(due, offset)
+			}
+		}
+		if len(due) == 0 {
+			continue
+		}
+
+		marked, err := r.h.gcalSvc.MarkRemindersSent(ctx, event, due)
+		if errors.Is(err, gcal.ErrBookingChanged) {
+			// Another instance sent it, or the booking was just cancelled or
+			// moved; the next pass sees the current state.
+			r.h.logger.Info("Booking changed while sending reminder, skipping", "event_id", event.Id)
+			continue
+		}
+		if err != nil {
+			r.h.logger.Error("Failed to record reminder, not sending it", "event_id", event.Id, "error", err)
+			continue
+		}
+
+		private := marked.ExtendedProperties.Private
+		details := r.h.confirmationDetails(marked, private["client_name"], private["client_email"], private["visitor_timezone"])
+		if err := r.h.emailSvc.SendBookingReminder(details); err != nil {
+			r.h.logger.Error("Failed to send booking reminder", "event_id", event.Id, "client_email", details.ToEmail, "error", err)
+			continue
+		}
+		r.h.logger.Info("Sent booking reminder", "event_id", event.Id, "offsets", due)
+		offsets := make([]string, len(due))
+		for i, offset := range due {
+			offsets[i] = offset.String()
+		}
+		r.h.recordAudit(ctx, audit.Record{
+			Action:  audit.ActionReminderSent,
+			EventID: event.Id,
+			Actor:   audit.ActorSystem,
+			Details: map[string]string{"offsets": strings.Join(offsets, ",")},
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package booking
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/gcal"
+)
+
+// reminderStubCalendar holds booked events and, like the Calendar API,
+// rejects updates made with a stale ETag.
+type reminderStubCalendar struct {
+	gcal.Service
+	mu     sync.Mutex
+	events map[string]*calendar.Event
+	etag   int
+}
+
+func (s *reminderStubCalendar) Location() *time.Location { return time.UTC }
+
+func (s *reminderStubCalendar) ListBookings(from, to time.Time) ([]*calendar.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*calendar.Event
+	for _, e := range s.events {
+		start, _ := time.Parse(time.RFC3339, e.Start.DateTime)
+		if !start.Before(from) && start.Before(to) {
+			out = append(out, copyEvent(e))
+		}
+	}
+	return out, nil
+}
+
+func (s *reminderStubCalendar) MarkRemindersSent(ctx context.Context, event *calendar.Event, offsets []time.Duration) (*calendar.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.events[event.Id].Etag != event.Etag {
+		return nil, gcal.ErrBookingChanged
+	}
+	updated := copyEvent(event)
+	for _, offset := range offsets {
+		updated.ExtendedProperties.Private[gcal.ReminderProperty(offset)] = "sent"
+	}
+	s.etag++
+	updated.Etag = time.Duration(s.etag).String()
+	s.events[event.Id] = updated
+	return copyEvent(updated), nil
+}
+
+func copyEvent(e *calendar.Event) *calendar.Event {
+	out := *e
+	private := make(map[string]string, len(e.ExtendedProperties.Private))
+	for k, v := range e.ExtendedProperties.Private {
+		private[k] = v
+	}
+	out.ExtendedProperties = &calendar.EventExtendedProperties{Private: private}
+	return &out
+}
+
+func newReminderFixture(t *testing.T) (*reminderStubCalendar, *testEmails, func(now time.Time) *Reminders) {
+	t.Helper()
+	cal := &reminderStubCalendar{events: map[string]*calendar.Event{
+		"b1": {
+			Id:          "b1",
+			Etag:        "0",
+			Start:       &calendar.EventDateTime{DateTime: "2026-06-15T13:30:00Z"},
+			End:         &calendar.EventDateTime{DateTime: "2026-06-15T14:00:00Z"},
+			HangoutLink: "https://meet.google.com/abc-defg-hij",
+			ExtendedProperties: &calendar.EventExtendedProperties{Private: map[string]string{
+				"client_name":        "Ada",
+				"client_email":       "ada@example.com",
+				"visitor_timezone":   "Europe/Athens",
+				"cancellation_token": "tok",
+			}},
+		},
+	}}
+	emails := &testEmails{}
+	cfg := &config.BookingConfig{ReminderOffsets: []time.Duration{24 * time.Hour, time.Hour}, ReminderInterval: time.Minute}
+	h := NewHandler(discardLogger, cal, emails, nil, cfg, Options{})
+	newReminders := func(now time.Time) *Reminders {
+		r := NewReminders(h)
+		r.now = func() time.Time { return now }
+		return r
+	}
+	return cal, emails, newReminders
+}
+
+// TestReminders_SendsEachOffsetOnce walks a fake clock towards a booking and
+// checks that each reminder goes out once, also across restarts, rendered in
+// the visitor's timezone with the Meet and cancel links.
+func TestReminders_SendsEachOffsetOnce(t *testing.T) {
+	_, emails, newReminders := newReminderFixture(t)
+	start := time.Date(2026, 6, 15, 13, 30, 0, 0, time.UTC)
+
+	steps := []struct {
+		before time.Duration
+		want   int
+	}{
+		{30 * time.Hour, 0},
+		{23 * time.Hour, 1},
+		{22 * time.Hour, 1},
+		{50 * time.Minute, 2},
+		{10 * time.Minute, 2},
+	}
+	for _, step := range steps {
+		// A fresh Reminders per step behaves like a restarted instance.
+		newReminders(start.Add(-step.before)).sendDue(t.Context())
+		if got := len(emails.reminders); got != step.want {
+			t.Fatalf("%v before the start: expected %d reminders in total, got %d", step.before, step.want, got)
+		}
+	}
+
+	got := emails.reminders[0]
+	if got.ToEmail != "ada@example.com" || got.MeetLink != "https://meet.google.com/abc-defg-hij" {
+		t.Errorf("unexpected recipient or Meet link: %+v", got)
+	}
+	if got.CancellationURL != "https://ivmanto.com/booking/cancel?token=tok" {
+		t.Errorf("unexpected cancellation URL %q", got.CancellationURL)
+	}
+	if got.StartTime.Location().String() != "Europe/Athens" {
+		t.Errorf("expected the reminder in the visitor's timezone, got %v (%s)", got.StartTime, got.Timezone)
+	}
+}
+
+// TestReminders_ConcurrentInstancesSendOnce runs several instances at the same
+// moment; the ETag check lets exactly one of them send the reminder.
+func TestReminders_ConcurrentInstancesSendOnce(t *testing.T) {
+	_, emails, newReminders := newReminderFixture(t)
+	now := time.Date(2026, 6, 14, 14, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			newReminders(now).sendDue(t.Context())
+		}()
+	}
+	wg.Wait()
+
+	if got := len(emails.reminders); got != 1 {
+		t.Fatalf("expected exactly one reminder, got %d", got)
+	}
+}
@@ -0,0 +1,289 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"ivmanto.com/backend/internal/audit"
+	"ivmanto.com/backend/internal/gcal"
+	"ivmanto.com/backend/internal/idempotency"
+)
+
+const (
+	// retentionInterval is how often past bookings are checked for data due
+	// for erasure. The retention period is counted in days, so hourly is
+	// plenty.
+	retentionInterval = time.Hour
+	// retentionLookback bounds how far back the retention job looks. Erased
+	// bookings are no longer listed, so after the first pass it only sees
+	// the bookings that became due since.
+	retentionLookback = 10 * 365 * 24 * time.Hour
+)
+
+// How an erasure touched a booking, as listed in erasureReport.
+const (
+	erasureErased       = "erased"        // a past booking was erased in place
+	erasureCancelled    = "cancelled"     // an upcoming booking was cancelled and its slot released
+	erasureGuestRemoved = "guest_removed" // the address was taken off the guests
+)
+
+// Retention erases the personal data of clients and their guests: from past
+// bookings once BookingConfig.RetentionPeriod has passed since they ended, and
+// from everything the service keeps when the admin files an erasure request
+// (see handleAdminErasePersonalData).
+//
+// Erased bookings stay in the calendar as busy time with their session type,
+// so statistics keep working, and their feedback keeps its rating.
+type Retention struct {
+	h           *Handler
+	idempotency idempotency.Store // nil if responses are not kept
+	after       time.Duration
+	now         func() time.Time
+	stopCh      chan struct{}
+}
+
+// NewRetention creates the retention job and erasure endpoint for the bookings
+// managed by h. Responses stored in idempotencyStore are searched on erasure
+// requests, as the response to a booking names the client.
+func NewRetention(h *Handler, idempotencyStore idempotency.Store) *Retention {
+	return &Retention{
+		h:           h,
+		idempotency: idempotencyStore,
+		after:       h.cfg.RetentionPeriod,
+		now:         time.Now,
+		stopCh:      make(chan struct{}),
+	}
+}
+
+// RegisterAdminRoutes sets up the erasure endpoint. Like the other admin
+// endpoints, the caller mounts it behind authentication.
+func (r *Retention) RegisterAdminRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("DELETE /api/admin/personal-data/{email}", r.handleAdminErasePersonalData)
+}
+
+// Start erases the data of bookings past the retention period every
+// retentionInterval in a background goroutine until Stop is called. It does
+// nothing when no retention period is configured.
+func (r *Retention) Start() {
+	if r.after == 0 {
+		r.h.logger.Info("Booking data retention is disabled")
+		return
+	}
+	r.h.logger.Info("Starting booking data retention", "retention", r.after, "interval", retentionInterval)
+	go func() {
+		ticker := time.NewTicker(retentionInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), retentionInterval)
+				r.eraseDue(ctx)
+				cancel()
+			case <-r.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop shuts down the background goroutine started by Start.
+func (r *Retention) Stop() {
+	close(r.stopCh)
+}
+
+// eraseDue erases the bookings that ended more than the retention period ago,
+// and the clients and guests from the audit records about them.
+func (r *Retention) eraseDue(ctx context.Context) {
+	cutoff := r.now().Add(-r.after)
+	if expirer, ok := r.h.auditLog.(audit.Expirer); ok {
+		if n, err := expirer.Expire(ctx, cutoff); err != nil {
+			r.h.logger.Error("Failed to erase personal data from expired audit records", "error", err)
+		} else if n > 0 {
+			r.h.logger.Info("Erased personal data from expired audit records", "records", n)
+		}
+	}
+	bookings, err := r.h.gcalSvc.ListBookings(cutoff.Add(-retentionLookback), cutoff)
+	if err != nil {
+		r.h.logger.Error("Failed to list past bookings for data retention", "error", err)
+		return
+	}
+
+	for _, event := range bookings {
+		end, err := time.Parse(time.RFC3339, event.End.DateTime)
+		if err != nil {
+			r.h.logger.Error("Could not parse end time of booking for data retention", "event_id", event.Id, "error", err)
+			continue
+		}
+		if end.After(cutoff) {
+			continue
+		}
+		if _, err := r.h.gcalSvc.EraseBooking(ctx, event); errors.Is(err, gcal.ErrBookingChanged) {
+			r.h.logger.Info("Booking changed while erasing its data, skipping", "event_id", event.Id)
+			continue
+		} else if err != nil {
+			r.h.logger.Error("Failed to erase personal data of booking", "event_id", event.Id, "error", err)
+			continue
+		}
+		r.h.recordAudit(ctx, audit.Record{
+			Action:  audit.ActionErase,
+			EventID: event.Id,
+			Actor:   audit.ActorSystem,
+			Details: map[string]string{"reason": "retention"},
+		})
+	}
+}
+
+// erasureReport lists what an erasure request touched. Failures name the
+// steps that did not complete; the request can be repeated to retry them, as
+// erased data is not found again.
+type erasureReport struct {
+	Email    string          `json:"email"`
+	ErasedAt time.Time       `json:"erasedAt"`
+	Bookings []erasedBooking `json:"bookings"`
+	// WaitlistEntries were deleted. FeedbackEntries are the erased bookings
+	// whose feedback lost its comment and kept its rating.
+	WaitlistEntries int `json:"waitlistEntries"`
+	FeedbackEntries int `json:"feedbackEntries"`
+	// IdempotencyRecords are stored responses that named the address.
+	IdempotencyRecords int `json:"idempotencyRecords"`
+	// AuditRecords had the address removed from their booking snapshots.
+	AuditRecords int `json:"auditRecords"`
+	// WebhookDeadLetters are failed webhook deliveries, of bookings or contact
+	// messages, that named the address and were deleted.
+	WebhookDeadLetters int `json:"webhookDeadLetters"`
+	// Notes name data that this endpoint cannot reach.
+	Notes    []string `json:"notes,omitempty"`
+	Failures []string `json:"failures,omitempty"`
+}
+
+// erasedBooking is a booking touched by an erasure request.
+type erasedBooking struct {
+	EventID string `json:"eventId"`
+	Start   string `json:"start"`
+	Action  string `json:"action"` // erasureErased, erasureCancelled or erasureGuestRemoved
+}
+
+// handleAdminErasePersonalData erases everything the service keeps about the
+// client or guest with the given email address, e.g. on a GDPR erasure
+// request, and responds with a report of what it touched.
+func (r *Retention) handleAdminErasePersonalData(w http.ResponseWriter, req *http.Request) {
+	address := req.PathValue("email")
+	if parsed, err := mail.ParseAddress(address); err != nil || parsed.Address != address {
+		r.h.respondError(w, http.StatusBadRequest, "Invalid email address.")
+		return
+	}
+	ctx := req.Context()
+	report := erasureReport{Email: address, ErasedAt: r.now().UTC(), Bookings: []erasedBooking{}}
+	fail := func(step string, err error) {
+		r.h.logger.Error("Erasure request step failed", "step", step, "error", err)
+		report.Failures = append(report.Failures, step)
+	}
+
+	r.eraseBookings(ctx, req, address, &report, fail)
+	if r.h.waitlist != nil {
+		if err := r.eraseWaitlist(ctx, address, &report); err != nil {
+			fail("waitlist", err)
+		}
+	}
+	if r.idempotency != nil {
+		n, err := r.idempotency.Forget(ctx, address)
+		report.IdempotencyRecords = n
+		if err != nil {
+			fail("idempotency", err)
+		}
+	}
+	switch eraser, ok := r.h.auditLog.(audit.Eraser); {
+	case ok:
+		n, err := eraser.Erase(ctx, address)
+		report.AuditRecords = n
+		if err != nil {
+			fail("audit", err)
+		}
+	case r.h.auditLog != nil:
+		report.Notes = append(report.Notes, "Audit records are kept in the service logs and must be erased there.")
+	}
+	if r.h.webhooks != nil {
+		n, err := r.h.webhooks.Forget(ctx, address)
+		report.WebhookDeadLetters = n
+		if err != nil {
+			fail("webhook dead letters", err)
+		}
+		if r.h.webhooks.LogsDeadLetters() {
+			report.Notes = append(report.Notes, "Failed webhook deliveries are kept in the service logs and must be erased there.")
+		}
+		report.Notes = append(report.Notes, "Webhook receivers were sent the bookings and contact messages of the address and must erase them themselves.")
+	}
+	report.Notes = append(report.Notes, "Contact form messages are emailed to the admin and must be erased from the mailbox.")
+
+	r.h.logger.Info("Processed erasure request", "bookings", len(report.Bookings), "failures", len(report.Failures))
+	r.h.respondJSON(w, http.StatusOK, report)
+}
+
+// eraseBookings erases address from the bookings naming it. Past bookings of
+// the client are erased in place; upcoming ones are cancelled, as their slot
+// cannot be kept for no one. A guest is only taken off the guest list.
+func (r *Retention) eraseBookings(ctx context.Context, req *http.Request, address string, report *erasureReport, fail func(string, error)) {
+	bookings, err := r.h.gcalSvc.BookingsOf(ctx, address)
+	if err != nil {
+		fail("bookings", err)
+		return
+	}
+	now := r.now()
+	for _, event := range bookings {
+		_, clientEmail, _ := bookingClient(event)
+		start, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+		action := erasureErased
+		var err error
+		switch {
+		case !strings.EqualFold(clientEmail, address):
+			action = erasureGuestRemoved
+			_, err = r.h.gcalSvc.RemoveGuest(ctx, event.Id, address)
+		case start.After(now):
+			action = erasureCancelled
+			_, err = r.h.gcalSvc.OverrideCancellation(ctx, event.Id)
+		default:
+			_, err = r.h.gcalSvc.EraseBooking(ctx, event)
+		}
+		if err != nil {
+			fail("booking "+event.Id, err)
+			continue
+		}
+		if _, ok := gcal.FeedbackOf(event); ok && action == erasureErased {
+			report.FeedbackEntries++
+		}
+		if action == erasureCancelled && r.h.waitlist != nil {
+			r.h.waitlist.SlotsChanged()
+		}
+		report.Bookings = append(report.Bookings, erasedBooking{EventID: event.Id, Start: event.Start.DateTime, Action: action})
+		r.h.recordAudit(ctx, audit.Record{
+			Action:  audit.ActionErase,
+			EventID: event.Id,
+			Actor:   audit.ActorAdmin,
+			IP:      audit.ClientIP(req),
+			Details: map[string]string{"reason": "request", "result": action},
+		})
+	}
+}
+
+// eraseWaitlist deletes the waitlist entries of address.
+func (r *Retention) eraseWaitlist(ctx context.Context, address string, report *erasureReport) error {
+	entries, err := r.h.waitlist.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !strings.EqualFold(entry.Email, address) {
+			continue
+		}
+		if err := r.h.waitlist.store.Delete(ctx, entry.ID); err != nil {
+			return err
+		}
+		report.WaitlistEntries++
+	}
+	return nil
+}
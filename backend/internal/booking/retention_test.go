@@ -0,0 +1,164 @@
+package booking
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ivmanto.com/backend/internal/audit"
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/gcal"
+	"ivmanto.com/backend/internal/idempotency"
+	"ivmanto.com/backend/internal/waitlist"
+	"ivmanto.com/backend/internal/webhook"
+	"ivmanto.com/backend/internal/webhook/webhooktest"
+)
+
+// recordFeedback records fb on the booked event eventID.
+func recordFeedback(t *testing.T, gcalSvc gcal.Service, eventID string, fb gcal.Feedback) {
+	t.Helper()
+	event, err := gcalSvc.GetBooking(t.Context(), eventID)
+	if err != nil {
+		t.Fatalf("GetBooking failed: %v", err)
+	}
+	if _, err := gcalSvc.RecordFeedback(t.Context(), event, fb); err != nil {
+		t.Fatalf("RecordFeedback failed: %v", err)
+	}
+}
+
+// TestRetention_EraseDue erases a booking past the retention period, with its
+// feedback and the client in its audit records, and leaves a more recent one
+// alone.
+func TestRetention_EraseDue(t *testing.T) {
+	cfg := testConfig()
+	cfg.Booking.RetentionPeriod = 30 * 24 * time.Hour
+	auditLog, err := audit.NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+	h, backend, _ := newTestHandler(t, cfg, Options{AuditLog: auditLog})
+	gcalSvc := h.gcalSvc
+	backend.AddEvent(slot("old", "2026-03-02T09:00:00Z", "2026-03-02T09:30:00Z"))
+	backend.AddEvent(slot("recent", "2026-05-25T09:00:00Z", "2026-05-25T09:30:00Z"))
+	for _, id := range []string{"old", "recent"} {
+		if _, err := gcalSvc.BookSlot(gcal.BookingDetails{EventID: id, Name: "Ada", Email: "ada@example.com"}); err != nil {
+			t.Fatalf("BookSlot failed: %v", err)
+		}
+	}
+	recordFeedback(t, gcalSvc, "old", gcal.Feedback{Rating: 5, Comment: "Thanks, Ada"})
+	for _, id := range []string{"old", "recent"} {
+		auditLog.Append(t.Context(), audit.Record{Action: audit.ActionBook, EventID: id, Actor: audit.ActorClient, After: audit.SnapshotOf(backend.Event(id))})
+	}
+	r := NewRetention(h, nil)
+	r.now = func() time.Time { return time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC) }
+
+	r.eraseDue(t.Context())
+
+	if old := backend.Event("old"); !gcal.Erased(old) || old.ExtendedProperties.Private["client_email"] != "" {
+		t.Errorf("expected the old booking to be erased, got %+v", old.ExtendedProperties.Private)
+	}
+	if fb, ok := gcal.FeedbackOf(backend.Event("old")); !ok || fb.Rating != 5 || fb.Comment != "" {
+		t.Errorf("expected the feedback to keep only its rating, got %+v", fb)
+	}
+	if _, err := gcalSvc.GetBooking(t.Context(), "recent"); err != nil {
+		t.Errorf("expected the recent booking to be kept, got %v", err)
+	}
+	if history, _ := auditLog.(audit.Querier).History(t.Context(), "old"); len(history) == 0 || history[0].After.ClientEmail != "" {
+		t.Errorf("expected the client to be erased from the old audit records, got %+v", history)
+	}
+	if history, _ := auditLog.(audit.Querier).History(t.Context(), "recent"); len(history) == 0 || history[0].After.ClientEmail != "ada@example.com" {
+		t.Errorf("expected the recent audit records to be kept, got %+v", history)
+	}
+}
+
+// TestErasePersonalData files an erasure request for a client with a past and
+// an upcoming booking, who is also a guest, on the waitlist, in the feedback,
+// the idempotency store, the audit log and the webhook dead letters.
+func TestErasePersonalData(t *testing.T) {
+	cfg := testConfig()
+	cfg.Booking.MaxGuests = 2
+	waitlistStore, _ := waitlist.NewFileStore("")
+	sink, err := audit.NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+	deadLetters := filepath.Join(t.TempDir(), "dead.jsonl")
+	os.WriteFile(deadLetters, []byte(`{"payload":{"type":"contact.received","data":{"email":"ada@example.com"}}}`+"\n"+`{"payload":{"type":"contact.received","data":{"email":"cy@example.com"}}}`+"\n"), 0o600)
+	webhooks, err := webhook.NewDispatcher(discardLogger, config.WebhookConfig{Targets: []config.WebhookTarget{webhooktest.NewReceiver(t).Target()}, DeadLetterFile: deadLetters})
+	if err != nil {
+		t.Fatalf("NewDispatcher failed: %v", err)
+	}
+	defer webhooks.Stop()
+	h, backend, emails := newTestHandler(t, cfg, Options{AuditLog: sink, Webhooks: webhooks})
+	h.waitlist = NewWaitlist(discardLogger, h.gcalSvc, emails, waitlistStore, &cfg.Booking)
+	gcalSvc := h.gcalSvc
+	backend.AddEvent(slot("past", "2026-03-02T09:00:00Z", "2026-03-02T09:30:00Z"))
+	backend.AddEvent(slot("upcoming", "2030-06-17T13:30:00Z", "2030-06-17T14:00:00Z"))
+	backend.AddEvent(slot("guest", "2030-06-18T13:30:00Z", "2030-06-18T14:00:00Z"))
+	ctx := t.Context()
+	for _, details := range []gcal.BookingDetails{
+		{EventID: "past", Name: "Ada", Email: "ada@example.com"},
+		{EventID: "upcoming", Name: "Ada", Email: "Ada@Example.com"},
+		{EventID: "guest", Name: "Bob", Email: "bob@example.com", Guests: []string{"ada@example.com"}},
+	} {
+		if _, err := gcalSvc.BookSlot(details); err != nil {
+			t.Fatalf("BookSlot failed: %v", err)
+		}
+	}
+
+	waitlistStore.Save(ctx, waitlist.Entry{ID: "w1", Email: "ada@example.com"})
+	waitlistStore.Save(ctx, waitlist.Entry{ID: "w2", Email: "cy@example.com"})
+	recordFeedback(t, gcalSvc, "past", gcal.Feedback{Rating: 4, Comment: "Great"})
+	idempotencyStore := idempotency.NewMemoryStore(time.Hour)
+	idempotencyStore.Reserve(ctx, "k", "fp")
+	idempotencyStore.Complete(ctx, "k", idempotency.Response{Status: 201, Body: []byte(`{"email":"ada@example.com"}`)})
+	sink.Append(ctx, audit.Record{Action: audit.ActionBook, EventID: "past", Actor: audit.ActorClient, After: &audit.Snapshot{ClientEmail: "ada@example.com"}})
+
+	mux := http.NewServeMux()
+	NewRetention(h, idempotencyStore).RegisterAdminRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/admin/personal-data/ada@example.com", nil))
+	var report erasureReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected a report, got %d: %s", rec.Code, rec.Body.String())
+	}
+	actions := map[string]string{}
+	for _, b := range report.Bookings {
+		actions[b.EventID] = b.Action
+	}
+	if actions["past"] != erasureErased || actions["upcoming"] != erasureCancelled || actions["guest"] != erasureGuestRemoved {
+		t.Errorf("unexpected bookings in the report: %+v", report.Bookings)
+	}
+	if report.WaitlistEntries != 1 || report.FeedbackEntries != 1 || report.IdempotencyRecords != 1 || report.AuditRecords != 1 || report.WebhookDeadLetters != 1 || len(report.Failures) != 0 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	if _, err := gcalSvc.GetBooking(ctx, "upcoming"); err != gcal.ErrSlotNotFound {
+		t.Errorf("expected the upcoming booking to be cancelled, got %v", err)
+	}
+	if guests := gcal.Guests(backend.Event("guest")); len(guests) != 0 {
+		t.Errorf("expected Ada to be off the guest list, got %v", guests)
+	}
+	if entries, _ := waitlistStore.List(ctx); len(entries) != 1 || entries[0].ID != "w2" {
+		t.Errorf("expected only the other visitor on the waitlist, got %+v", entries)
+	}
+	if data, _ := os.ReadFile(deadLetters); strings.Contains(string(data), "ada@example.com") || !strings.Contains(string(data), "cy@example.com") {
+		t.Errorf("expected only the other visitor's dead letter to be kept, got %s", data)
+	}
+	history, _ := sink.(audit.Querier).History(ctx, "past")
+	if len(history) != 2 || history[0].After.ClientEmail != "" || history[1].Action != audit.ActionErase {
+		t.Errorf("expected the erased booking record and the erasure, got %+v", history)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/admin/personal-data/ada@example.com", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil || len(report.Bookings) != 0 || report.WaitlistEntries != 0 {
+		t.Errorf("expected nothing left to erase, got %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,234 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/audit"
+	"ivmanto.com/backend/internal/email"
+	"ivmanto.com/backend/internal/gcal"
+	"ivmanto.com/backend/internal/webhook"
+)
+
+// recurrenceRequest asks for a recurring series of bookings at the time of the
+// booked slot, e.g. for a retainer client who meets every week.
+type recurrenceRequest struct {
+	// Frequency is "weekly" or "biweekly".
+	Frequency string `json:"frequency"`
+	// Count is the number of bookings, the first included. Until is the last
+	// day (YYYY-MM-DD, in the calendar's timezone) a booking may fall on.
+	// Exactly one of them is set.
+	Count int    `json:"count,omitempty"`
+	Until string `json:"until,omitempty"`
+}
+
+// rule converts req into the recurrence rule of a series in the calendar's
+// timezone loc. The error message is meant for the visitor.
+func (req recurrenceRequest) rule(loc *time.Location) (gcal.Recurrence, error) {
+	var rule gcal.Recurrence
+	switch req.Frequency {
+	case "weekly":
+		rule.IntervalWeeks = 1
+	case "biweekly":
+		rule.IntervalWeeks = 2
+	default:
+		return rule, errors.New(`The recurrence frequency must be "weekly" or "biweekly".`)
+	}
+	if (req.Count == 0) == (req.Until == "") {
+		return rule, errors.New("A recurring series needs either a count or an until date.")
+	}
+	rule.Count = req.Count
+	if req.Until != "" {
+		until, err := time.ParseInLocation("2006-01-02", req.Until, loc)
+		if err != nil {
+			return rule, errors.New("invalid until date format, use YYYY-MM-DD")
+		}
+		rule.Until = until
+	}
+	return rule, nil
+}
+
+// seriesUnavailableResponse is the 409 body for a series some of whose
+// bookings cannot be made.
+type seriesUnavailableResponse struct {
+	Message string `json:"message"`
+	// Unavailable are the starts of the missing bookings, as RFC 3339 times
+	// in the visitor's timezone.
+	Unavailable []string `json:"unavailable"`
+}
+
+// respondSeriesError writes the response for the errors of
+// gcal.Service.BookSeries that concern the series rather than the booking,
+// and reports whether err was one of them.
+func (h *Handler) respondSeriesError(w http.ResponseWriter, err error, visitorTZ string) bool {
+	var unavailable *gcal.SeriesUnavailableError
+	switch {
+	case errors.As(err, &unavailable):
+		loc := resolveVisitorTimezone(visitorTZ, h.gcalSvc.Location())
+		resp := seriesUnavailableResponse{Message: "Some dates of the series are not available. Please choose another time or fewer sessions."}
+		for _, start := range unavailable.Starts {
+			resp.Unavailable = append(resp.Unavailable, start.In(loc).Format(time.RFC3339))
+		}
+		h.respondJSON(w, http.StatusConflict, resp)
+		return true
+	case errors.Is(err, gcal.ErrInvalidRecurrence):
+		h.respondError(w, http.StatusBadRequest, fmt.Sprintf("A recurring series has between 2 and %d sessions.", gcal.MaxSeriesOccurrences))
+		return true
+	}
+	return false
+}
+
+// seriesOccurrences returns the booked occurrences of the series event
+// belongs to, or nil if it is a single booking.
+func (h *Handler) seriesOccurrences(ctx context.Context, event *calendar.Event) ([]*calendar.Event, error) {
+	series, ok := gcal.SeriesOf(event)
+	if !ok {
+		return nil, nil
+	}
+	return h.gcalSvc.SeriesBookings(ctx, series.ID)
+}
+
+// withSeries describes the recurring series of event, whose booked
+// occurrences are given, in the confirmation details, so the invitation
+// covers the whole series without the cancelled occurrences. Every
+// cancellation revises the invitation. It does nothing for a single booking.
+func (h *Handler) withSeries(details *email.BookingConfirmationDetails, event *calendar.Event, occurrences []*calendar.Event) {
+	series, ok := gcal.SeriesOf(event)
+	if !ok {
+		return
+	}
+	loc := h.gcalSvc.Location()
+	booked := make(map[int64]bool, len(occurrences))
+	for _, occurrence := range occurrences {
+		if start, err := time.Parse(time.RFC3339, occurrence.Start.DateTime); err == nil {
+			booked[start.Unix()] = true
+		}
+	}
+	start, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+	end, _ := time.Parse(time.RFC3339, event.End.DateTime)
+	info := &email.BookingSeries{
+		IntervalWeeks: series.IntervalWeeks,
+		Count:         series.Count,
+		Start:         series.Start.In(loc),
+		End:           series.Start.In(loc).Add(end.Sub(start)),
+		Location:      loc,
+	}
+	for _, occurrence := range series.Starts(loc) {
+		if !booked[occurrence.Unix()] {
+			info.Cancelled = append(info.Cancelled, occurrence)
+		}
+	}
+	details.Series = info
+	details.IcsSequence += len(info.Cancelled)
+}
+
+// withOccurrences lists the bookings of a newly booked series in the
+// response to the visitor.
+func (h *Handler) withOccurrences(booking *Booking, events []*calendar.Event, visitorTZ string) {
+	series, ok := gcal.SeriesOf(events[0])
+	if !ok {
+		return
+	}
+	loc := resolveVisitorTimezone(visitorTZ, h.gcalSvc.Location())
+	booking.SeriesID = series.ID
+	for _, event := range events {
+		start, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+		end, _ := time.Parse(time.RFC3339, event.End.DateTime)
+		booking.Occurrences = append(booking.Occurrences, SeriesOccurrence{ID: event.Id, StartTime: start.In(loc), EndTime: end.In(loc)})
+	}
+}
+
+// seriesCancelResponse confirms the cancellation of a recurring series. Start
+// times are RFC 3339 times in the visitor's timezone.
+type seriesCancelResponse struct {
+	Message   string   `json:"message"`
+	Cancelled []string `json:"cancelled"`
+	// Kept are the occurrences that have started or are inside the
+	// cancellation cutoff.
+	Kept []string `json:"kept"`
+}
+
+// handleCancelSeries cancels all occurrences of the recurring series of the
+// booking the token was issued for that can still be cancelled, and emails
+// the client once for all of them.
+func (h *Handler) handleCancelSeries(w http.ResponseWriter, r *http.Request, token string) {
+	cancelled, kept, err := h.gcalSvc.CancelSeries(r.Context(), token)
+	if err != nil {
+		h.logger.Error("Failed to cancel series", "token_prefix", tokenPrefix(token), "cancelled", len(cancelled), "error", err)
+	}
+	if err != nil && len(cancelled) == 0 {
+		if h.respondPolicyError(w, r, token, err, "cancelled") {
+			return
+		}
+		switch {
+		case errors.Is(err, gcal.ErrNotSeries):
+			h.respondError(w, http.StatusBadRequest, "This booking is not part of a recurring series.")
+		case errors.Is(err, gcal.ErrSlotNotFound):
+			h.respondError(w, http.StatusNotFound, "Booking not found. The link may be invalid or expired.")
+		default:
+			h.respondError(w, http.StatusInternalServerError, "An internal error occurred while cancelling the booking.")
+		}
+		return
+	}
+
+	resp := seriesCancelResponse{Message: "Series cancelled successfully", Cancelled: []string{}, Kept: []string{}}
+	var starts []time.Time
+	var clientName, clientEmail string
+	var visitorLoc *time.Location
+	for _, originalEvent := range cancelled {
+		h.recordAudit(r.Context(), audit.Record{
+			Action:  audit.ActionCancel,
+			EventID: originalEvent.Id,
+			Actor:   audit.ActorClient,
+			IP:      audit.ClientIP(r),
+			Before:  audit.SnapshotOf(originalEvent),
+			Details: map[string]string{"series": "true"},
+		})
+		webhookData := h.bookingWebhookOf(originalEvent)
+		webhookData.CancelledBy = audit.ActorClient
+		h.webhooks.Send(webhook.EventBookingCancelled, webhookData)
+
+		snapshot := audit.SnapshotOf(originalEvent)
+		clientName, clientEmail = snapshot.ClientName, snapshot.ClientEmail
+		visitorLoc = resolveVisitorTimezone(snapshot.VisitorTimezone, h.gcalSvc.Location())
+		start, _ := time.Parse(time.RFC3339, originalEvent.Start.DateTime)
+		starts = append(starts, start)
+		resp.Cancelled = append(resp.Cancelled, start.In(visitorLoc).Format(time.RFC3339))
+	}
+	for _, event := range kept {
+		_, _, visitorTZ := bookingClient(event)
+		start, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+		resp.Kept = append(resp.Kept, start.In(resolveVisitorTimezone(visitorTZ, h.gcalSvc.Location())).Format(time.RFC3339))
+	}
+	h.logger.Info("Series cancelled", "cancelled", len(cancelled), "kept", len(kept))
+
+	if len(cancelled) > 0 {
+		if h.waitlist != nil {
+			h.waitlist.SlotsChanged()
+		}
+		go func() {
+			if err := h.emailSvc.SendBookingSeriesCancellationToClient(clientName, clientEmail, starts, visitorLoc); err != nil {
+				h.logger.Error("Failed to send series cancellation email to client", "client_email", clientEmail, "error", err)
+			}
+		}()
+		go func() {
+			calLoc := h.gcalSvc.Location()
+			for _, start := range starts {
+				if err := h.emailSvc.SendBookingCancellationToAdmin(clientName, clientEmail, start.In(calLoc)); err != nil {
+					h.logger.Error("Failed to send cancellation notification to admin", "error", err)
+				}
+			}
+		}()
+	}
+
+	if err != nil {
+		resp.Message = "Some sessions of the series could not be cancelled. Please try again."
+		h.respondJSON(w, http.StatusInternalServerError, resp)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,117 @@
+package booking
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ivmanto.com/backend/internal/gcal"
+	"ivmanto.com/backend/internal/waitlist"
+)
+
+// TestSeries_BookAndCancel books a weekly series over HTTP, cancels one of its
+// sessions and then the rest of the series.
+func TestSeries_BookAndCancel(t *testing.T) {
+	h, backend, emails := newTestHandler(t, nil, Options{})
+	gcalSvc := h.gcalSvc
+	for id, start := range map[string]string{"slot1": "2030-06-17T13:30:00Z", "slot2": "2030-06-24T13:30:00Z", "slot3": "2030-07-01T13:30:00Z"} {
+		backend.AddEvent(slot(id, start, strings.Replace(start, "13:30", "14:00", 1)))
+	}
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	do := func(method, path string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		raw, _ := json.Marshal(body)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewReader(raw)))
+		return rec
+	}
+	book := createBookingRequest{EventID: "slot1", Name: "Ada", Email: "ada@example.com", Recurrence: &recurrenceRequest{Frequency: "weekly", Count: 4}}
+
+	rec := do(http.MethodPost, "/api/booking/book", book)
+	var unavailable seriesUnavailableResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &unavailable); err != nil || rec.Code != http.StatusConflict || len(unavailable.Unavailable) != 1 || unavailable.Unavailable[0] != "2030-07-08T13:30:00Z" {
+		t.Fatalf("expected the fourth week to be unavailable, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := gcalSvc.GetBooking(t.Context(), "slot1"); err != gcal.ErrSlotNotFound {
+		t.Fatalf("expected nothing to be booked, got %v", err)
+	}
+	book.Recurrence.Until = "2030-07-01"
+	if rec := do(http.MethodPost, "/api/booking/book", book); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected a count and an until date to be rejected, got %d", rec.Code)
+	}
+
+	book.Recurrence.Count = 0
+	rec = do(http.MethodPost, "/api/booking/book", book)
+	var booking Booking
+	if err := json.Unmarshal(rec.Body.Bytes(), &booking); err != nil || rec.Code != http.StatusCreated || booking.SeriesID == "" || len(booking.Occurrences) != 3 {
+		t.Fatalf("expected a series of three, got %d: %s", rec.Code, rec.Body.String())
+	}
+	emails.wait(t, func() bool { return len(emails.confirmations) > 0 })
+	if confirmation := emails.confirmations[0]; confirmation.Series == nil || confirmation.Series.Count != 3 || len(confirmation.Series.Cancelled) != 0 {
+		t.Errorf("expected one confirmation for the series, got %+v", confirmation.Series)
+	}
+
+	token := backend.Event("slot1").ExtendedProperties.Private["cancellation_token"]
+	if rec := do(http.MethodPost, "/api/booking/reschedule", rescheduleRequest{Token: token, EventID: "slot2"}); rec.Code != http.StatusConflict {
+		t.Errorf("expected a session of the series not to be rescheduled, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/booking/cancel", cancelRequest{Token: token, EventID: "slot2"}); rec.Code != http.StatusOK {
+		t.Fatalf("expected the second session to be cancelled, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = do(http.MethodGet, "/api/booking/manage/ics?token="+token, nil)
+	if ics := rec.Body.String(); !strings.Contains(ics, "RRULE:FREQ=WEEKLY;INTERVAL=1;COUNT=3\r\n") || !strings.Contains(ics, "EXDATE:20300624T133000Z\r\n") || !strings.Contains(ics, "SEQUENCE:1\r\n") {
+		t.Errorf("expected the invitation to leave out the cancelled session, got:\n%s", ics)
+	}
+
+	rec = do(http.MethodPost, "/api/booking/cancel", cancelRequest{Token: token, Series: true})
+	var cancelled seriesCancelResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &cancelled); err != nil || rec.Code != http.StatusOK || len(cancelled.Cancelled) != 2 || len(cancelled.Kept) != 0 {
+		t.Fatalf("expected the remaining sessions to be cancelled, got %d: %s", rec.Code, rec.Body.String())
+	}
+	emails.wait(t, func() bool { return len(emails.seriesCancellations) > 0 })
+	if starts := emails.seriesCancellations[0]; len(starts) != 2 {
+		t.Errorf("expected one email for both sessions, got %v", starts)
+	}
+	if _, err := gcalSvc.GetBooking(t.Context(), "slot3"); err != gcal.ErrSlotNotFound {
+		t.Errorf("expected the last session to be cancelled, got %v", err)
+	}
+}
+
+// TestSeries_WaitlistReservation books a series whose second session is
+// reserved for a waitlisted visitor, without and with their priority link.
+func TestSeries_WaitlistReservation(t *testing.T) {
+	cfg := testConfig()
+	h, backend, emails := newTestHandler(t, cfg, Options{})
+	store, _ := waitlist.NewFileStore("")
+	h.waitlist = NewWaitlist(discardLogger, h.gcalSvc, emails, store, &cfg.Booking)
+	for id, start := range map[string]string{"slot1": "2030-06-17T13:30:00Z", "slot2": "2030-06-24T13:30:00Z", "slot3": "2030-07-01T13:30:00Z"} {
+		backend.AddEvent(slot(id, start, strings.Replace(start, "13:30", "14:00", 1)))
+	}
+	store.Save(t.Context(), waitlist.Entry{ID: "w1", Email: "bob@example.com", Offers: []waitlist.Offer{
+		{Token: "priority", EventIDs: []string{"slot2"}, Expires: time.Now().Add(time.Hour)},
+	}})
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	book := func(waitlistToken string) int {
+		t.Helper()
+		raw, _ := json.Marshal(createBookingRequest{EventID: "slot1", Name: "Bob", Email: "bob@example.com", WaitlistToken: waitlistToken, Recurrence: &recurrenceRequest{Frequency: "weekly", Count: 3}})
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/booking/book", bytes.NewReader(raw)))
+		return rec.Code
+	}
+
+	if code := book(""); code != http.StatusConflict {
+		t.Fatalf("expected the reserved second session to block the series, got %d", code)
+	}
+	if _, err := h.gcalSvc.GetBooking(t.Context(), "slot1"); err != gcal.ErrSlotNotFound {
+		t.Fatalf("expected nothing to be booked, got %v", err)
+	}
+	if code := book("priority"); code != http.StatusCreated {
+		t.Errorf("expected the priority link to book the series, got %d", code)
+	}
+}
@@ -0,0 +1,314 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/email"
+	"ivmanto.com/backend/internal/gcal"
+	"ivmanto.com/backend/internal/waitlist"
+)
+
+// waitlistJoinLimit is how many times an hour a client address may join the
+// waitlist.
+const waitlistJoinLimit = 5
+
+// joinAttempts bounds how often Join retries when the visitor's entry is
+// changed under it, e.g. by a matching pass on another instance.
+const joinAttempts = 3
+
+// errSlotReserved is returned when a slot is reserved for waitlisted visitors
+// and the booking does not carry one of their priority tokens.
+var errSlotReserved = errors.New("slot is reserved for the waitlist")
+
+// Waitlist matches visitors waiting for a slot in a date range against the
+// open slots. When new slots open up in an entry's range, whether freed by a
+// cancellation or added to the calendar, the visitor is emailed a priority
+// booking link. Until the link expires, the offered slots can only be booked
+// with the token of a link that offered them.
+type Waitlist struct {
+	logger   *slog.Logger
+	gcalSvc  gcal.Service
+	emailSvc email.Service
+	store    waitlist.Store
+	offerTTL time.Duration
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex // serialises matching passes and joins
+	changed chan struct{}
+	stopCh  chan struct{}
+}
+
+// NewWaitlist creates the waitlist for the booking calendar behind gcalSvc.
+func NewWaitlist(logger *slog.Logger, gcalSvc gcal.Service, emailSvc email.Service, store waitlist.Store, cfg *config.BookingConfig) *Waitlist {
+	return &Waitlist{
+		logger:   logger,
+		gcalSvc:  gcalSvc,
+		emailSvc: emailSvc,
+		store:    store,
+		offerTTL: cfg.WaitlistOfferTTL,
+		interval: cfg.WaitlistInterval,
+		now:      time.Now,
+		changed:  make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start matches the waitlist every interval, and whenever SlotsChanged is
+// called, in a background goroutine until Stop is called.
+func (w *Waitlist) Start() {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-w.changed:
+			case <-w.stopCh:
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			w.match(ctx)
+			cancel()
+		}
+	}()
+}
+
+// Stop shuts down the background goroutine started by Start.
+func (w *Waitlist) Stop() {
+	close(w.stopCh)
+}
+
+// SlotsChanged asks for a matching pass soon, e.g. after a cancellation freed
+// a slot. It never blocks; calls made while a pass is pending are coalesced.
+func (w *Waitlist) SlotsChanged() {
+	select {
+	case w.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Join puts a visitor on the waitlist for the days from-to (YYYY-MM-DD,
+// inclusive) in timezone. Only slots that open up after they joined are
+// offered: the slots open now can be booked by anyone, and offering them
+// would reserve them for the visitor. A visitor who is already waiting has
+// their entry replaced; slots offered to them before are not offered again.
+func (w *Waitlist) Join(ctx context.Context, emailAddr, from, to, timezone string) (waitlist.Entry, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for range joinAttempts - 1 {
+		entry, err := w.join(ctx, emailAddr, from, to, timezone)
+		if !errors.Is(err, waitlist.ErrEntryChanged) {
+			return entry, err
+		}
+	}
+	return w.join(ctx, emailAddr, from, to, timezone)
+}
+
+// join makes one attempt at Join. w.mu must be held.
+func (w *Waitlist) join(ctx context.Context, emailAddr, from, to, timezone string) (waitlist.Entry, error) {
+	entries, err := w.store.List(ctx)
+	if err != nil {
+		return waitlist.Entry{}, err
+	}
+	entry := waitlist.Entry{Email: emailAddr}
+	for _, existing := range entries {
+		if strings.EqualFold(existing.Email, emailAddr) {
+			entry = existing
+			break
+		}
+	}
+	if entry.ID == "" {
+		id, err := uuid.NewRandom()
+		if err != nil {
+			return waitlist.Entry{}, fmt.Errorf("could not generate waitlist entry ID: %w", err)
+		}
+		entry.ID = id.String()
+	}
+	entry.From, entry.To, entry.Timezone = from, to, timezone
+	entry.CreatedAt = w.now()
+	entry.Open = nil
+	if _, rangeFrom, rangeTo, ok := w.window(entry, entry.CreatedAt); ok {
+		slots, err := w.gcalSvc.GetAvailabilityRange(rangeFrom, rangeTo)
+		if err != nil {
+			return waitlist.Entry{}, fmt.Errorf("could not list open slots: %w", err)
+		}
+		for _, slot := range slots {
+			entry.Open = append(entry.Open, slot.Id)
+		}
+	}
+
+	if err := w.store.Save(ctx, entry); err != nil {
+		return waitlist.Entry{}, err
+	}
+	w.logger.Info("Visitor joined the waitlist", "entry_id", entry.ID, "from", from, "to", to, "timezone", timezone)
+	w.SlotsChanged()
+	return entry, nil
+}
+
+// window returns the part of entry's date range that still lies ahead.
+// ok is false once the range is over.
+func (w *Waitlist) window(entry waitlist.Entry, now time.Time) (loc *time.Location, from, to time.Time, ok bool) {
+	loc = resolveVisitorTimezone(entry.Timezone, w.gcalSvc.Location())
+	from, err1 := time.ParseInLocation("2006-01-02", entry.From, loc)
+	last, err2 := time.ParseInLocation("2006-01-02", entry.To, loc)
+	if err1 != nil || err2 != nil {
+		return loc, from, to, false
+	}
+	to = last.AddDate(0, 0, 1) // The range is inclusive of the "to" day.
+	if from.Before(now) {
+		from = now
+	}
+	return loc, from, to, from.Before(to)
+}
+
+// match offers the open slots in each entry's range that were neither open
+// when the visitor joined nor offered to them before, and drops entries whose
+// range is over.
+func (w *Waitlist) match(ctx context.Context) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entries, err := w.store.List(ctx)
+	if err != nil {
+		w.logger.Error("Failed to list waitlist entries", "error", err)
+		return
+	}
+	now := w.now()
+
+	// One availability query covers the ranges of all entries.
+	var active []waitlist.Entry
+	var from, to time.Time
+	for _, entry := range entries {
+		_, entryFrom, entryTo, ok := w.window(entry, now)
+		if !ok {
+			if err := w.store.Delete(ctx, entry.ID); err != nil {
+				w.logger.Error("Failed to remove expired waitlist entry", "entry_id", entry.ID, "error", err)
+			}
+			continue
+		}
+		if len(active) == 0 || entryFrom.Before(from) {
+			from = entryFrom
+		}
+		if len(active) == 0 || entryTo.After(to) {
+			to = entryTo
+		}
+		active = append(active, entry)
+	}
+	if len(active) == 0 {
+		return
+	}
+	slots, err := w.gcalSvc.GetAvailabilityRange(from, to)
+	if err != nil {
+		w.logger.Error("Failed to get availability for the waitlist", "error", err)
+		return
+	}
+
+	for _, entry := range active {
+		loc, entryFrom, entryTo, _ := w.window(entry, now)
+		var offered []*calendar.Event
+		for _, slot := range slots {
+			start, err := time.Parse(time.RFC3339, slot.Start.DateTime)
+			if err != nil || start.Before(entryFrom) || !start.Before(entryTo) || entry.Offered(slot.Id) {
+				continue
+			}
+			offered = append(offered, slot)
+		}
+		if len(offered) > 0 {
+			w.offer(ctx, entry, offered, loc, now)
+		}
+	}
+}
+
+// offer records a priority booking link for slots on entry and emails it. The
+// offer is saved first so that a failed email never leads to repeated offers.
+func (w *Waitlist) offer(ctx context.Context, entry waitlist.Entry, slots []*calendar.Event, loc *time.Location, now time.Time) {
+	token, err := uuid.NewRandom()
+	if err != nil {
+		w.logger.Error("Could not generate waitlist offer token", "entry_id", entry.ID, "error", err)
+		return
+	}
+	offer := waitlist.Offer{Token: token.String(), Expires: now.Add(w.offerTTL)}
+	details := email.WaitlistOfferDetails{ToEmail: entry.Email, Expires: offer.Expires.In(loc)}
+	for _, slot := range slots {
+		start, _ := time.Parse(time.RFC3339, slot.Start.DateTime)
+		offer.EventIDs = append(offer.EventIDs, slot.Id)
+		details.Slots = append(details.Slots, start.In(loc))
+	}
+	details.Timezone = details.Slots[0].Format("MST")
+	details.BookingURL = fmt.Sprintf("https://ivmanto.com/booking?date=%s&waitlist=%s",
+		details.Slots[0].Format("2006-01-02"), url.QueryEscape(offer.Token))
+
+	entry.Offers = append(entry.Offers, offer)
+	if err := w.store.Save(ctx, entry); errors.Is(err, waitlist.ErrEntryChanged) {
+		// Another instance offered the slots, or the visitor joined again or
+		// booked; the next pass sees the change.
+		w.logger.Info("Waitlist entry changed, offer skipped", "entry_id", entry.ID)
+		return
+	} else if err != nil {
+		w.logger.Error("Failed to record waitlist offer", "entry_id", entry.ID, "error", err)
+		return
+	}
+	if err := w.emailSvc.SendWaitlistOffer(details); err != nil {
+		w.logger.Error("Failed to send waitlist offer", "entry_id", entry.ID, "error", err)
+		return
+	}
+	w.logger.Info("Sent waitlist offer", "entry_id", entry.ID, "slots", len(slots))
+}
+
+// checkReservation returns errSlotReserved if one of eventIDs is held by an
+// unexpired offer and token does not belong to any of the offers holding it.
+func (w *Waitlist) checkReservation(ctx context.Context, token string, eventIDs ...string) error {
+	entries, err := w.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	now := w.now()
+	for _, eventID := range eventIDs {
+		reserved, own := false, false
+		for _, entry := range entries {
+			for _, offer := range entry.Offers {
+				if !now.Before(offer.Expires) || !offer.Includes(eventID) {
+					continue
+				}
+				if token != "" && offer.Token == token {
+					own = true
+				}
+				reserved = true
+			}
+		}
+		if reserved && !own {
+			return errSlotReserved
+		}
+	}
+	return nil
+}
+
+// redeem removes the entry that was sent token, once its visitor has booked.
+func (w *Waitlist) redeem(ctx context.Context, token string) error {
+	entries, err := w.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		for _, offer := range entry.Offers {
+			if offer.Token == token {
+				w.logger.Info("Waitlisted visitor booked a slot", "entry_id", entry.ID)
+				return w.store.Delete(ctx, entry.ID)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package booking
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/waitlist"
+)
+
+// TestWaitlist_OffersOpenedSlotsOnce joins the waitlist for a fully booked
+// range, opens a slot inside and one outside it, and checks that the visitor
+// is offered the inside slot once and that the slot is reserved for the
+// priority link until the offer expires.
+func TestWaitlist_OffersOpenedSlotsOnce(t *testing.T) {
+	athens, err := time.LoadLocation("Europe/Athens")
+	if err != nil {
+		t.Skipf("tzdata not available for Europe/Athens: %v", err)
+	}
+	cal := &rangeStubCalendar{loc: time.UTC}
+	emails := &testEmails{}
+	store, err := waitlist.NewFileStore("")
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	cfg := &config.BookingConfig{WaitlistOfferTTL: 2 * time.Hour, WaitlistInterval: time.Minute}
+	w := NewWaitlist(discardLogger, cal, emails, store, cfg)
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+
+	if _, err := w.Join(t.Context(), "ada@example.com", "2026-06-15", "2026-06-16", "Europe/Athens"); err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	w.match(t.Context())
+	if len(emails.offers) != 0 {
+		t.Fatalf("expected no offer while the range is fully booked, got %d", len(emails.offers))
+	}
+
+	// 22:30 UTC on the 16th is already the 17th in Athens, outside the range.
+	cal.events = []*calendar.Event{
+		slot("in", "2026-06-16T08:00:00Z", "2026-06-16T08:30:00Z"),
+		slot("out", "2026-06-16T22:30:00Z", "2026-06-16T23:00:00Z"),
+	}
+	w.match(t.Context())
+	w.match(t.Context())
+	if len(emails.offers) != 1 {
+		t.Fatalf("expected exactly one offer, got %d", len(emails.offers))
+	}
+	offer := emails.offers[0]
+	if len(offer.Slots) != 1 || !offer.Slots[0].Equal(time.Date(2026, 6, 16, 11, 0, 0, 0, athens)) || offer.Timezone != "EEST" {
+		t.Fatalf("expected the 11:00 EEST slot only, got %v (%s)", offer.Slots, offer.Timezone)
+	}
+	_, token, _ := strings.Cut(offer.BookingURL, "waitlist=")
+
+	if err := w.checkReservation(t.Context(), "", "in"); err != errSlotReserved {
+		t.Errorf("expected the offered slot to be reserved, got %v", err)
+	}
+	if err := w.checkReservation(t.Context(), token, "in"); err != nil {
+		t.Errorf("expected the priority token to book the slot, got %v", err)
+	}
+	if err := w.checkReservation(t.Context(), "", "out"); err != nil {
+		t.Errorf("expected a slot that was not offered to be bookable, got %v", err)
+	}
+	now = now.Add(3 * time.Hour)
+	if err := w.checkReservation(t.Context(), "", "in"); err != nil {
+		t.Errorf("expected the reservation to end when the offer expires, got %v", err)
+	}
+
+	if err := w.redeem(t.Context(), token); err != nil {
+		t.Fatalf("redeem failed: %v", err)
+	}
+	if entries, _ := store.List(t.Context()); len(entries) != 0 {
+		t.Errorf("expected the visitor to leave the waitlist after booking, got %d entries", len(entries))
+	}
+}
+
+// TestWaitlist_DoesNotOfferSlotsOpenAtJoin joins the waitlist for a range
+// that still has a free slot and checks that the slot is neither offered nor
+// reserved, while a slot that opens up later is.
+func TestWaitlist_DoesNotOfferSlotsOpenAtJoin(t *testing.T) {
+	cal := &rangeStubCalendar{loc: time.UTC, events: []*calendar.Event{
+		slot("free", "2026-06-15T08:00:00Z", "2026-06-15T08:30:00Z"),
+	}}
+	emails := &testEmails{}
+	store, err := waitlist.NewFileStore("")
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	cfg := &config.BookingConfig{WaitlistOfferTTL: 2 * time.Hour, WaitlistInterval: time.Minute}
+	w := NewWaitlist(discardLogger, cal, emails, store, cfg)
+	w.now = func() time.Time { return time.Date(2026, 6, 10, 9, 0, 0, 0, time.UTC) }
+
+	if _, err := w.Join(t.Context(), "ada@example.com", "2026-06-15", "2026-06-16", "UTC"); err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	w.match(t.Context())
+	if len(emails.offers) != 0 {
+		t.Fatalf("expected no offer for a slot that was open at join, got %+v", emails.offers)
+	}
+	if err := w.checkReservation(t.Context(), "", "free"); err != nil {
+		t.Errorf("expected the open slot to stay bookable, got %v", err)
+	}
+
+	cal.events = append(cal.events, slot("new", "2026-06-16T08:00:00Z", "2026-06-16T08:30:00Z"))
+	w.match(t.Context())
+	if len(emails.offers) != 1 || len(emails.offers[0].Slots) != 1 {
+		t.Fatalf("expected the slot that opened later to be offered, got %+v", emails.offers)
+	}
+}
+
+// TestWaitlist_JoinDuringMatch joins the waitlist from several visitors while
+// matching passes run, and checks that every visitor keeps their entry and is
+// never offered the same slot twice. Run with -race.
+func TestWaitlist_JoinDuringMatch(t *testing.T) {
+	cal := &rangeStubCalendar{loc: time.UTC}
+	emails := &testEmails{}
+	store, err := waitlist.NewFileStore("")
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	cfg := &config.BookingConfig{WaitlistOfferTTL: 2 * time.Hour, WaitlistInterval: time.Minute}
+	w := NewWaitlist(discardLogger, cal, emails, store, cfg)
+	w.now = func() time.Time { return time.Date(2026, 6, 10, 9, 0, 0, 0, time.UTC) }
+	visitors := []string{"ada@example.com", "bob@example.com", "cy@example.com", "dee@example.com"}
+
+	var wg sync.WaitGroup
+	for _, visitor := range visitors {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := w.Join(t.Context(), visitor, "2026-06-15", "2026-06-16", "UTC"); err != nil {
+				t.Errorf("Join failed for %s: %v", visitor, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			w.match(t.Context())
+		}()
+	}
+	wg.Wait()
+
+	cal.mu.Lock()
+	cal.events = []*calendar.Event{slot("new", "2026-06-16T08:00:00Z", "2026-06-16T08:30:00Z")}
+	cal.mu.Unlock()
+	wg.Add(len(visitors))
+	for _, visitor := range visitors {
+		go func() {
+			defer wg.Done()
+			if _, err := w.Join(t.Context(), visitor, "2026-06-15", "2026-06-16", "UTC"); err != nil {
+				t.Errorf("Join failed for %s: %v", visitor, err)
+			}
+		}()
+	}
+	for range visitors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.match(t.Context())
+		}()
+	}
+	wg.Wait()
+	w.match(t.Context())
+
+	entries, err := store.List(t.Context())
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != len(visitors) {
+		t.Fatalf("expected an entry per visitor, got %d", len(entries))
+	}
+	emails.mu.Lock()
+	defer emails.mu.Unlock()
+	offered := map[string]int{}
+	for _, offer := range emails.offers {
+		offered[offer.ToEmail]++
+	}
+	for _, visitor := range visitors {
+		if offered[visitor] > 1 {
+			t.Errorf("expected %s to be offered the slot at most once, got %d offers", visitor, offered[visitor])
+		}
+	}
+}
+
+// TestWaitlist_ReservationBlocksHoldAndReschedule checks that slots reserved
+// for waitlisted visitors can only be held or rescheduled to with the token
+// of the priority link that offered them.
+func TestWaitlist_ReservationBlocksHoldAndReschedule(t *testing.T) {
+	cfg := testConfig()
+	cfg.Booking.HoldTTL = 5 * time.Minute
+	h, backend, emails := newTestHandler(t, cfg, Options{})
+	store, _ := waitlist.NewFileStore("")
+	h.waitlist = NewWaitlist(discardLogger, h.gcalSvc, emails, store, &cfg.Booking)
+	for id, start := range map[string]string{"slot1": "2030-06-17T13:30:00Z", "slot2": "2030-06-18T13:30:00Z", "slot3": "2030-06-19T13:30:00Z"} {
+		backend.AddEvent(slot(id, start, strings.Replace(start, "13:30", "14:00", 1)))
+	}
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	post := func(path string, body any) int {
+		t.Helper()
+		raw, _ := json.Marshal(body)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(raw)))
+		return rec.Code
+	}
+	if code := post("/api/booking/book", createBookingRequest{EventID: "slot1", Name: "Ada", Email: "ada@example.com"}); code != http.StatusCreated {
+		t.Fatalf("expected slot1 to be booked, got %d", code)
+	}
+	token := backend.Event("slot1").ExtendedProperties.Private["cancellation_token"]
+	store.Save(t.Context(), waitlist.Entry{ID: "w1", Email: "bob@example.com", Offers: []waitlist.Offer{
+		{Token: "priority", EventIDs: []string{"slot2", "slot3"}, Expires: time.Now().Add(time.Hour)},
+	}})
+
+	if code := post("/api/booking/hold", holdRequest{EventID: "slot3"}); code != http.StatusConflict {
+		t.Errorf("expected the reserved slot not to be held, got %d", code)
+	}
+	if code := post("/api/booking/hold", holdRequest{EventID: "slot3", WaitlistToken: "priority"}); code != http.StatusCreated {
+		t.Errorf("expected the priority link to hold the slot, got %d", code)
+	}
+
+	if code := post("/api/booking/reschedule", rescheduleRequest{Token: token, EventID: "slot2"}); code != http.StatusConflict {
+		t.Errorf("expected the booking not to move to the reserved slot, got %d", code)
+	}
+	if _, err := h.gcalSvc.GetBooking(t.Context(), "slot1"); err != nil {
+		t.Fatalf("expected the booking to stay on slot1, got %v", err)
+	}
+	if code := post("/api/booking/reschedule", rescheduleRequest{Token: token, EventID: "slot2", WaitlistToken: "priority"}); code != http.StatusOK {
+		t.Errorf("expected the priority link to move the booking, got %d", code)
+	}
+}
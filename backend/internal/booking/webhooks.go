@@ -0,0 +1,56 @@
+package booking
+
+import (
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/audit"
+	"ivmanto.com/backend/internal/gcal"
+)
+
+// bookingWebhook is the data of the booking.* webhooks. Like the public
+// booking it leaves out the management token of the booking.
+type bookingWebhook struct {
+	EventID         string   `json:"eventId"`
+	Start           string   `json:"start"`
+	End             string   `json:"end"`
+	SessionType     string   `json:"sessionType"`
+	SessionName     string   `json:"sessionName"`
+	Name            string   `json:"name"`
+	Email           string   `json:"email"`
+	Guests          []string `json:"guests,omitempty"`
+	VisitorTimezone string   `json:"visitorTimezone,omitempty"`
+	ConferenceName  string   `json:"conferenceName,omitempty"`
+	Notes           string   `json:"notes,omitempty"` // booking.created
+	// SeriesID is set for the occurrences of a recurring series; each
+	// occurrence is sent as a booking of its own.
+	SeriesID string `json:"seriesId,omitempty"`
+	// PreviousEventID, PreviousStart and PreviousEnd are the slot a
+	// booking.rescheduled booking moved from.
+	PreviousEventID string `json:"previousEventId,omitempty"`
+	PreviousStart   string `json:"previousStart,omitempty"`
+	PreviousEnd     string `json:"previousEnd,omitempty"`
+	// CancelledBy is audit.ActorClient or audit.ActorAdmin for
+	// booking.cancelled; Late is set when the admin overrode the cutoff.
+	CancelledBy string `json:"cancelledBy,omitempty"`
+	Late        bool   `json:"late,omitempty"`
+}
+
+// bookingWebhookOf describes event, a booked event or the snapshot of one
+// returned when it was cancelled or moved.
+func (h *Handler) bookingWebhookOf(event *calendar.Event) bookingWebhook {
+	snapshot := audit.SnapshotOf(event)
+	sessionType := h.sessionTypeOf(event)
+	series, _ := gcal.SeriesOf(event)
+	return bookingWebhook{
+		EventID:         event.Id,
+		Start:           snapshot.Start,
+		End:             snapshot.End,
+		SessionType:     sessionType.ID,
+		SessionName:     sessionType.Name,
+		Name:            snapshot.ClientName,
+		Email:           snapshot.ClientEmail,
+		Guests:          snapshot.Guests,
+		VisitorTimezone: snapshot.VisitorTimezone,
+		ConferenceName:  conferenceName(event),
+		SeriesID:        series.ID,
+	}
+}
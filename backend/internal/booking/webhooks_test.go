@@ -0,0 +1,78 @@
+package booking
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/webhook"
+	"ivmanto.com/backend/internal/webhook/webhooktest"
+)
+
+// TestWebhooks_BookingLifecycle books, reschedules and cancels a booking over
+// HTTP and checks what a local receiver is sent.
+func TestWebhooks_BookingLifecycle(t *testing.T) {
+	receiver := webhooktest.NewReceiver(t)
+	webhooks, err := webhook.NewDispatcher(discardLogger, config.WebhookConfig{
+		Targets:     []config.WebhookTarget{receiver.Target(webhook.EventBookingCreated, webhook.EventBookingRescheduled, webhook.EventBookingCancelled)},
+		MaxAttempts: 1,
+		Backoff:     time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewDispatcher failed: %v", err)
+	}
+	defer webhooks.Stop()
+	h, backend, _ := newTestHandler(t, nil, Options{Webhooks: webhooks})
+	for id, start := range map[string]string{"slot1": "2030-06-17T13:30:00Z", "slot2": "2030-06-18T13:30:00Z"} {
+		backend.AddEvent(slot(id, start, strings.Replace(start, "13:30", "14:00", 1)))
+	}
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	post := func(path string, body any) {
+		t.Helper()
+		raw, _ := json.Marshal(body)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(raw)))
+		if rec.Code >= 300 {
+			t.Fatalf("POST %s failed with %d: %s", path, rec.Code, rec.Body.String())
+		}
+	}
+
+	post("/api/booking/book", createBookingRequest{EventID: "slot1", Name: "Ada", Email: "ada@example.com", Notes: "Data platform review"})
+	token := backend.Event("slot1").ExtendedProperties.Private["cancellation_token"]
+	created := receiver.Wait(t, 1)[0]
+	post("/api/booking/reschedule", rescheduleRequest{Token: token, EventID: "slot2"})
+	rescheduled := receiver.Wait(t, 2)[1]
+	post("/api/booking/cancel", cancelRequest{Token: token})
+	cancelled := receiver.Wait(t, 3)[2]
+
+	var data bookingWebhook
+	if err := json.Unmarshal(created.Data, &data); err != nil || created.Type != webhook.EventBookingCreated {
+		t.Fatalf("unexpected first delivery %+v", created)
+	}
+	if data.EventID != "slot1" || data.Email != "ada@example.com" || data.Notes != "Data platform review" || data.Start != "2030-06-17T13:30:00Z" {
+		t.Errorf("unexpected booking.created data %+v", data)
+	}
+	data = bookingWebhook{}
+	if err := json.Unmarshal(rescheduled.Data, &data); err != nil || rescheduled.Type != webhook.EventBookingRescheduled {
+		t.Fatalf("unexpected second delivery %+v", rescheduled)
+	}
+	if data.EventID != "slot2" || data.PreviousEventID != "slot1" || data.PreviousStart != "2030-06-17T13:30:00Z" || data.Name != "Ada" {
+		t.Errorf("unexpected booking.rescheduled data %+v", data)
+	}
+	data = bookingWebhook{}
+	if err := json.Unmarshal(cancelled.Data, &data); err != nil || cancelled.Type != webhook.EventBookingCancelled {
+		t.Fatalf("unexpected third delivery %+v", cancelled)
+	}
+	if data.EventID != "slot2" || data.Email != "ada@example.com" || data.CancelledBy != "client" {
+		t.Errorf("unexpected booking.cancelled data %+v", data)
+	}
+	if bytes.Contains(created.Data, []byte(token)) {
+		t.Error("expected the webhook not to contain the management token")
+	}
+}
@@ -0,0 +1,158 @@
+// Package bookingtoken signs the tokens in the links clients view, cancel and
+// reschedule their bookings with, and give feedback on them. A token names the booked event, the actions
+// it allows and when it expires, so it is checked without a calendar lookup;
+// an HMAC-SHA256 signature keeps it from being forged or altered.
+package bookingtoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"ivmanto.com/backend/internal/config"
+)
+
+// Scope is an action a token allows on its booking.
+type Scope string
+
+const (
+	ScopeView       Scope = "view"
+	ScopeCancel     Scope = "cancel"
+	ScopeReschedule Scope = "reschedule"
+	// ScopeFeedback is the scope of the link in the follow-up email asking
+	// for feedback on a past consultation.
+	ScopeFeedback Scope = "feedback"
+)
+
+// AllScopes are the scopes of the link clients manage their booking with.
+var AllScopes = []Scope{ScopeView, ScopeCancel, ScopeReschedule}
+
+var (
+	// ErrNoKey is returned by Sign when no signing key is configured.
+	ErrNoKey = errors.New("no booking token key configured")
+	// ErrInvalid is returned for a token that is malformed, was signed with
+	// an unknown key or has been altered.
+	ErrInvalid = errors.New("invalid booking token")
+	// ErrExpired is returned for a token past its expiry.
+	ErrExpired = errors.New("booking token has expired")
+	// ErrScope is returned for a token that does not allow the action.
+	ErrScope = errors.New("booking token does not allow this action")
+)
+
+// version prefixes every token, so the format can change later.
+const version = "v1"
+
+// Claims is what a token grants.
+type Claims struct {
+	EventID string
+	// Binding ties the token to one booking of the event; see Binding.
+	Binding string
+	Scopes  []Scope
+	Expires time.Time
+}
+
+// payload is the signed part of a token.
+type payload struct {
+	EventID string  `json:"e"`
+	Binding string  `json:"b"`
+	Scopes  []Scope `json:"s"`
+	Expires int64   `json:"x"`
+}
+
+// Signer signs and verifies tokens with the configured keys.
+type Signer struct {
+	keys []config.TokenKey
+}
+
+// NewSigner returns a signer for the keys of cfg. The first key signs.
+func NewSigner(cfg config.TokenConfig) *Signer {
+	return &Signer{keys: cfg.Keys}
+}
+
+// Enabled reports whether a signing key is configured.
+func (s *Signer) Enabled() bool {
+	return len(s.keys) > 0
+}
+
+// Sign returns a token for c, signed with the newest key. The token is
+// "v1.<key ID>.<payload>.<signature>", all of it URL-safe.
+func (s *Signer) Sign(c Claims) (string, error) {
+	if !s.Enabled() {
+		return "", ErrNoKey
+	}
+	raw, err := json.Marshal(payload{EventID: c.EventID, Binding: c.Binding, Scopes: c.Scopes, Expires: c.Expires.Unix()})
+	if err != nil {
+		return "", err
+	}
+	key := s.keys[0]
+	signed := version + "." + key.ID + "." + base64.RawURLEncoding.EncodeToString(raw)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature(key.Secret, signed)), nil
+}
+
+// Verify checks that token was signed with one of the keys, has not expired
+// at now and allows scope, and returns its claims.
+func (s *Signer) Verify(token string, scope Scope, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 || parts[0] != version {
+		return Claims{}, ErrInvalid
+	}
+	i := slices.IndexFunc(s.keys, func(k config.TokenKey) bool { return k.ID == parts[1] })
+	if i < 0 {
+		return Claims{}, ErrInvalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil || !hmac.Equal(sig, signature(s.keys[i].Secret, strings.Join(parts[:3], "."))) {
+		return Claims{}, ErrInvalid
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrInvalid
+	}
+	var p payload
+	if err := json.Unmarshal(raw, &p); err != nil || p.EventID == "" {
+		return Claims{}, ErrInvalid
+	}
+
+	claims := Claims{EventID: p.EventID, Binding: p.Binding, Scopes: p.Scopes, Expires: time.Unix(p.Expires, 0)}
+	if !now.Before(claims.Expires) {
+		return Claims{}, ErrExpired
+	}
+	if !slices.Contains(claims.Scopes, scope) {
+		return Claims{}, ErrScope
+	}
+	return claims, nil
+}
+
+// Binding derives the binding of a token from the secret stored on a booking
+// when it is made. A slot that is released and booked again gets a new secret,
+// so tokens for the earlier booking of the same event stop working. Only a
+// digest is put into the token, so it does not reveal the secret, which is
+// also the booking's legacy token.
+func Binding(secret string) string {
+	sum := sha256.Sum256([]byte("binding:" + secret))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// IsLegacy reports whether token is an unsigned legacy token, i.e. a bare
+// UUID stored on the booking before tokens were signed.
+func IsLegacy(token string) bool {
+	if len(token) != 36 {
+		return false
+	}
+	_, err := uuid.Parse(token)
+	return err == nil
+}
+
+// signature signs a token for booking management, so a token signed with the
+// same secret for another purpose is not accepted here.
+func signature(secret, signed string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("booking:" + signed))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,109 @@
+package bookingtoken
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ivmanto.com/backend/internal/config"
+)
+
+var (
+	oldKey = config.TokenKey{ID: "2026-04", Secret: strings.Repeat("o", 32)}
+	newKey = config.TokenKey{ID: "2026-10", Secret: strings.Repeat("n", 32)}
+)
+
+// TestSignVerify checks a round trip and that expiry and scope are enforced.
+func TestSignVerify(t *testing.T) {
+	signer := NewSigner(config.TokenConfig{Keys: []config.TokenKey{newKey}})
+	now := time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC)
+	token, err := signer.Sign(Claims{EventID: "evt1", Binding: Binding("secret"), Scopes: []Scope{ScopeView, ScopeCancel}, Expires: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if !strings.HasPrefix(token, "v1.2026-10.") {
+		t.Errorf("expected the token to name its key, got %q", token)
+	}
+
+	claims, err := signer.Verify(token, ScopeCancel, now)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if claims.EventID != "evt1" || claims.Binding != Binding("secret") || !claims.Expires.Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if _, err := signer.Verify(token, ScopeReschedule, now); err != ErrScope {
+		t.Errorf("expected ErrScope for a scope the token lacks, got %v", err)
+	}
+	if _, err := signer.Verify(token, ScopeView, now.Add(time.Hour)); err != ErrExpired {
+		t.Errorf("expected ErrExpired at the expiry, got %v", err)
+	}
+}
+
+// TestVerify_Invalid rejects altered tokens and tokens of unknown keys.
+func TestVerify_Invalid(t *testing.T) {
+	signer := NewSigner(config.TokenConfig{Keys: []config.TokenKey{newKey}})
+	now := time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC)
+	token, err := signer.Sign(Claims{EventID: "evt1", Scopes: AllScopes, Expires: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	parts := strings.Split(token, ".")
+	forged, _ := NewSigner(config.TokenConfig{Keys: []config.TokenKey{{ID: newKey.ID, Secret: strings.Repeat("x", 32)}}}).
+		Sign(Claims{EventID: "evt2", Scopes: AllScopes, Expires: now.Add(time.Hour)})
+
+	for name, bad := range map[string]string{
+		"empty":           "",
+		"uuid":            "0b8e5b8e-4f7d-4c43-9d5b-7a3f1f7e9b2a",
+		"other version":   "v2." + strings.Join(parts[1:], "."),
+		"unknown key":     strings.Join([]string{parts[0], "2025-01", parts[2], parts[3]}, "."),
+		"altered payload": strings.Join([]string{parts[0], parts[1], parts[2] + "A", parts[3]}, "."),
+		"other secret":    forged,
+	} {
+		if _, err := signer.Verify(bad, ScopeView, now); err != ErrInvalid {
+			t.Errorf("%s: expected ErrInvalid, got %v", name, err)
+		}
+	}
+}
+
+// TestKeyRotation signs with the newest key and still accepts tokens signed
+// with an older one until it is removed.
+func TestKeyRotation(t *testing.T) {
+	now := time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC)
+	claims := Claims{EventID: "evt1", Scopes: AllScopes, Expires: now.Add(time.Hour)}
+	before, err := NewSigner(config.TokenConfig{Keys: []config.TokenKey{oldKey}}).Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	rotated := NewSigner(config.TokenConfig{Keys: []config.TokenKey{newKey, oldKey}})
+	after, err := rotated.Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if !strings.HasPrefix(after, "v1.2026-10.") {
+		t.Errorf("expected the newest key to sign, got %q", after)
+	}
+	for _, token := range []string{before, after} {
+		if _, err := rotated.Verify(token, ScopeView, now); err != nil {
+			t.Errorf("expected %q to verify after the rotation, got %v", token, err)
+		}
+	}
+
+	retired := NewSigner(config.TokenConfig{Keys: []config.TokenKey{newKey}})
+	if _, err := retired.Verify(before, ScopeView, now); err != ErrInvalid {
+		t.Errorf("expected a token of a removed key to be rejected, got %v", err)
+	}
+	if _, err := NewSigner(config.TokenConfig{}).Sign(claims); err != ErrNoKey {
+		t.Errorf("expected ErrNoKey without keys, got %v", err)
+	}
+}
+
+func TestIsLegacy(t *testing.T) {
+	if !IsLegacy("0b8e5b8e-4f7d-4c43-9d5b-7a3f1f7e9b2a") {
+		t.Error("expected a UUID to be a legacy token")
+	}
+	if IsLegacy("v1.2026-10.e30.c2ln") || IsLegacy("") {
+		t.Error("expected only UUIDs to be legacy tokens")
+	}
+}
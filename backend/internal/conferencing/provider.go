@@ -0,0 +1,198 @@
+// Package conferencing attaches a video conference to booked calendar events.
+// Google Meet conferences are created by the Calendar API; the other providers
+// put their meeting URL into the event's location.
+package conferencing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/config"
+)
+
+// Private extended properties recording the conference of a booking. Google
+// Meet links are read from the event's conference data instead.
+const (
+	providerProperty = "conference_provider"
+	nameProperty     = "conference_name"
+	linkProperty     = "conference_link"
+)
+
+// Provider attaches one kind of video conference to a booked event.
+type Provider interface {
+	// ID is the name the provider is configured and requested by, e.g. "meet".
+	ID() string
+	// Name is the display name, e.g. "Google Meet".
+	Name() string
+	// Attach sets up the conference on event before the booking is written.
+	Attach(event *calendar.Event) error
+}
+
+// Providers returns the providers enabled by cfg, ordered by ID.
+func Providers(cfg config.ConferencingConfig) []Provider {
+	providers := []Provider{meet{}}
+	if cfg.JitsiURL != "" {
+		providers = append(providers, jitsi{baseURL: cfg.JitsiURL})
+	}
+	for id, url := range cfg.Rooms {
+		providers = append(providers, room{id: id, url: url})
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].ID() < providers[j].ID() })
+	return providers
+}
+
+// Lookup returns the provider id enabled by cfg.
+func Lookup(cfg config.ConferencingConfig, id string) (Provider, bool) {
+	for _, p := range Providers(cfg) {
+		if p.ID() == id {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Link returns the link to join the conference of event, or "" if it has none.
+func Link(event *calendar.Event) string {
+	if link := private(event, linkProperty); link != "" {
+		return link
+	}
+	// The HangoutLink is not always populated immediately, so the video entry
+	// point of the conference data is the fallback.
+	if event.HangoutLink != "" {
+		return event.HangoutLink
+	}
+	if event.ConferenceData != nil {
+		for _, entryPoint := range event.ConferenceData.EntryPoints {
+			if entryPoint.EntryPointType == "video" {
+				return entryPoint.Uri
+			}
+		}
+	}
+	return ""
+}
+
+// Name returns the display name of the conference of event, e.g. "Zoom".
+func Name(event *calendar.Event) string {
+	if name := private(event, nameProperty); name != "" {
+		return name
+	}
+	return meet{}.Name()
+}
+
+// Clear removes the conference a provider attached to event, e.g. when the
+// booking is released. A Google Meet conference stays on the event.
+func Clear(event *calendar.Event) {
+	if link := private(event, linkProperty); link != "" && event.Location == link {
+		event.Location = ""
+	}
+	if event.ExtendedProperties != nil {
+		for _, key := range []string{providerProperty, nameProperty, linkProperty} {
+			delete(event.ExtendedProperties.Private, key)
+		}
+	}
+}
+
+func private(event *calendar.Event, key string) string {
+	if event.ExtendedProperties == nil {
+		return ""
+	}
+	return event.ExtendedProperties.Private[key]
+}
+
+// record stores the provider and link of a conference on event and puts the
+// link into its location, where calendar clients show it.
+func record(event *calendar.Event, p Provider, link string) {
+	setPrivate(event, providerProperty, p.ID())
+	setPrivate(event, nameProperty, p.Name())
+	setPrivate(event, linkProperty, link)
+	event.Location = link
+}
+
+// meet asks the Calendar API to create a Google Meet conference.
+type meet struct{}
+
+func (meet) ID() string   { return config.ConferencingMeet }
+func (meet) Name() string { return "Google Meet" }
+
+func (m meet) Attach(event *calendar.Event) error {
+	requestID, err := uuid.NewRandom()
+	if err != nil {
+		return fmt.Errorf("could not generate Meet request ID: %w", err)
+	}
+	Clear(event)
+	setPrivate(event, providerProperty, m.ID())
+	event.ConferenceData = &calendar.ConferenceData{
+		CreateRequest: &calendar.CreateConferenceRequest{
+			RequestId:             requestID.String(),
+			ConferenceSolutionKey: &calendar.ConferenceSolutionKey{Type: "hangoutsMeet"},
+		},
+	}
+	return nil
+}
+
+// room is a fixed meeting URL shared by all bookings, e.g. a personal Zoom
+// or Teams room.
+type room struct {
+	id  string
+	url string
+}
+
+func (r room) ID() string { return r.id }
+
+// Name names well-known rooms by their product and others by their ID.
+func (r room) Name() string {
+	switch r.id {
+	case "zoom":
+		return "Zoom"
+	case "teams":
+		return "Microsoft Teams"
+	case "webex":
+		return "Webex"
+	}
+	return r.id
+}
+
+func (r room) Attach(event *calendar.Event) error {
+	Clear(event)
+	record(event, r, r.url)
+	return nil
+}
+
+// jitsi opens a new room with a unique, unguessable name on a self-hosted
+// Jitsi server for every booking. A rescheduled booking keeps its room.
+type jitsi struct {
+	baseURL string
+}
+
+func (jitsi) ID() string   { return config.ConferencingJitsi }
+func (jitsi) Name() string { return "Jitsi Meet" }
+
+func (j jitsi) Attach(event *calendar.Event) error {
+	if link := private(event, linkProperty); private(event, providerProperty) == j.ID() && strings.HasPrefix(link, j.baseURL+"/") {
+		record(event, j, link)
+		return nil
+	}
+	suffix := make([]byte, 12)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("could not generate Jitsi room name: %w", err)
+	}
+	Clear(event)
+	record(event, j, fmt.Sprintf("%s/ivmanto-%s", j.baseURL, hex.EncodeToString(suffix)))
+	return nil
+}
+
+// setPrivate sets a private extended property on event, creating the maps as needed.
+func setPrivate(event *calendar.Event, key, value string) {
+	if event.ExtendedProperties == nil {
+		event.ExtendedProperties = &calendar.EventExtendedProperties{}
+	}
+	if event.ExtendedProperties.Private == nil {
+		event.ExtendedProperties.Private = make(map[string]string)
+	}
+	event.ExtendedProperties.Private[key] = value
+}
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"cloud.google.com/go/compute/metadata"
 )
@@ -17,11 +21,72 @@ type Config struct {
 	Ideas     IdeasConfig
 	Analytics AnalyticsConfig
 	Blog      BlogConfig
+	Booking   BookingConfig
+	Admin     AdminConfig
+	Audit     AuditConfig
+	Webhooks  WebhookConfig
+}
+
+// AuditConfig selects where the booking audit log is written.
+type AuditConfig struct {
+	// Sink is AuditSinkLog (the default) or AuditSinkFile.
+	Sink string
+	// File is the JSON Lines file of AuditSinkFile.
+	File string
+}
+
+// Audit log sinks for AuditConfig.Sink.
+const (
+	AuditSinkLog  = "log"   // structured log entries, e.g. in Cloud Logging
+	AuditSinkFile = "jsonl" // a JSON Lines file, also queryable through the admin API
+)
+
+// WebhookConfig lists the receivers of outgoing webhooks and how failed
+// deliveries are retried.
+type WebhookConfig struct {
+	Targets []WebhookTarget
+	// MaxAttempts is how often a delivery is tried before it is dead-lettered.
+	MaxAttempts int
+	// Backoff is the wait before the first retry; it doubles for each one after.
+	Backoff time.Duration
+	// DeadLetterFile is the JSON Lines file that deliveries which failed for
+	// good are appended to. Empty logs them instead.
+	DeadLetterFile string
+}
+
+// WebhookTarget is one receiver of webhooks.
+type WebhookTarget struct {
+	URL string `json:"url"`
+	// Secret signs the deliveries to URL, so the receiver can check them.
+	Secret string `json:"secret"`
+	// Events are the event types sent to URL, e.g. "booking.created". Empty
+	// sends all of them.
+	Events []string `json:"events,omitempty"`
+}
+
+// AdminConfig holds configuration for the admin API.
+type AdminConfig struct {
+	// Token is the bearer token required by the /api/admin endpoints. Empty
+	// disables the admin API.
+	Token string
 }
 
 // ServiceConfig holds configuration for the HTTP service.
 type ServiceConfig struct {
 	Port string
+	// IdempotencyTTL is how long the response to a request sent with an
+	// Idempotency-Key header is kept for replay.
+	IdempotencyTTL time.Duration
+	// IdempotencyFile is the JSON file idempotency keys are kept in. Empty
+	// keeps them in memory only.
+	IdempotencyFile string
+	// IdempotencyBucket is the Cloud Storage bucket idempotency keys are kept
+	// in, shared by all instances. It takes precedence over IdempotencyFile.
+	IdempotencyBucket string
+	// TrustedProxies is how many proxies in front of the service append an
+	// address to X-Forwarded-For; the client address is the entry before
+	// theirs. See audit.ClientIP.
+	TrustedProxies int
 }
 
 // EmailConfig holds configuration for the SMTP email service.
@@ -39,6 +104,582 @@ type GCalConfig struct {
 	AvailableSlotSummary string
 	ServiceAccountEmail  string // Runtime SA email, used as the impersonation source for DWD.
 	ImpersonateUser      string // Workspace user to impersonate via Domain-Wide Delegation.
+	// ConflictCalendarIDs are other calendars of the consultant (personal,
+	// client) whose busy time must not be offered for booking.
+	ConflictCalendarIDs []string
+	// Backend selects the calendar behind the booking flow: GCalBackendGoogle
+	// (the default) or GCalBackendMemory, an offline fake for local development.
+	Backend string
+	// MemoryFile optionally seeds the memory backend and keeps its events
+	// across restarts. MemoryTimezone is the IANA timezone of its calendar.
+	MemoryFile     string
+	MemoryTimezone string
+}
+
+// Calendar backends for GCalConfig.Backend.
+const (
+	GCalBackendGoogle = "google"
+	GCalBackendMemory = "memory"
+)
+
+// BookingConfig holds configuration for the consultation booking flow.
+type BookingConfig struct {
+	// SessionTypes is the catalog of consultation types that can be booked.
+	// "Available" calendar events are matched to an entry by its ID.
+	SessionTypes []SessionType
+	// AvailabilityMode selects where bookable slots come from:
+	// AvailabilityModePlaceholder (hand-made "Available" events, the default)
+	// or AvailabilityModeRules (computed from Availability and FreeBusy).
+	AvailabilityMode string
+	Availability     AvailabilityRules
+	// ReminderOffsets are how long before a booked consultation the client is
+	// reminded by email, e.g. 24h and 1h, longest first. Empty disables reminders.
+	ReminderOffsets []time.Duration
+	// ReminderInterval is how often due reminders and follow-ups are looked for.
+	ReminderInterval time.Duration
+	// FollowUpDelay is how long after a consultation ends the client is
+	// thanked by email and asked for feedback. Zero disables follow-ups, as
+	// does the lack of token keys to sign the feedback links with.
+	FollowUpDelay time.Duration
+	// HoldTTL is how long POST /api/booking/hold reserves a slot for the
+	// visitor filling in the booking form.
+	HoldTTL time.Duration
+	// WaitlistBucket is the Cloud Storage bucket waitlist entries are kept
+	// in, shared by all instances. It takes precedence over WaitlistFile.
+	WaitlistBucket string
+	// WaitlistFile is the JSON file waitlist entries are kept in, for local
+	// development. Without a bucket or a file the waitlist is disabled.
+	WaitlistFile string
+	// WaitlistOfferTTL is how long a priority booking link sent to a
+	// waitlisted visitor reserves the opened slots for them.
+	WaitlistOfferTTL time.Duration
+	// WaitlistInterval is how often the waitlist is matched against open slots.
+	WaitlistInterval time.Duration
+	// Cancellation limits when clients may cancel or reschedule themselves.
+	Cancellation CancellationPolicy
+	// Conferencing selects the video conference attached to bookings.
+	Conferencing ConferencingConfig
+	// Tokens configures the links clients manage their bookings with.
+	Tokens TokenConfig
+	// MaxGuests is how many guests a client may invite to a booking. Zero
+	// disables guests.
+	MaxGuests int
+	// RetentionPeriod is how long after a consultation the personal data of
+	// the client and guests is erased from the booking and its feedback.
+	// Zero keeps it indefinitely.
+	RetentionPeriod time.Duration
+}
+
+// TokenConfig configures the signed tokens in the links clients view, cancel
+// and reschedule their bookings with.
+type TokenConfig struct {
+	// Keys sign and verify tokens. The first key signs new tokens; the others
+	// only verify, so tokens sent before a key rotation keep working. Without
+	// keys the links carry the unsigned legacy token stored on the booking.
+	Keys []TokenKey
+	// Grace is how long after a booking ends its links keep working.
+	Grace time.Duration
+	// LegacyUntil ends the migration from unsigned tokens: from then on they
+	// are rejected. Zero accepts them indefinitely.
+	LegacyUntil time.Time
+}
+
+// TokenKey is a named secret for signing booking tokens. The ID is part of
+// every token, so the key that signed it can be found after a rotation.
+type TokenKey struct {
+	ID     string
+	Secret string
+}
+
+// minTokenSecretLength keeps token keys out of brute-force reach.
+const minTokenSecretLength = 32
+
+// ConferencingConfig configures the video-conferencing providers a booking
+// can use. Google Meet is always available; the others are enabled by
+// configuring them.
+type ConferencingConfig struct {
+	// Default is used when neither the session type nor the booking request
+	// names a provider. It is ConferencingMeet unless configured otherwise.
+	Default string
+	// Rooms are fixed meeting URLs by provider ID, e.g. a personal Zoom or
+	// Teams room shared by all bookings.
+	Rooms map[string]string
+	// JitsiURL is the base URL of a self-hosted Jitsi server. Set, it enables
+	// ConferencingJitsi, which opens a new room for every booking.
+	JitsiURL string
+}
+
+// Built-in conferencing provider IDs.
+const (
+	ConferencingMeet  = "meet"
+	ConferencingJitsi = "jitsi"
+)
+
+// Has reports whether the provider id is available.
+func (c ConferencingConfig) Has(id string) bool {
+	switch id {
+	case ConferencingMeet:
+		return true
+	case ConferencingJitsi:
+		return c.JitsiURL != ""
+	}
+	_, ok := c.Rooms[id]
+	return ok
+}
+
+// CancellationPolicy limits self-service cancellation and rescheduling.
+// Bookings that have started can never be cancelled; inside the cutoff
+// window only the admin can cancel them.
+type CancellationPolicy struct {
+	// Cutoff is how long before the start self-service changes close,
+	// e.g. 24h. Zero allows them until the booking starts.
+	Cutoff time.Duration
+	// Contact is the email address clients are pointed to for late changes.
+	Contact string
+}
+
+// Deadline returns when self-service changes close for a booking starting at start.
+func (p CancellationPolicy) Deadline(start time.Time) time.Time {
+	return start.Add(-p.Cutoff)
+}
+
+// Availability modes for BookingConfig.AvailabilityMode.
+const (
+	AvailabilityModePlaceholder = "placeholder"
+	AvailabilityModeRules       = "rules"
+)
+
+// AvailabilityRules describes when slots may be offered in rules mode. All
+// wall-clock values are interpreted in the booking calendar's timezone.
+type AvailabilityRules struct {
+	WorkingHours  []WorkingHours
+	SlotLength    time.Duration
+	BufferBefore  time.Duration // free time required before a slot
+	BufferAfter   time.Duration // free time required after a slot
+	MinNotice     time.Duration // earliest bookable start, relative to now
+	MaxHorizon    time.Duration // latest bookable start, relative to now
+	BlackoutDates []string      // YYYY-MM-DD days with no slots at all
+}
+
+// WorkingHours is one block of bookable time on a weekday, e.g. Monday
+// 09:00-12:00. Start and End are offsets from midnight.
+type WorkingHours struct {
+	Weekday time.Weekday
+	Start   time.Duration
+	End     time.Duration
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseWorkingHours parses BOOKING_WORKING_HOURS, a semicolon-separated list
+// of "<days> <from>-<to>[,<from>-<to>...]" entries, where <days> is a weekday
+// or a range of weekdays. Example: "mon-fri 09:00-12:00,13:00-17:00; sat 10:00-12:00".
+func parseWorkingHours(raw string) ([]WorkingHours, error) {
+	var hours []WorkingHours
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		days, ranges, ok := strings.Cut(entry, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid BOOKING_WORKING_HOURS entry %q", entry)
+		}
+
+		first, last, isRange := strings.Cut(strings.ToLower(days), "-")
+		from, ok1 := weekdays[first]
+		to, ok2 := weekdays[last]
+		if !isRange {
+			to, ok2 = from, ok1
+		}
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("invalid weekday in BOOKING_WORKING_HOURS entry %q", entry)
+		}
+
+		for _, r := range strings.Split(ranges, ",") {
+			startStr, endStr, ok := strings.Cut(strings.TrimSpace(r), "-")
+			start, err1 := parseClock(startStr)
+			end, err2 := parseClock(endStr)
+			if !ok || err1 != nil || err2 != nil || end <= start {
+				return nil, fmt.Errorf("invalid time range %q in BOOKING_WORKING_HOURS", r)
+			}
+			for d := from; ; d = (d + 1) % 7 {
+				hours = append(hours, WorkingHours{Weekday: d, Start: start, End: end})
+				if d == to {
+					break
+				}
+			}
+		}
+	}
+	return hours, nil
+}
+
+// parseClock parses an "HH:MM" wall-clock time into an offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// durationEnv reads a Go duration (e.g. "30m", "24h") from the environment,
+// returning def when the variable is unset.
+func durationEnv(name string, def time.Duration) (time.Duration, error) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative duration like 30m or 24h", name, raw)
+	}
+	return d, nil
+}
+
+// intEnv reads a non-negative number from the environment, returning def
+// when the variable is unset.
+func intEnv(name string, def int) (int, error) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative number", name, raw)
+	}
+	return n, nil
+}
+
+// splitList splits a comma-separated environment value, dropping empty items.
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// loadGCalBackend reads the GCAL_BACKEND selection and, for the memory
+// backend, the timezone of its calendar.
+func loadGCalBackend() (backend, timezone string, err error) {
+	backend = strings.TrimSpace(os.Getenv("GCAL_BACKEND"))
+	if backend == "" {
+		backend = GCalBackendGoogle
+	}
+	if backend != GCalBackendGoogle && backend != GCalBackendMemory {
+		return "", "", fmt.Errorf("invalid GCAL_BACKEND %q: use %q or %q", backend, GCalBackendGoogle, GCalBackendMemory)
+	}
+	timezone = strings.TrimSpace(os.Getenv("GCAL_MEMORY_TIMEZONE"))
+	if timezone == "" {
+		timezone = "Europe/Berlin"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return "", "", fmt.Errorf("invalid GCAL_MEMORY_TIMEZONE %q: %w", timezone, err)
+	}
+	return backend, timezone, nil
+}
+
+// loadAvailability reads the availability mode and, in rules mode, the rules.
+// Rules mode computes slots of one length that offer the default session
+// type, so it cannot be combined with a catalog of several session types.
+func loadAvailability(sessionTypes []SessionType) (string, AvailabilityRules, error) {
+	mode := strings.TrimSpace(os.Getenv("BOOKING_AVAILABILITY_MODE"))
+	if mode == "" {
+		mode = AvailabilityModePlaceholder
+	}
+	if mode != AvailabilityModePlaceholder && mode != AvailabilityModeRules {
+		return "", AvailabilityRules{}, fmt.Errorf("invalid BOOKING_AVAILABILITY_MODE %q: use %q or %q", mode, AvailabilityModePlaceholder, AvailabilityModeRules)
+	}
+	if mode == AvailabilityModeRules && len(sessionTypes) > 1 {
+		return "", AvailabilityRules{}, fmt.Errorf("BOOKING_AVAILABILITY_MODE=%s supports a single session type, but BOOKING_SESSION_TYPES lists %d", AvailabilityModeRules, len(sessionTypes))
+	}
+	defaultSessionType, _ := BookingConfig{SessionTypes: sessionTypes}.SessionType("")
+	defaultSlotLength := time.Duration(defaultSessionType.DurationMinutes) * time.Minute
+
+	var rules AvailabilityRules
+	var err error
+	if rules.WorkingHours, err = parseWorkingHours(os.Getenv("BOOKING_WORKING_HOURS")); err != nil {
+		return "", rules, err
+	}
+	if mode == AvailabilityModeRules && len(rules.WorkingHours) == 0 {
+		return "", rules, fmt.Errorf("BOOKING_WORKING_HOURS is required when BOOKING_AVAILABILITY_MODE=%s", AvailabilityModeRules)
+	}
+	if rules.SlotLength, err = durationEnv("BOOKING_SLOT_LENGTH", defaultSlotLength); err != nil {
+		return "", rules, err
+	}
+	if rules.SlotLength == 0 {
+		return "", rules, fmt.Errorf("BOOKING_SLOT_LENGTH must be positive")
+	}
+	if rules.BufferBefore, err = durationEnv("BOOKING_BUFFER_BEFORE", 0); err != nil {
+		return "", rules, err
+	}
+	if rules.BufferAfter, err = durationEnv("BOOKING_BUFFER_AFTER", 0); err != nil {
+		return "", rules, err
+	}
+	if rules.MinNotice, err = durationEnv("BOOKING_MIN_NOTICE", 2*time.Hour); err != nil {
+		return "", rules, err
+	}
+	if rules.MaxHorizon, err = durationEnv("BOOKING_MAX_HORIZON", 60*24*time.Hour); err != nil {
+		return "", rules, err
+	}
+	for _, day := range splitList(os.Getenv("BOOKING_BLACKOUT_DATES")) {
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			return "", rules, fmt.Errorf("invalid date %q in BOOKING_BLACKOUT_DATES, use YYYY-MM-DD", day)
+		}
+		rules.BlackoutDates = append(rules.BlackoutDates, day)
+	}
+	return mode, rules, nil
+}
+
+// loadConferencing reads the conferencing providers and checks that the
+// default and every session type use one of them.
+func loadConferencing(sessionTypes []SessionType) (ConferencingConfig, error) {
+	cfg := ConferencingConfig{
+		Default:  strings.TrimSpace(os.Getenv("BOOKING_CONFERENCING_DEFAULT")),
+		Rooms:    make(map[string]string),
+		JitsiURL: strings.TrimRight(strings.TrimSpace(os.Getenv("BOOKING_JITSI_URL")), "/"),
+	}
+	if cfg.Default == "" {
+		cfg.Default = ConferencingMeet
+	}
+	if cfg.JitsiURL != "" && !strings.HasPrefix(cfg.JitsiURL, "https://") {
+		return ConferencingConfig{}, fmt.Errorf("invalid BOOKING_JITSI_URL %q: must be an https URL", cfg.JitsiURL)
+	}
+	for _, entry := range splitList(os.Getenv("BOOKING_CONFERENCING_ROOMS")) {
+		id, url, ok := strings.Cut(entry, "=")
+		id, url = strings.TrimSpace(id), strings.TrimSpace(url)
+		if !ok || id == "" || !strings.HasPrefix(url, "https://") {
+			return ConferencingConfig{}, fmt.Errorf("invalid room %q in BOOKING_CONFERENCING_ROOMS: use provider=https://url, e.g. zoom=https://zoom.us/j/123", entry)
+		}
+		if id == ConferencingMeet || id == ConferencingJitsi {
+			return ConferencingConfig{}, fmt.Errorf("invalid room %q in BOOKING_CONFERENCING_ROOMS: %q is a built-in provider", entry, id)
+		}
+		cfg.Rooms[id] = url
+	}
+
+	if !cfg.Has(cfg.Default) {
+		return ConferencingConfig{}, fmt.Errorf("BOOKING_CONFERENCING_DEFAULT %q is not a configured conferencing provider", cfg.Default)
+	}
+	for _, st := range sessionTypes {
+		if st.Conferencing != "" && !cfg.Has(st.Conferencing) {
+			return ConferencingConfig{}, fmt.Errorf("session type %q uses conferencing provider %q, which is not configured", st.ID, st.Conferencing)
+		}
+	}
+	return cfg, nil
+}
+
+// loadTokens reads the booking token keys, newest first, and the end of the
+// migration from unsigned tokens.
+func loadTokens() (TokenConfig, error) {
+	var cfg TokenConfig
+	seen := make(map[string]bool)
+	for i, entry := range splitList(os.Getenv("BOOKING_TOKEN_KEYS")) {
+		// Errors name keys by position, so that secrets are not logged.
+		id, secret, ok := strings.Cut(entry, "=")
+		id, secret = strings.TrimSpace(id), strings.TrimSpace(secret)
+		if !ok || id == "" || strings.Contains(id, ".") || seen[id] {
+			return TokenConfig{}, fmt.Errorf("invalid key #%d in BOOKING_TOKEN_KEYS: use unique id=secret pairs, e.g. 2026-10=<secret>, with no dots in the id", i+1)
+		}
+		if len(secret) < minTokenSecretLength {
+			return TokenConfig{}, fmt.Errorf("key %q in BOOKING_TOKEN_KEYS is too short: use at least %d characters", id, minTokenSecretLength)
+		}
+		seen[id] = true
+		cfg.Keys = append(cfg.Keys, TokenKey{ID: id, Secret: secret})
+	}
+
+	grace, err := durationEnv("BOOKING_TOKEN_GRACE", 7*24*time.Hour)
+	if err != nil {
+		return TokenConfig{}, err
+	}
+	cfg.Grace = grace
+
+	if raw := strings.TrimSpace(os.Getenv("BOOKING_LEGACY_TOKENS_UNTIL")); raw != "" {
+		until, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return TokenConfig{}, fmt.Errorf("invalid BOOKING_LEGACY_TOKENS_UNTIL %q: must be a date like 2026-12-31", raw)
+		}
+		if len(cfg.Keys) == 0 {
+			return TokenConfig{}, fmt.Errorf("BOOKING_LEGACY_TOKENS_UNTIL requires BOOKING_TOKEN_KEYS")
+		}
+		cfg.LegacyUntil = until
+	}
+	return cfg, nil
+}
+
+// loadAudit reads the audit log sink.
+func loadAudit() (AuditConfig, error) {
+	cfg := AuditConfig{
+		Sink: strings.TrimSpace(os.Getenv("AUDIT_SINK")),
+		File: strings.TrimSpace(os.Getenv("AUDIT_FILE")),
+	}
+	switch cfg.Sink {
+	case "":
+		cfg.Sink = AuditSinkLog
+	case AuditSinkLog, AuditSinkFile:
+	default:
+		return AuditConfig{}, fmt.Errorf("invalid AUDIT_SINK %q: must be %q or %q", cfg.Sink, AuditSinkLog, AuditSinkFile)
+	}
+	if cfg.Sink == AuditSinkFile && cfg.File == "" {
+		cfg.File = "audit.jsonl"
+	}
+	return cfg, nil
+}
+
+// loadWebhooks reads the webhook targets from the WEBHOOK_TARGETS JSON array
+// and the retry settings. Which event types exist is checked by the webhook
+// package.
+func loadWebhooks() (WebhookConfig, error) {
+	cfg := WebhookConfig{DeadLetterFile: strings.TrimSpace(os.Getenv("WEBHOOK_DEAD_LETTER_FILE"))}
+	if raw := strings.TrimSpace(os.Getenv("WEBHOOK_TARGETS")); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &cfg.Targets); err != nil {
+			return WebhookConfig{}, fmt.Errorf("invalid WEBHOOK_TARGETS: %w", err)
+		}
+	}
+	for i, target := range cfg.Targets {
+		if !strings.HasPrefix(target.URL, "https://") && !strings.HasPrefix(target.URL, "http://") {
+			return WebhookConfig{}, fmt.Errorf("invalid WEBHOOK_TARGETS: target %d needs an http(s) url", i+1)
+		}
+		// Name the target by position, as its URL may carry a token.
+		if len(target.Secret) < minTokenSecretLength {
+			return WebhookConfig{}, fmt.Errorf("invalid WEBHOOK_TARGETS: the secret of target %d must be at least %d characters", i+1, minTokenSecretLength)
+		}
+	}
+
+	maxAttempts, err := intEnv("WEBHOOK_MAX_ATTEMPTS", 6)
+	if err != nil {
+		return WebhookConfig{}, err
+	}
+	if maxAttempts == 0 {
+		return WebhookConfig{}, fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be positive")
+	}
+	cfg.MaxAttempts = maxAttempts
+	backoff, err := durationEnv("WEBHOOK_BACKOFF", 30*time.Second)
+	if err != nil {
+		return WebhookConfig{}, err
+	}
+	if backoff == 0 || backoff > time.Minute {
+		return WebhookConfig{}, fmt.Errorf("WEBHOOK_BACKOFF must be positive and at most 1m")
+	}
+	cfg.Backoff = backoff
+	return cfg, nil
+}
+
+// loadReminders reads the reminder offsets and the polling interval.
+func loadReminders() ([]time.Duration, time.Duration, error) {
+	var offsets []time.Duration
+	for _, raw := range splitList(os.Getenv("BOOKING_REMINDER_OFFSETS")) {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < time.Minute {
+			return nil, 0, fmt.Errorf("invalid offset %q in BOOKING_REMINDER_OFFSETS: must be a duration of at least 1m like 24h or 1h", raw)
+		}
+		offsets = append(offsets, d)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] > offsets[j] })
+
+	interval, err := durationEnv("BOOKING_REMINDER_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, 0, err
+	}
+	if interval == 0 {
+		return nil, 0, fmt.Errorf("BOOKING_REMINDER_INTERVAL must be positive")
+	}
+	return offsets, interval, nil
+}
+
+// loadWaitlist reads the priority link lifetime and the matching interval.
+func loadWaitlist() (time.Duration, time.Duration, error) {
+	offerTTL, err := durationEnv("BOOKING_WAITLIST_OFFER_TTL", 2*time.Hour)
+	if err != nil {
+		return 0, 0, err
+	}
+	interval, err := durationEnv("BOOKING_WAITLIST_INTERVAL", 10*time.Minute)
+	if err != nil {
+		return 0, 0, err
+	}
+	if offerTTL == 0 || interval == 0 {
+		return 0, 0, fmt.Errorf("BOOKING_WAITLIST_OFFER_TTL and BOOKING_WAITLIST_INTERVAL must be positive")
+	}
+	return offerTTL, interval, nil
+}
+
+// SessionType describes one kind of consultation, e.g. a 30-minute intro call.
+type SessionType struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	DurationMinutes int     `json:"durationMinutes"`
+	Price           float64 `json:"price"`
+	Currency        string  `json:"currency"`
+	// Intake is the questionnaire a client answers when booking this type.
+	Intake []IntakeField `json:"intake,omitempty"`
+	// Conferencing is the video-conferencing provider of this type, e.g.
+	// "meet", "jitsi" or a room from BOOKING_CONFERENCING_ROOMS. Empty uses
+	// the default provider.
+	Conferencing string `json:"conferencing,omitempty"`
+}
+
+// DefaultSessionType is offered when BOOKING_SESSION_TYPES is not set. It
+// matches what every slot was before session types existed.
+var DefaultSessionType = SessionType{
+	ID:              "consultation",
+	Name:            "Consultation",
+	DurationMinutes: 30,
+	Price:           250.0,
+	Currency:        "USD",
+}
+
+// Catalog returns the configured session types, or DefaultSessionType alone
+// when none are configured. The first entry is the default session type.
+func (c BookingConfig) Catalog() []SessionType {
+	if len(c.SessionTypes) == 0 {
+		return []SessionType{DefaultSessionType}
+	}
+	return c.SessionTypes
+}
+
+// SessionType looks up a session type by ID. An empty ID means the default
+// session type.
+func (c BookingConfig) SessionType(id string) (SessionType, bool) {
+	catalog := c.Catalog()
+	if id == "" {
+		return catalog[0], true
+	}
+	for _, st := range catalog {
+		if st.ID == id {
+			return st, true
+		}
+	}
+	return SessionType{}, false
+}
+
+// parseSessionTypes parses the BOOKING_SESSION_TYPES JSON array.
+func parseSessionTypes(raw string) ([]SessionType, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var types []SessionType
+	if err := json.Unmarshal([]byte(raw), &types); err != nil {
+		return nil, fmt.Errorf("invalid BOOKING_SESSION_TYPES: %w", err)
+	}
+	seen := make(map[string]bool)
+	for _, st := range types {
+		if st.ID == "" || st.Name == "" || st.DurationMinutes <= 0 || st.Currency == "" {
+			return nil, fmt.Errorf("invalid BOOKING_SESSION_TYPES: session type %q needs an id, name, positive durationMinutes and currency", st.ID)
+		}
+		if seen[st.ID] {
+			return nil, fmt.Errorf("invalid BOOKING_SESSION_TYPES: duplicate session type id %q", st.ID)
+		}
+		seen[st.ID] = true
+		if err := validateIntakeFields(st); err != nil {
+			return nil, fmt.Errorf("invalid BOOKING_SESSION_TYPES: %w", err)
+		}
+	}
+	return types, nil
 }
 
 // GCPConfig holds project-level Google Cloud configuration.
@@ -92,21 +733,31 @@ func Load() (*Config, error) {
 		missingVars = append(missingVars, "SMTP_PASS")
 	}
 
-	calendarID := os.Getenv("CALENDAR_ID")
-	if calendarID == "" {
-		missingVars = append(missingVars, "CALENDAR_ID")
-	}
 	availableSlotSummary := os.Getenv("GCAL_AVAILABLE_SLOT_SUMMARY")
 	if availableSlotSummary == "" {
 		missingVars = append(missingVars, "GCAL_AVAILABLE_SLOT_SUMMARY")
 	}
 	gcalSAEmail := os.Getenv("GCAL_SA_EMAIL")
-	if gcalSAEmail == "" {
-		missingVars = append(missingVars, "GCAL_SA_EMAIL")
-	}
 	gcalImpersonateUser := os.Getenv("GCAL_IMPERSONATE_USER")
-	if gcalImpersonateUser == "" {
-		missingVars = append(missingVars, "GCAL_IMPERSONATE_USER")
+	gcalBackend, gcalMemoryTimezone, err := loadGCalBackend()
+	if err != nil {
+		return nil, err
+	}
+	calendarID := os.Getenv("CALENDAR_ID")
+	if calendarID == "" {
+		if gcalBackend == GCalBackendMemory {
+			calendarID = "primary"
+		} else {
+			missingVars = append(missingVars, "CALENDAR_ID")
+		}
+	}
+	if gcalBackend == GCalBackendGoogle {
+		if gcalSAEmail == "" {
+			missingVars = append(missingVars, "GCAL_SA_EMAIL")
+		}
+		if gcalImpersonateUser == "" {
+			missingVars = append(missingVars, "GCAL_IMPERSONATE_USER")
+		}
 	}
 
 	projectID, err := metadata.ProjectID()
@@ -143,18 +794,120 @@ func Load() (*Config, error) {
 		missingVars = append(missingVars, "GA_MEASUREMENT_ID")
 	}
 
+	// Load Booking config
+	sessionTypes, err := parseSessionTypes(os.Getenv("BOOKING_SESSION_TYPES"))
+	if err != nil {
+		return nil, err
+	}
+	availabilityMode, availabilityRules, err := loadAvailability(sessionTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	conferencing, err := loadConferencing(sessionTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := loadTokens()
+	if err != nil {
+		return nil, err
+	}
+
+	reminderOffsets, reminderInterval, err := loadReminders()
+	if err != nil {
+		return nil, err
+	}
+
+	waitlistOfferTTL, waitlistInterval, err := loadWaitlist()
+	if err != nil {
+		return nil, err
+	}
+
+	cancellationCutoff, err := durationEnv("BOOKING_CANCELLATION_CUTOFF", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cancellationContact := os.Getenv("BOOKING_CANCELLATION_CONTACT")
+	if cancellationContact == "" {
+		cancellationContact = sendFrom
+	}
+
+	idempotencyTTL, err := durationEnv("IDEMPOTENCY_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if idempotencyTTL == 0 {
+		return nil, fmt.Errorf("IDEMPOTENCY_TTL must be positive")
+	}
+
+	// The external HTTPS load balancer in front of Cloud Run appends
+	// "<client-ip>, <lb-ip>" to X-Forwarded-For.
+	trustedProxies, err := intEnv("TRUSTED_PROXIES", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	followUpDelay, err := durationEnv("BOOKING_FOLLOWUP_DELAY", 2*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	// Guests are kept in one private extended property of the event, which
+	// holds at most 1024 characters.
+	maxGuests, err := intEnv("BOOKING_MAX_GUESTS", 5)
+	if err != nil {
+		return nil, err
+	}
+	if maxGuests > 10 {
+		return nil, fmt.Errorf("BOOKING_MAX_GUESTS must be at most 10")
+	}
+
+	retentionDays, err := intEnv("BOOKING_RETENTION_DAYS", 0)
+	if err != nil {
+		return nil, err
+	}
+	retention := time.Duration(retentionDays) * 24 * time.Hour
+	// The follow-up email and the feedback it asks for need the client's
+	// address, so it must not be erased before they are due.
+	if retention != 0 && retention <= followUpDelay {
+		return nil, fmt.Errorf("BOOKING_RETENTION_DAYS must be longer than BOOKING_FOLLOWUP_DELAY")
+	}
+
+	holdTTL, err := durationEnv("BOOKING_HOLD_TTL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if holdTTL == 0 {
+		return nil, fmt.Errorf("BOOKING_HOLD_TTL must be positive")
+	}
+
+	auditCfg, err := loadAudit()
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := loadWebhooks()
+	if err != nil {
+		return nil, err
+	}
+
 	if len(missingVars) > 0 {
 		return nil, fmt.Errorf("missing required environment variables: %s", strings.Join(missingVars, ", "))
 	}
 
 	return &Config{
-		Service: ServiceConfig{Port: port},
+		Service: ServiceConfig{Port: port, IdempotencyTTL: idempotencyTTL, IdempotencyFile: os.Getenv("IDEMPOTENCY_FILE"), IdempotencyBucket: os.Getenv("IDEMPOTENCY_BUCKET"), TrustedProxies: trustedProxies},
 		Email:   EmailConfig{SmtpHost: smtpHost, SmtpPort: smtpPort, SendFrom: sendFrom, SendFromAlias: sendFromAlias, SmtpPass: smtpPass},
 		GCal: GCalConfig{
 			CalendarID:           calendarID,
 			AvailableSlotSummary: availableSlotSummary,
 			ServiceAccountEmail:  gcalSAEmail,
 			ImpersonateUser:      gcalImpersonateUser,
+			ConflictCalendarIDs:  splitList(os.Getenv("GCAL_CONFLICT_CALENDAR_IDS")),
+			Backend:              gcalBackend,
+			MemoryFile:           os.Getenv("GCAL_MEMORY_FILE"),
+			MemoryTimezone:       gcalMemoryTimezone,
 		},
 		GCP:     GCPConfig{ProjectID: projectID, Location: location},
 		Ideas:   IdeasConfig{GenerateIdeasPromptTemplate: generateIdeasPromptTemplate},
@@ -163,5 +916,26 @@ func Load() (*Config, error) {
 			MeasurementID: gaMeasurementID,
 		},
 		Blog: BlogConfig{GCSBucket: gcsBlogBucket, PubSubPushToken: pubsubPushToken, FrontendRebuildWebhookURL: os.Getenv("FRONTEND_REBUILD_WEBHOOK_URL")},
+		Booking: BookingConfig{
+			SessionTypes:     sessionTypes,
+			AvailabilityMode: availabilityMode,
+			Availability:     availabilityRules,
+			ReminderOffsets:  reminderOffsets,
+			ReminderInterval: reminderInterval,
+			FollowUpDelay:    followUpDelay,
+			HoldTTL:          holdTTL,
+			WaitlistBucket:   os.Getenv("BOOKING_WAITLIST_BUCKET"),
+			WaitlistFile:     os.Getenv("BOOKING_WAITLIST_FILE"),
+			WaitlistOfferTTL: waitlistOfferTTL,
+			WaitlistInterval: waitlistInterval,
+			Cancellation:     CancellationPolicy{Cutoff: cancellationCutoff, Contact: cancellationContact},
+			Conferencing:     conferencing,
+			Tokens:           tokens,
+			MaxGuests:        maxGuests,
+			RetentionPeriod:  retention,
+		},
+		Admin:    AdminConfig{Token: os.Getenv("ADMIN_API_TOKEN")},
+		Audit:    auditCfg,
+		Webhooks: webhooks,
 	}, nil
 }
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseWorkingHours covers weekday ranges, several blocks per day and
+// a single weekday entry.
+func TestParseWorkingHours(t *testing.T) {
+	hours, err := parseWorkingHours("mon-fri 09:00-12:00,13:00-17:30; sat 10:00-12:00")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hours) != 11 {
+		t.Fatalf("expected 11 blocks (5 days x 2 + 1), got %d: %+v", len(hours), hours)
+	}
+	if hours[0] != (WorkingHours{Weekday: time.Monday, Start: 9 * time.Hour, End: 12 * time.Hour}) {
+		t.Errorf("unexpected first block %+v", hours[0])
+	}
+	last := hours[len(hours)-1]
+	if last != (WorkingHours{Weekday: time.Saturday, Start: 10 * time.Hour, End: 12 * time.Hour}) {
+		t.Errorf("unexpected last block %+v", last)
+	}
+}
+
+// TestParseWorkingHours_Invalid rejects malformed entries instead of
+// silently offering no (or wrong) slots.
+func TestParseWorkingHours_Invalid(t *testing.T) {
+	for _, raw := range []string{
+		"monday 09:00-12:00",
+		"mon 9-12",
+		"mon 12:00-09:00",
+		"mon-fri",
+	} {
+		if _, err := parseWorkingHours(raw); err == nil {
+			t.Errorf("expected an error for %q", raw)
+		}
+	}
+}
+
+// TestValidateIntake reports every unknown field and invalid answer by field
+// ID, and trims valid answers while dropping empty optional ones.
+// TestLoadAvailability_RulesWithSessionTypes checks that rules mode takes
+// its slot length from a single session type and rejects a catalog of
+// several, as its slots only offer the default type.
+func TestLoadAvailability_RulesWithSessionTypes(t *testing.T) {
+	t.Setenv("BOOKING_AVAILABILITY_MODE", AvailabilityModeRules)
+	t.Setenv("BOOKING_WORKING_HOURS", "mon-fri 09:00-17:00")
+	intro := SessionType{ID: "intro", Name: "Intro Call", DurationMinutes: 45}
+	review := SessionType{ID: "review", Name: "Architecture Review", DurationMinutes: 90}
+
+	_, rules, err := loadAvailability([]SessionType{intro})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rules.SlotLength != 45*time.Minute {
+		t.Errorf("expected the slot length of the session type, got %v", rules.SlotLength)
+	}
+	if _, _, err := loadAvailability([]SessionType{intro, review}); err == nil {
+		t.Error("expected an error for rules mode with several session types")
+	}
+	t.Setenv("BOOKING_AVAILABILITY_MODE", AvailabilityModePlaceholder)
+	if _, _, err := loadAvailability([]SessionType{intro, review}); err != nil {
+		t.Errorf("expected placeholder mode to take several session types, got %v", err)
+	}
+}
+
+func TestValidateIntake(t *testing.T) {
+	st := SessionType{ID: "review", Name: "Architecture Review", Intake: []IntakeField{
+		{ID: "company", Label: "Company", Required: true, MaxLength: 10},
+		{ID: "team_size", Label: "Team size", Type: IntakeNumber},
+		{ID: "cloud", Label: "Cloud provider", Type: IntakeSelect, Options: []string{"AWS", "GCP"}},
+		{ID: "goal", Label: "Goal"},
+	}}
+
+	clean, err := st.ValidateIntake(map[string]string{"company": " Acme ", "team_size": "12", "goal": ""})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(clean) != 2 || clean["company"] != "Acme" || clean["team_size"] != "12" {
+		t.Errorf("unexpected answers %v", clean)
+	}
+
+	_, err = st.ValidateIntake(map[string]string{"team_size": "a dozen", "cloud": "Azure", "budget": "1k"})
+	fieldErrs, ok := err.(IntakeErrors)
+	if !ok {
+		t.Fatalf("expected IntakeErrors, got %v", err)
+	}
+	for _, id := range []string{"company", "team_size", "cloud", "budget"} {
+		if fieldErrs[id] == "" {
+			t.Errorf("expected an error for %q, got %v", id, fieldErrs)
+		}
+	}
+	if _, err := st.ValidateIntake(map[string]string{"company": "Much too long Inc."}); err == nil {
+		t.Error("expected an error for an answer over maxLength")
+	}
+}
+
+// TestParseSessionTypes_InvalidIntake rejects questionnaires that could not
+// be stored or answered.
+func TestParseSessionTypes_InvalidIntake(t *testing.T) {
+	for _, intake := range []string{
+		`[{"id":"Company","label":"Company"}]`,
+		`[{"id":"company"}]`,
+		`[{"id":"company","label":"Company"},{"id":"company","label":"Firm"}]`,
+		`[{"id":"cloud","label":"Cloud","type":"select"}]`,
+		`[{"id":"size","label":"Size","type":"range"}]`,
+	} {
+		raw := `[{"id":"intro","name":"Intro","durationMinutes":30,"currency":"EUR","intake":` + intake + `}]`
+		if _, err := parseSessionTypes(raw); err == nil {
+			t.Errorf("expected an error for intake %s", intake)
+		}
+	}
+}
+
+// TestLoadConferencing reads the rooms and Jitsi server, and rejects a
+// default or session type provider that is not configured.
+func TestLoadConferencing(t *testing.T) {
+	t.Setenv("BOOKING_CONFERENCING_DEFAULT", "zoom")
+	t.Setenv("BOOKING_CONFERENCING_ROOMS", "zoom=https://zoom.us/j/123, teams=https://teams.microsoft.com/l/meetup-join/abc")
+	t.Setenv("BOOKING_JITSI_URL", "https://meet.example.com/")
+	cfg, err := loadConferencing([]SessionType{{ID: "intro", Conferencing: ConferencingJitsi}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Default != "zoom" || cfg.JitsiURL != "https://meet.example.com" || len(cfg.Rooms) != 2 {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	for _, id := range []string{ConferencingMeet, ConferencingJitsi, "zoom", "teams"} {
+		if !cfg.Has(id) {
+			t.Errorf("expected provider %q to be configured", id)
+		}
+	}
+
+	if _, err := loadConferencing([]SessionType{{ID: "intro", Conferencing: "webex"}}); err == nil {
+		t.Error("expected an error for a session type with an unconfigured provider")
+	}
+	for name, value := range map[string]string{
+		"BOOKING_CONFERENCING_DEFAULT": "webex",
+		"BOOKING_CONFERENCING_ROOMS":   "zoom=http://zoom.us/j/123",
+		"BOOKING_JITSI_URL":            "meet.example.com",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := loadConferencing(nil); err == nil {
+				t.Errorf("expected an error for %s=%s", name, value)
+			}
+		})
+	}
+}
+
+// TestLoadTokens reads the keys newest first and rejects weak or ambiguous
+// keys and a migration end without keys to replace the legacy tokens.
+func TestLoadTokens(t *testing.T) {
+	secret := strings.Repeat("s", minTokenSecretLength)
+	t.Setenv("BOOKING_TOKEN_KEYS", "2026-10="+secret+", 2026-04="+secret)
+	t.Setenv("BOOKING_LEGACY_TOKENS_UNTIL", "2026-12-31")
+	cfg, err := loadTokens()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Keys) != 2 || cfg.Keys[0].ID != "2026-10" || cfg.Grace != 7*24*time.Hour {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if !cfg.LegacyUntil.Equal(time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected migration end %v", cfg.LegacyUntil)
+	}
+
+	for _, keys := range []string{"2026-10", "2026-10=short", "2026.10=" + secret, "a=" + secret + ",a=" + secret, ""} {
+		t.Setenv("BOOKING_TOKEN_KEYS", keys)
+		if _, err := loadTokens(); err == nil {
+			t.Errorf("expected an error for BOOKING_TOKEN_KEYS=%q", keys)
+		}
+	}
+}
+
+func TestLoadAudit(t *testing.T) {
+	cfg, err := loadAudit()
+	if err != nil || cfg.Sink != AuditSinkLog {
+		t.Errorf("expected the log sink by default, got %+v (%v)", cfg, err)
+	}
+	t.Setenv("AUDIT_SINK", "jsonl")
+	if cfg, err := loadAudit(); err != nil || cfg.File != "audit.jsonl" {
+		t.Errorf("expected the default audit file, got %+v (%v)", cfg, err)
+	}
+	t.Setenv("AUDIT_SINK", "bigquery")
+	if _, err := loadAudit(); err == nil {
+		t.Error("expected an error for an unknown sink")
+	}
+}
+
+func TestLoadWebhooks(t *testing.T) {
+	secret := strings.Repeat("s", minTokenSecretLength)
+	t.Setenv("WEBHOOK_TARGETS", `[{"url":"https://crm.example.com/hooks","secret":"`+secret+`","events":["booking.created"]}]`)
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "3")
+	cfg, err := loadWebhooks()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Targets) != 1 || cfg.Targets[0].Events[0] != "booking.created" || cfg.MaxAttempts != 3 || cfg.Backoff != 30*time.Second {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	for _, targets := range []string{`{}`, `[{"url":"crm.example.com","secret":"` + secret + `"}]`, `[{"url":"https://crm.example.com","secret":"short"}]`} {
+		t.Setenv("WEBHOOK_TARGETS", targets)
+		if _, err := loadWebhooks(); err == nil {
+			t.Errorf("expected an error for WEBHOOK_TARGETS=%s", targets)
+		}
+	}
+	t.Setenv("WEBHOOK_TARGETS", "")
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "0")
+	if _, err := loadWebhooks(); err == nil {
+		t.Error("expected an error for WEBHOOK_MAX_ATTEMPTS=0")
+	}
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "3")
+	t.Setenv("WEBHOOK_BACKOFF", "5m")
+	if _, err := loadWebhooks(); err == nil {
+		t.Error("expected an error for a WEBHOOK_BACKOFF longer than the retry window allows")
+	}
+}
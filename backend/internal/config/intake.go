@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Intake field types for IntakeField.Type.
+const (
+	IntakeText   = "text"
+	IntakeNumber = "number"
+	IntakeSelect = "select"
+)
+
+// defaultIntakeMaxLength bounds text answers when a field sets no MaxLength.
+// Answers are stored as private extended properties, whose values the Calendar
+// API limits to 1024 characters.
+const (
+	defaultIntakeMaxLength = 500
+	maxIntakeMaxLength     = 1000
+)
+
+// intakeFieldID restricts field IDs to what can safely become part of a
+// private extended property key.
+var intakeFieldID = regexp.MustCompile(`^[a-z][a-z0-9_]{0,29}$`)
+
+// IntakeField is one question of a session type's intake questionnaire,
+// e.g. the client's company or team size.
+type IntakeField struct {
+	ID        string   `json:"id"`
+	Label     string   `json:"label"`
+	Type      string   `json:"type,omitempty"` // IntakeText (default), IntakeNumber or IntakeSelect
+	Required  bool     `json:"required,omitempty"`
+	Options   []string `json:"options,omitempty"`   // allowed answers of an IntakeSelect field
+	MaxLength int      `json:"maxLength,omitempty"` // of an IntakeText answer; 0 means 500
+}
+
+// IntakeErrors maps intake field IDs to what is wrong with their answers.
+type IntakeErrors map[string]string
+
+func (e IntakeErrors) Error() string {
+	ids := make([]string, 0, len(e))
+	for id := range e {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = id + ": " + e[id]
+	}
+	return "invalid intake answers: " + strings.Join(msgs, "; ")
+}
+
+// ValidateIntake checks answers against the session type's questionnaire. It
+// returns the trimmed answers, leaving out empty optional ones, or
+// IntakeErrors naming every unknown field and every invalid answer.
+func (st SessionType) ValidateIntake(answers map[string]string) (map[string]string, error) {
+	fieldErrs := IntakeErrors{}
+	known := make(map[string]bool, len(st.Intake))
+	clean := make(map[string]string)
+	for _, field := range st.Intake {
+		known[field.ID] = true
+		value := strings.TrimSpace(answers[field.ID])
+		if value == "" {
+			if field.Required {
+				fieldErrs[field.ID] = "is required"
+			}
+			continue
+		}
+		if msg := field.check(value); msg != "" {
+			fieldErrs[field.ID] = msg
+			continue
+		}
+		clean[field.ID] = value
+	}
+	for id := range answers {
+		if !known[id] {
+			fieldErrs[id] = fmt.Sprintf("is not a question for %s", st.Name)
+		}
+	}
+	if len(fieldErrs) > 0 {
+		return nil, fieldErrs
+	}
+	return clean, nil
+}
+
+// check returns what is wrong with a non-empty answer, or "" if it is valid.
+func (f IntakeField) check(value string) string {
+	switch f.Type {
+	case IntakeNumber:
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return "must be a whole number"
+		}
+	case IntakeSelect:
+		for _, option := range f.Options {
+			if value == option {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of: %s", strings.Join(f.Options, ", "))
+	default:
+		maxLength := f.MaxLength
+		if maxLength == 0 {
+			maxLength = defaultIntakeMaxLength
+		}
+		if len([]rune(value)) > maxLength {
+			return fmt.Sprintf("must be at most %d characters", maxLength)
+		}
+	}
+	return ""
+}
+
+// validateIntakeFields checks the questionnaire of a configured session type.
+func validateIntakeFields(st SessionType) error {
+	seen := make(map[string]bool)
+	for _, field := range st.Intake {
+		if !intakeFieldID.MatchString(field.ID) || field.Label == "" {
+			return fmt.Errorf("intake field %q of session type %q needs a lowercase id (a-z, 0-9, _) and a label", field.ID, st.ID)
+		}
+		if seen[field.ID] {
+			return fmt.Errorf("duplicate intake field %q in session type %q", field.ID, st.ID)
+		}
+		seen[field.ID] = true
+		switch field.Type {
+		case "", IntakeText, IntakeNumber:
+		case IntakeSelect:
+			if len(field.Options) == 0 {
+				return fmt.Errorf("select intake field %q of session type %q needs options", field.ID, st.ID)
+			}
+		default:
+			return fmt.Errorf("intake field %q of session type %q has unknown type %q", field.ID, st.ID, field.Type)
+		}
+		if field.MaxLength < 0 || field.MaxLength > maxIntakeMaxLength {
+			return fmt.Errorf("intake field %q of session type %q: maxLength must be at most %d", field.ID, st.ID, maxIntakeMaxLength)
+		}
+	}
+	return nil
+}
@@ -6,17 +6,26 @@ import (
 	"net/http"
 
 	"ivmanto.com/backend/internal/email"
+	"ivmanto.com/backend/internal/webhook"
 )
 
 // Handler holds dependencies for the contact handlers.
 type Handler struct {
-	logger  *slog.Logger
-	emailer email.Service
+	logger   *slog.Logger
+	emailer  email.Service
+	webhooks *webhook.Dispatcher
+}
+
+// contactWebhook is the data of the contact.received webhook.
+type contactWebhook struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Message string `json:"message"`
 }
 
 // NewHandler creates a new contact handler.
-func NewHandler(logger *slog.Logger, emailer email.Service) *Handler {
-	return &Handler{logger: logger, emailer: emailer}
+func NewHandler(logger *slog.Logger, emailer email.Service, webhooks *webhook.Dispatcher) *Handler {
+	return &Handler{logger: logger, emailer: emailer, webhooks: webhooks}
 }
 
 // RegisterRoutes registers the contact routes with a mux.
@@ -47,6 +56,7 @@ func (h *Handler) handleContactSubmit(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to send message", http.StatusInternalServerError)
 		return
 	}
+	h.webhooks.Send(webhook.EventContactReceived, contactWebhook{Name: msg.Name, Email: msg.Email, Message: msg.Message})
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Message sent successfully"))
@@ -6,11 +6,24 @@ import "time"
 type Service interface {
 	SendContactMessage(msg ContactMessage) error
 	SendBookingConfirmation(details BookingConfirmationDetails) error
-	SendBookingNotificationToAdmin(name, clientEmail string, startTime time.Time, notes string) error
+	SendBookingNotificationToAdmin(details BookingNotificationDetails) error
 	// SendBookingCancellationToClient renders the cancellation email using the
 	// visitor's timezone (visitorLoc + visitorTZLabel) when available, so the
 	// slot time is in the visitor's local zone rather than the calendar owner's.
 	SendBookingCancellationToClient(toName, toEmail string, startTime time.Time, visitorLoc *time.Location, visitorTZLabel string) error
+	// SendBookingSeriesCancellationToClient confirms the cancellation of
+	// several occurrences of a recurring series in one email.
+	SendBookingSeriesCancellationToClient(toName, toEmail string, startTimes []time.Time, visitorLoc *time.Location) error
 	SendBookingCancellationToAdmin(clientName, clientEmail string, startTime time.Time) error
+	SendBookingRescheduled(details BookingRescheduleDetails) error
+	SendBookingRescheduleToAdmin(clientName, clientEmail string, previousStart, newStart time.Time) error
+	// SendBookingReminder reminds the client of an upcoming consultation. The
+	// details are rendered like the confirmation, without the .ics attachment.
+	SendBookingReminder(details BookingConfirmationDetails) error
+	// SendBookingFollowUp thanks the client after a consultation and links to
+	// the feedback form.
+	SendBookingFollowUp(details BookingFollowUpDetails) error
+	SendFeedbackToAdmin(details FeedbackNotificationDetails) error
+	SendWaitlistOffer(details WaitlistOfferDetails) error
 	SendGeneratedIdeas(toEmail, topic string, ideasBody string) error
 }
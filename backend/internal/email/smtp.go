@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"encoding/base64"
 	"fmt"
+	"html"
 	"log/slog"
 	"mime/multipart"
 	"net/smtp"
@@ -188,28 +189,33 @@ func (s *SmtpService) send(to, cc []string, subject, htmlBody string, attachment
 // body. Extracted from SendBookingConfirmation so tests can assert the
 // rendered HTML without going through the SMTP transport.
 func buildBookingConfirmationHTML(details BookingConfirmationDetails) string {
-	var meetLinkHTML string
-	if details.MeetLink != "" {
-		meetLinkHTML = fmt.Sprintf(`<li><strong>Google Meet Link:</strong> <a href="%s">%s</a></li>`, details.MeetLink, details.MeetLink)
+	meetLinkHTML := meetingLinkHTML(details)
+	invitation := "A calendar invitation (.ics file) is attached to this email. Please open it to add the event to your calendar."
+	if len(details.Guests) > 0 {
+		invitation += " Forward it to your guests so they can add it to theirs; only you can change or cancel the booking."
 	}
 
 	body := fmt.Sprintf(`
 		<p>Hi %s,</p>
-		<p>Your 30-minute consultation is confirmed. Here are the details:</p>
+		<p>Your %s is confirmed. Here are the details:</p>
 		<ul>
 		<li><strong>Date:</strong> %s</li>
 		<li><strong>Time:</strong> %s - %s (%s)</li>
-		%s
+		%s%s%s
 		</ul>
-		<p>A calendar invitation (.ics file) is attached to this email. Please open it to add the event to your calendar.</p>
+		<p>%s</p>
 		<p>We look forward to speaking with you!</p>
 		<p>Thanks,<br>The IVMANTO Team</p>`,
 		details.ToName,
+		sessionLabel(details),
 		details.StartTime.Format("Monday, January 2, 2006"),
 		details.StartTime.Format("3:04 PM"),
 		details.EndTime.Format("3:04 PM"),
 		details.Timezone,
-		meetLinkHTML)
+		seriesHTML(details),
+		meetLinkHTML,
+		guestsHTML(details),
+		invitation)
 
 	if details.CancellationURL != "" {
 		body += fmt.Sprintf(`<p style="font-size: small; color: #666;">Need to make a change? <a href="%s">Cancel this booking</a>.</p>`, details.CancellationURL)
@@ -217,13 +223,88 @@ func buildBookingConfirmationHTML(details BookingConfirmationDetails) string {
 	return body
 }
 
+// seriesHTML renders the list item describing the recurring series of the
+// booking, or nothing for a single booking.
+func seriesHTML(details BookingConfirmationDetails) string {
+	series := details.Series
+	if series == nil {
+		return ""
+	}
+	every := "week"
+	if series.IntervalWeeks > 1 {
+		every = fmt.Sprintf("%d weeks", series.IntervalWeeks)
+	}
+	last := series.Start.AddDate(0, 0, 7*series.IntervalWeeks*(series.Count-1)).In(details.StartTime.Location())
+	repeats := fmt.Sprintf("Every %s, %d sessions until %s", every, series.Count, last.Format("Monday, January 2, 2006"))
+	if n := len(series.Cancelled); n > 0 {
+		repeats += fmt.Sprintf(" (%d cancelled)", n)
+	}
+	return fmt.Sprintf(`<li><strong>Repeats:</strong> %s</li>`, repeats)
+}
+
+// guestsHTML renders the list item naming the client's guests, or nothing if
+// there are none.
+func guestsHTML(details BookingConfirmationDetails) string {
+	if len(details.Guests) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`<li><strong>Guests:</strong> %s</li>`, html.EscapeString(strings.Join(details.Guests, ", ")))
+}
+
+// meetingLinkHTML renders the list item with the link to join the video
+// conference, named after its provider, or nothing if there is no link.
+func meetingLinkHTML(details BookingConfirmationDetails) string {
+	if details.MeetLink == "" {
+		return ""
+	}
+	name := details.ConferenceName
+	if name == "" {
+		name = "Google Meet"
+	}
+	return fmt.Sprintf(`<li><strong>%s Link:</strong> <a href="%s">%s</a></li>`, html.EscapeString(name), details.MeetLink, details.MeetLink)
+}
+
+// sessionLabel describes the booked session for the email copy, e.g.
+// "30-minute consultation" or "90-minute architecture review".
+func sessionLabel(details BookingConfirmationDetails) string {
+	name := "consultation"
+	if details.SessionName != "" {
+		name = strings.ToLower(details.SessionName)
+	}
+	minutes := int(details.EndTime.Sub(details.StartTime).Minutes())
+	if minutes <= 0 {
+		return name
+	}
+	return fmt.Sprintf("%d-minute %s", minutes, name)
+}
+
 // SendBookingConfirmation sends a confirmation email to the user.
 func (s *SmtpService) SendBookingConfirmation(details BookingConfirmationDetails) error {
 	subject := "Your consultation is confirmed!"
 	htmlBody := buildBookingConfirmationHTML(details)
+	return s.send([]string{details.ToEmail}, nil, subject, htmlBody, bookingInvite(details))
+}
 
+// bookingInvite builds the .ics attachment for a booked slot.
+func bookingInvite(details BookingConfirmationDetails) *ical.Attachment {
 	// Generate the .ics file content
-	icsContent := ical.Generate(ical.EventDetails{
+	icsContent := ical.Generate(InviteDetails(details))
+
+	// Create the attachment
+	return &ical.Attachment{
+		Headers: textproto.MIMEHeader{
+			"Content-Type":        {`text/calendar; charset="utf-8"; method=REQUEST`},
+			"Content-Disposition": {`attachment; filename="invite.ics"`},
+		},
+		Body: []byte(icsContent),
+	}
+}
+
+// InviteDetails describes the calendar invitation for a booking. The
+// invitation to a recurring series starts at its first occurrence and leaves
+// out the cancelled ones.
+func InviteDetails(details BookingConfirmationDetails) ical.EventDetails {
+	event := ical.EventDetails{
 		UID:         details.IcsUID,
 		StartTime:   details.StartTime,
 		EndTime:     details.EndTime,
@@ -233,22 +314,192 @@ func (s *SmtpService) SendBookingConfirmation(details BookingConfirmationDetails
 		Name:        details.ToName,
 		Email:       details.ToEmail,
 		Timezone:    details.IcsTimezone,
-	})
+		Sequence:    details.IcsSequence,
+		Guests:      details.Guests,
+	}
+	if series := details.Series; series != nil {
+		event.StartTime = series.Start
+		event.EndTime = series.End
+		event.Recurrence = &ical.Recurrence{
+			IntervalWeeks: series.IntervalWeeks,
+			Count:         series.Count,
+			Location:      series.Location,
+			ExDates:       series.Cancelled,
+		}
+	}
+	return event
+}
 
-	// Create the attachment
-	attachment := &ical.Attachment{
-		Headers: textproto.MIMEHeader{
-			"Content-Type":        {`text/calendar; charset="utf-8"; method=REQUEST`},
-			"Content-Disposition": {`attachment; filename="invite.ics"`},
-		},
-		Body: []byte(icsContent),
+// buildBookingReminderHTML renders the reminder sent ahead of a booked
+// consultation, in the visitor's timezone.
+func buildBookingReminderHTML(details BookingConfirmationDetails) string {
+	meetLinkHTML := meetingLinkHTML(details)
+
+	body := fmt.Sprintf(`
+		<p>Hi %s,</p>
+		<p>This is a reminder of your upcoming %s:</p>
+		<ul>
+		<li><strong>Date:</strong> %s</li>
+		<li><strong>Time:</strong> %s - %s (%s)</li>
+		%s
+		</ul>
+		<p>We look forward to speaking with you!</p>
+		<p>Thanks,<br>The IVMANTO Team</p>`,
+		details.ToName,
+		sessionLabel(details),
+		details.StartTime.Format("Monday, January 2, 2006"),
+		details.StartTime.Format("3:04 PM"),
+		details.EndTime.Format("3:04 PM"),
+		details.Timezone,
+		meetLinkHTML)
+
+	if details.CancellationURL != "" {
+		body += fmt.Sprintf(`<p style="font-size: small; color: #666;">Can't make it? <a href="%s">Cancel or reschedule this booking</a>.</p>`, details.CancellationURL)
 	}
+	return body
+}
 
-	return s.send([]string{details.ToEmail}, nil, subject, htmlBody, attachment)
+// SendBookingReminder reminds the client of their upcoming consultation.
+func (s *SmtpService) SendBookingReminder(details BookingConfirmationDetails) error {
+	subject := fmt.Sprintf("Reminder: your consultation on %s", details.StartTime.Format("Monday, January 2 at 3:04 PM"))
+	if details.Timezone != "" {
+		subject += " " + details.Timezone
+	}
+	return s.send([]string{details.ToEmail}, nil, subject, buildBookingReminderHTML(details), nil)
+}
+
+// buildBookingFollowUpHTML renders the thank-you email sent after a
+// consultation, with the link to the feedback form.
+func buildBookingFollowUpHTML(details BookingFollowUpDetails) string {
+	return fmt.Sprintf(`
+		<p>Hi %s,</p>
+		<p>Thank you for taking the time for your %s on %s.</p>
+		<p>We would love to hear how it went. It only takes a minute:</p>
+		<p><a href="%s"><strong>Share your feedback</strong></a></p>
+		<p>Thanks,<br>The IVMANTO Team</p>`,
+		details.ToName,
+		sessionLabel(BookingConfirmationDetails{SessionName: details.SessionName}),
+		details.StartTime.Format("Monday, January 2"),
+		details.FeedbackURL)
+}
+
+// SendBookingFollowUp thanks the client for their consultation and asks for feedback.
+func (s *SmtpService) SendBookingFollowUp(details BookingFollowUpDetails) error {
+	subject := "Thank you for your consultation"
+	return s.send([]string{details.ToEmail}, nil, subject, buildBookingFollowUpHTML(details), nil)
+}
+
+// SendFeedbackToAdmin forwards a client's feedback on a consultation to the admin.
+func (s *SmtpService) SendFeedbackToAdmin(details FeedbackNotificationDetails) error {
+	parts := strings.Split(s.cfg.SendFrom, "@")
+	var adminEmail string
+	if len(parts) == 2 {
+		// Use a '+feedback' alias to help with filtering in the admin's inbox.
+		adminEmail = fmt.Sprintf("%s+feedback@%s", parts[0], parts[1])
+	} else {
+		adminEmail = s.cfg.SendFrom // Fallback for non-standard emails
+	}
+
+	subject := fmt.Sprintf("Consultation Feedback: %d/5", details.Rating)
+	body := fmt.Sprintf("<strong>%s (%s)</strong> rated the %s on <strong>%s</strong> %d out of 5.<br>Event ID: %s",
+		html.EscapeString(details.ClientName), html.EscapeString(details.ClientEmail), html.EscapeString(details.SessionName),
+		details.StartTime.Format(time.RFC1123), details.Rating, details.EventID)
+	if details.Comment != "" {
+		body += "<br><br>Comment:<br>" + html.EscapeString(details.Comment)
+	}
+	return s.send([]string{adminEmail}, nil, subject, body, nil)
+}
+
+// buildWaitlistOfferHTML renders the email telling a waitlisted visitor that
+// slots opened up, with their priority booking link.
+func buildWaitlistOfferHTML(details WaitlistOfferDetails) string {
+	var slots strings.Builder
+	for _, start := range details.Slots {
+		fmt.Fprintf(&slots, "<li>%s (%s)</li>", start.Format("Monday, January 2, 2006 at 3:04 PM"), details.Timezone)
+	}
+
+	return fmt.Sprintf(`
+		<p>Hi,</p>
+		<p>Good news: a consultation slot opened up in the dates you were waiting for.</p>
+		<ul>%s</ul>
+		<p><a href="%s"><strong>Book now with your priority link</strong></a></p>
+		<p style="font-size: small; color: #666;">These slots are reserved for waitlisted visitors until %s (%s). After that, anyone can book them.</p>
+		<p>Thanks,<br>The IVMANTO Team</p>`,
+		slots.String(),
+		details.BookingURL,
+		details.Expires.Format("Monday, January 2 at 3:04 PM"),
+		details.Timezone)
+}
+
+// SendWaitlistOffer tells a waitlisted visitor that slots opened up.
+func (s *SmtpService) SendWaitlistOffer(details WaitlistOfferDetails) error {
+	subject := "A consultation slot just opened up"
+	return s.send([]string{details.ToEmail}, nil, subject, buildWaitlistOfferHTML(details), nil)
+}
+
+// buildBookingRescheduleHTML renders the email sent to the client after a
+// reschedule. It shows the old and the new slot side by side, both in the
+// visitor's timezone.
+func buildBookingRescheduleHTML(details BookingRescheduleDetails) string {
+	meetLinkHTML := meetingLinkHTML(details.BookingConfirmationDetails)
+
+	previousStart := details.PreviousStartTime.In(details.StartTime.Location())
+	previousEnd := details.PreviousEndTime.In(details.StartTime.Location())
+
+	body := fmt.Sprintf(`
+		<p>Hi %s,</p>
+		<p>Your consultation has been rescheduled.</p>
+		<p><strong>Previous time:</strong> <s>%s, %s - %s (%s)</s></p>
+		<p><strong>New time:</strong></p>
+		<ul>
+		<li><strong>Date:</strong> %s</li>
+		<li><strong>Time:</strong> %s - %s (%s)</li>
+		%s
+		</ul>
+		<p>An updated calendar invitation (.ics file) is attached. Opening it will move the existing event in your calendar.</p>
+		<p>Thanks,<br>The IVMANTO Team</p>`,
+		details.ToName,
+		previousStart.Format("Monday, January 2, 2006"),
+		previousStart.Format("3:04 PM"),
+		previousEnd.Format("3:04 PM"),
+		previousStart.Format("MST"),
+		details.StartTime.Format("Monday, January 2, 2006"),
+		details.StartTime.Format("3:04 PM"),
+		details.EndTime.Format("3:04 PM"),
+		details.Timezone,
+		meetLinkHTML)
+
+	if details.CancellationURL != "" {
+		body += fmt.Sprintf(`<p style="font-size: small; color: #666;">Need to make a change? <a href="%s">Cancel this booking</a>.</p>`, details.CancellationURL)
+	}
+	return body
+}
+
+// SendBookingRescheduled sends the combined "rescheduled" email to the client,
+// with an updated .ics that replaces the original invitation.
+func (s *SmtpService) SendBookingRescheduled(details BookingRescheduleDetails) error {
+	subject := "Your consultation has been rescheduled"
+	htmlBody := buildBookingRescheduleHTML(details)
+	return s.send([]string{details.ToEmail}, nil, subject, htmlBody, bookingInvite(details.BookingConfirmationDetails))
+}
+
+// SendBookingRescheduleToAdmin notifies the admin that a client moved their booking.
+func (s *SmtpService) SendBookingRescheduleToAdmin(clientName, clientEmail string, previousStart, newStart time.Time) error {
+	parts := strings.Split(s.cfg.SendFrom, "@")
+	var adminEmail string
+	if len(parts) == 2 {
+		adminEmail = fmt.Sprintf("%s+booking@%s", parts[0], parts[1])
+	} else {
+		adminEmail = s.cfg.SendFrom // Fallback for non-standard emails
+	}
+
+	subject := "Consultation Rescheduled by Client"
+	body := fmt.Sprintf("The consultation with <strong>%s (%s)</strong> has been moved from <strong>%s</strong> to <strong>%s</strong>.", clientName, clientEmail, previousStart.Format(time.RFC1123), newStart.Format(time.RFC1123))
+	return s.send([]string{adminEmail}, nil, subject, body, nil)
 }
 
 // SendBookingNotificationToAdmin sends a notification email to the admin.
-func (s *SmtpService) SendBookingNotificationToAdmin(name, clientEmail string, startTime time.Time, notes string) error {
+func (s *SmtpService) SendBookingNotificationToAdmin(details BookingNotificationDetails) error {
 	// Use a '+booking' alias to ensure delivery to the admin's inbox.
 	parts := strings.Split(s.cfg.SendFrom, "@")
 	var adminEmail string
@@ -259,7 +510,28 @@ func (s *SmtpService) SendBookingNotificationToAdmin(name, clientEmail string, s
 	}
 
 	subject := "New Consultation Booked!"
-	body := fmt.Sprintf("New booking with:<br>Name: %s<br>Email: %s<br>Time: %s<br>Notes: %s", name, clientEmail, startTime.Format(time.RFC1123), notes)
+	if details.SessionName != "" {
+		subject = fmt.Sprintf("New %s Booked!", details.SessionName)
+	}
+	body := fmt.Sprintf("New booking with:<br>Name: %s<br>Email: %s<br>Session: %s<br>Time: %s<br>Notes: %s", details.Name, details.Email, details.SessionName, details.StartTime.Format(time.RFC1123), details.Notes)
+	if len(details.Guests) > 0 {
+		body += "<br>Guests: " + html.EscapeString(strings.Join(details.Guests, ", "))
+	}
+	if len(details.Occurrences) > 0 {
+		body += fmt.Sprintf("<br><br>Recurring series of %d sessions:", len(details.Occurrences))
+		for _, start := range details.Occurrences {
+			body += "<br>" + start.Format(time.RFC1123)
+		}
+	}
+	if len(details.Intake) > 0 {
+		body += "<br><br>Intake:"
+		for _, answer := range details.Intake {
+			body += fmt.Sprintf("<br>%s: %s", html.EscapeString(answer.Label), html.EscapeString(answer.Value))
+		}
+	}
+	if details.HiddenConflicts > 0 {
+		body += fmt.Sprintf("<br><br>Note: %d slot(s) on this day were hidden because they clash with your other calendars.", details.HiddenConflicts)
+	}
 	return s.send([]string{adminEmail}, nil, subject, body, nil)
 }
 
@@ -317,6 +589,32 @@ func (s *SmtpService) SendBookingCancellationToClient(toName, toEmail string, st
 	return s.send([]string{toEmail}, nil, subject, htmlBody, nil)
 }
 
+// SendBookingSeriesCancellationToClient confirms to the client that the
+// occurrences of a recurring series starting at startTimes were cancelled,
+// rendered in the visitor's timezone like SendBookingCancellationToClient.
+func (s *SmtpService) SendBookingSeriesCancellationToClient(toName, toEmail string, startTimes []time.Time, visitorLoc *time.Location) error {
+	subject := "Your consultations have been cancelled"
+
+	var slots strings.Builder
+	for _, startTime := range startTimes {
+		if visitorLoc != nil {
+			startTime = startTime.In(visitorLoc)
+		}
+		slots.WriteString(fmt.Sprintf("<li>%s</li>", startTime.Format("Monday, January 2, 2006 at 3:04 PM MST")))
+	}
+
+	htmlBody := fmt.Sprintf(`
+		<p>Hi %s,</p>
+		<p>This is a confirmation that the following consultations of your recurring series have been successfully cancelled:</p>
+		<ul>%s</ul>
+		<p>If you wish to book another time, please feel free to visit our <a href="https://ivmanto.com/booking"><strong>booking page</strong></a> again.</p>
+		<p>Thanks,<br>The IVMANTO Team</p>`,
+		toName,
+		slots.String())
+
+	return s.send([]string{toEmail}, nil, subject, htmlBody, nil)
+}
+
 // SendBookingCancellationToAdmin sends a notification to the admin about a client cancellation.
 func (s *SmtpService) SendBookingCancellationToAdmin(clientName, clientEmail string, startTime time.Time) error {
 	parts := strings.Split(s.cfg.SendFrom, "@")
@@ -128,3 +128,153 @@ func TestBookingConfirmationHTML_NoMeetLinkOmitsLine(t *testing.T) {
 		t.Errorf("expected no Meet-link line when MeetLink is empty, body was:\n%s", body)
 	}
 }
+
+// TestBookingConfirmationHTML_NamesConferenceProvider labels the meeting link
+// with the provider hosting it.
+func TestBookingConfirmationHTML_NamesConferenceProvider(t *testing.T) {
+	body := buildBookingConfirmationHTML(BookingConfirmationDetails{
+		ToName:         "Test",
+		StartTime:      time.Now(),
+		EndTime:        time.Now().Add(30 * time.Minute),
+		MeetLink:       "https://zoom.us/j/123",
+		ConferenceName: "Zoom",
+	})
+	if !strings.Contains(body, `<strong>Zoom Link:</strong> <a href="https://zoom.us/j/123">`) {
+		t.Errorf("expected a Zoom link line, body was:\n%s", body)
+	}
+	if strings.Contains(body, "Google Meet") {
+		t.Errorf("expected no mention of Google Meet, body was:\n%s", body)
+	}
+}
+
+// TestBookingRescheduleHTML_ShowsPreviousAndNewSlot verifies that the
+// reschedule email renders both the old and the new slot in the
+// visitor's timezone, so the client can see what moved.
+func TestBookingRescheduleHTML_ShowsPreviousAndNewSlot(t *testing.T) {
+	athens, err := time.LoadLocation("Europe/Athens")
+	if err != nil {
+		t.Skipf("tzdata not available for Europe/Athens: %v", err)
+	}
+
+	// Previous: 15:30 CEST (16:30 EEST). New: next day 10:00 CEST (11:00 EEST).
+	prevStart := time.Date(2026, 6, 15, 13, 30, 0, 0, time.UTC)
+	newStart := time.Date(2026, 6, 16, 8, 0, 0, 0, time.UTC).In(athens)
+
+	body := buildBookingRescheduleHTML(BookingRescheduleDetails{
+		BookingConfirmationDetails: BookingConfirmationDetails{
+			ToName:    "Marina Muchakova",
+			ToEmail:   "marina@example.com",
+			StartTime: newStart,
+			EndTime:   newStart.Add(30 * time.Minute),
+			Timezone:  newStart.Format("MST"),
+		},
+		PreviousStartTime: prevStart,
+		PreviousEndTime:   prevStart.Add(30 * time.Minute),
+	})
+
+	wantSubs := []string{
+		"Monday, June 15, 2026, 4:30 PM - 5:00 PM (EEST)",
+		"Tuesday, June 16, 2026",
+		"11:00 AM - 11:30 AM (EEST)",
+	}
+	for _, s := range wantSubs {
+		if !strings.Contains(body, s) {
+			t.Errorf("expected body to contain %q, body was:\n%s", s, body)
+		}
+	}
+}
+
+// TestBookingConfirmationHTML_SessionLabel checks that the intro line names
+// the booked session type and its actual length, and keeps the original
+// wording for the default 30-minute consultation.
+func TestBookingConfirmationHTML_SessionLabel(t *testing.T) {
+	start := time.Date(2026, 6, 15, 9, 0, 0, 0, time.UTC)
+
+	review := buildBookingConfirmationHTML(BookingConfirmationDetails{
+		ToName:      "Test",
+		StartTime:   start,
+		EndTime:     start.Add(90 * time.Minute),
+		SessionName: "Architecture Review",
+	})
+	if !strings.Contains(review, "Your 90-minute architecture review is confirmed.") {
+		t.Errorf("expected the session label for a review, body was:\n%s", review)
+	}
+
+	consultation := buildBookingConfirmationHTML(BookingConfirmationDetails{
+		ToName:    "Test",
+		StartTime: start,
+		EndTime:   start.Add(30 * time.Minute),
+	})
+	if !strings.Contains(consultation, "Your 30-minute consultation is confirmed.") {
+		t.Errorf("expected the default label, body was:\n%s", consultation)
+	}
+}
+
+// TestBookingReminderHTML_IncludesMeetAndManageLinks checks that the reminder
+// shows the slot in the visitor's zone with the Meet and cancel/reschedule links.
+func TestBookingReminderHTML_IncludesMeetAndManageLinks(t *testing.T) {
+	athens, err := time.LoadLocation("Europe/Athens")
+	if err != nil {
+		t.Skipf("tzdata not available for Europe/Athens: %v", err)
+	}
+	start := time.Date(2026, 6, 15, 13, 30, 0, 0, time.UTC).In(athens)
+
+	body := buildBookingReminderHTML(BookingConfirmationDetails{
+		ToName:          "Ada",
+		StartTime:       start,
+		EndTime:         start.Add(30 * time.Minute),
+		Timezone:        start.Format("MST"),
+		MeetLink:        "https://meet.google.com/abc-defg-hij",
+		CancellationURL: "https://ivmanto.com/booking/cancel?token=tok",
+	})
+
+	for _, s := range []string{"4:30 PM", "(EEST)", "https://meet.google.com/abc-defg-hij", "https://ivmanto.com/booking/cancel?token=tok", "30-minute consultation"} {
+		if !strings.Contains(body, s) {
+			t.Errorf("expected body to contain %q, body was:\n%s", s, body)
+		}
+	}
+}
+
+// TestBookingConfirmationHTML_ListsGuests names the guests and asks the client
+// to forward the invitation to them.
+func TestBookingConfirmationHTML_ListsGuests(t *testing.T) {
+	body := buildBookingConfirmationHTML(BookingConfirmationDetails{
+		ToName:    "Test",
+		ToEmail:   "test@example.com",
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(30 * time.Minute),
+		Timezone:  "UTC",
+		Guests:    []string{"bob@example.com", "cy@example.com"},
+	})
+	if !strings.Contains(body, "bob@example.com, cy@example.com") || !strings.Contains(body, "Forward it to your guests") {
+		t.Errorf("expected the guests and a note to forward the invitation, body was:\n%s", body)
+	}
+}
+
+func TestBookingConfirmationHTML_DescribesSeries(t *testing.T) {
+	start := time.Date(2026, 6, 8, 9, 0, 0, 0, time.UTC)
+	details := BookingConfirmationDetails{
+		ToName:    "Test",
+		ToEmail:   "test@example.com",
+		StartTime: start,
+		EndTime:   start.Add(30 * time.Minute),
+		Timezone:  "UTC",
+		IcsUID:    "series@ivmanto.com",
+		Series: &BookingSeries{
+			IntervalWeeks: 2,
+			Count:         3,
+			Start:         start,
+			End:           start.Add(30 * time.Minute),
+			Location:      time.UTC,
+			Cancelled:     []time.Time{start.AddDate(0, 0, 14)},
+		},
+	}
+	body := buildBookingConfirmationHTML(details)
+	if !strings.Contains(body, "Every 2 weeks, 3 sessions until Monday, July 6, 2026 (1 cancelled)") {
+		t.Errorf("expected the series to be described, body was:\n%s", body)
+	}
+	invite := string(bookingInvite(details).Body)
+	if !strings.Contains(invite, "RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=3\r\n") || !strings.Contains(invite, "EXDATE:20260622T090000Z\r\n") {
+		t.Errorf("expected the invitation to the series, got:\n%s", invite)
+	}
+}
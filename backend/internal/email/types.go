@@ -18,7 +18,9 @@ type BookingConfirmationDetails struct {
 	StartTime       time.Time
 	EndTime         time.Time
 	Timezone        string
-	MeetLink        string
+	SessionName     string // e.g. "Architecture Review"; empty renders as "consultation"
+	MeetLink        string // link to join the video conference, whichever provider hosts it
+	ConferenceName  string // e.g. "Zoom"; empty renders as "Google Meet"
 	CancellationURL string
 	IcsUID          string
 	IcsSummary      string
@@ -28,6 +30,98 @@ type BookingConfirmationDetails struct {
 	// VCALENDAR level so older Outlook/iOS clients render the event in the
 	// visitor's zone. Empty means the header is omitted.
 	IcsTimezone string
+	// IcsSequence is the revision of the invitation identified by IcsUID.
+	// It is 0 for a new booking and incremented on every reschedule.
+	IcsSequence int
+	// Guests are the colleagues the client invited. They are listed in the
+	// email and invited in the .ics attachment, which the client forwards.
+	Guests []string
+	// Series describes the recurring series the booking is an occurrence of,
+	// or is nil for a single booking. The .ics attachment then invites the
+	// client to the whole series.
+	Series *BookingSeries
+}
+
+// BookingSeries describes a recurring series of bookings.
+type BookingSeries struct {
+	IntervalWeeks int
+	Count         int // occurrences booked, cancelled ones included
+	// Start and End are the first occurrence of the series, which is where
+	// the recurrence of the invitation starts even if it was cancelled.
+	Start time.Time
+	End   time.Time
+	// Location is the calendar's timezone, in which the series keeps its
+	// time of day.
+	Location *time.Location
+	// Cancelled are the starts of the cancelled occurrences.
+	Cancelled []time.Time
+}
+
+// BookingNotificationDetails holds the information for the admin
+// notification about a new booking.
+type BookingNotificationDetails struct {
+	Name        string
+	Email       string
+	StartTime   time.Time
+	Notes       string
+	SessionName string
+	Guests      []string
+	// Intake holds the client's answers to the intake questionnaire.
+	Intake []IntakeAnswer
+	// Occurrences are the starts of all bookings of a recurring series, or
+	// empty for a single booking.
+	Occurrences []time.Time
+	// HiddenConflicts is the number of slots on the booked day that were not
+	// offered because they clash with one of the consultant's other calendars.
+	HiddenConflicts int
+}
+
+// IntakeAnswer is a client's answer to one intake question.
+type IntakeAnswer struct {
+	Label string
+	Value string
+}
+
+// BookingRescheduleDetails holds the information for the email sent when a
+// client moves their booking to a new slot. The embedded confirmation
+// details describe the new slot; the .ics attachment reuses the original
+// UID with an incremented sequence so calendar clients move the event.
+type BookingRescheduleDetails struct {
+	BookingConfirmationDetails
+	PreviousStartTime time.Time
+	PreviousEndTime   time.Time
+}
+
+// BookingFollowUpDetails holds the information for the thank-you email sent
+// after a consultation.
+type BookingFollowUpDetails struct {
+	ToName      string
+	ToEmail     string
+	StartTime   time.Time // in the visitor's timezone
+	SessionName string
+	FeedbackURL string
+}
+
+// FeedbackNotificationDetails holds the information for the admin
+// notification about feedback given on a consultation.
+type FeedbackNotificationDetails struct {
+	EventID     string
+	ClientName  string
+	ClientEmail string
+	StartTime   time.Time
+	SessionName string
+	Rating      int
+	Comment     string
+}
+
+// WaitlistOfferDetails holds the information for the email telling a
+// waitlisted visitor that slots opened up in their preferred date range.
+type WaitlistOfferDetails struct {
+	ToEmail    string
+	Slots      []time.Time // start times of the opened slots, in the visitor's timezone
+	Timezone   string      // display label of the visitor's timezone, e.g. "EEST"
+	BookingURL string      // priority booking link
+	Expires    time.Time   // when the priority booking link stops reserving the slots
 }
 
 // GeneratedIdea holds the data for a single AI-generated idea.
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,19 +16,115 @@ import (
 	"google.golang.org/api/googleapi"
 	"google.golang.org/api/impersonate"
 	"google.golang.org/api/option"
+	"ivmanto.com/backend/internal/availability"
+	"ivmanto.com/backend/internal/bookingtoken"
+	"ivmanto.com/backend/internal/conferencing"
 	"ivmanto.com/backend/internal/config"
 )
 
 var (
 	// ErrSlotNotFound is returned when a requested booking slot cannot be found or is already booked.
 	ErrSlotNotFound = errors.New("slot not found or already booked")
+	// ErrBookingChanged is returned when a booked event was modified after it was read.
+	ErrBookingChanged = errors.New("booking changed since it was read")
+	// ErrCancellationClosed is returned when a client cancels or reschedules a
+	// booking inside the cancellation cutoff; only the admin can cancel it then.
+	ErrCancellationClosed = errors.New("booking is inside the cancellation cutoff")
+	// ErrBookingStarted is returned when a booking that has already started is
+	// cancelled or rescheduled.
+	ErrBookingStarted = errors.New("booking has already started")
+	// ErrUnknownConferencing is returned when a booking asks for a
+	// video-conferencing provider that is not configured.
+	ErrUnknownConferencing = errors.New("unknown conferencing provider")
+	// ErrGuestNotFound is returned when a guest to remove is not on the booking.
+	ErrGuestNotFound = errors.New("guest not found on booking")
+	// ErrInvalidRecurrence is returned when a recurrence rule asks for fewer
+	// than two or more than MaxSeriesOccurrences bookings.
+	ErrInvalidRecurrence = errors.New("invalid recurrence")
+	// ErrNotSeries is returned when a series operation is asked of a booking
+	// that is not part of a recurring series.
+	ErrNotSeries = errors.New("booking is not part of a series")
+	// ErrSeriesOccurrence is returned when an occurrence of a recurring series
+	// is rescheduled; it can only be cancelled.
+	ErrSeriesOccurrence = errors.New("booking is an occurrence of a series")
+	// ErrFeedbackExists is returned when feedback is recorded on a booking
+	// that has feedback already.
+	ErrFeedbackExists = errors.New("feedback already given")
 )
 
+// MaxPropertyValue is the longest value, in characters, the Calendar API
+// keeps in an extended property of an event.
+const MaxPropertyValue = 1024
+
 // Service defines the interface for interacting with Google Calendar.
 type Service interface {
 	GetAvailability(day time.Time) ([]*calendar.Event, error)
+	GetAvailabilityRange(from, to time.Time) ([]*calendar.Event, error)
+	// ConflictingSlots returns the slots in [from, to) that would be available
+	// but are hidden because they clash with one of the conflict calendars.
+	ConflictingSlots(from, to time.Time) ([]*calendar.Event, error)
+	// HoldSlot reserves an available slot for a short time and returns the
+	// hold token needed to book it while the hold lasts.
+	HoldSlot(ctx context.Context, eventID string) (token string, expires time.Time, err error)
+	// ReleaseHold ends the hold with token on eventID before it expires.
+	ReleaseHold(ctx context.Context, eventID, token string) error
 	BookSlot(details BookingDetails) (*calendar.Event, error)
+	// BookSeries books the slot details.EventID and the slots at the same
+	// time of day that rule repeats it on, all or none. See Recurrence.
+	BookSeries(ctx context.Context, details BookingDetails, rule Recurrence) ([]*calendar.Event, error)
+	// SeriesEventIDs returns the slots BookSeries would book, without booking
+	// them.
+	SeriesEventIDs(ctx context.Context, eventID string, rule Recurrence) ([]string, error)
+	// SeriesBookings returns the booked occurrences of the series seriesID,
+	// past and upcoming, ordered by start time.
+	SeriesBookings(ctx context.Context, seriesID string) ([]*calendar.Event, error)
+	// FindBooking returns the booked event a management token with the view
+	// scope was issued for, without changing it, or ErrSlotNotFound if the
+	// token is invalid or expired or the booking is gone. See bookingtoken.
+	FindBooking(ctx context.Context, token string) (*calendar.Event, error)
+	// FeedbackBooking returns the booked event a feedback token was issued
+	// for, or ErrSlotNotFound like FindBooking.
+	FeedbackBooking(ctx context.Context, token string) (*calendar.Event, error)
+	// CancelBooking cancels a booking on the client's behalf under the
+	// cancellation policy, failing with ErrCancellationClosed or ErrBookingStarted.
 	CancelBooking(ctx context.Context, token string) (*calendar.Event, error)
+	// CancelOccurrence is CancelBooking for the occurrence eventID of the
+	// series of the booking the token was issued for.
+	CancelOccurrence(ctx context.Context, token, eventID string) (*calendar.Event, error)
+	// CancelSeries cancels the occurrences of the series of the booking the
+	// token was issued for that the cancellation policy still allows to be
+	// cancelled. It returns their snapshots and the occurrences it kept.
+	CancelSeries(ctx context.Context, token string) (cancelled, kept []*calendar.Event, err error)
+	// OverrideCancellation cancels the booked event eventID for the admin,
+	// also inside the cancellation cutoff. See LateCancellation.
+	OverrideCancellation(ctx context.Context, eventID string) (*calendar.Event, error)
+	RescheduleBooking(ctx context.Context, token, newEventID string) (previous *calendar.Event, rescheduled *calendar.Event, err error)
+	// ListBookings lists the booked consultations that start in [from, to).
+	ListBookings(from, to time.Time) ([]*calendar.Event, error)
+	// GetBooking returns the booked event with the given ID, or ErrSlotNotFound.
+	GetBooking(ctx context.Context, eventID string) (*calendar.Event, error)
+	// MarkRemindersSent records on a booked event that the reminders for the
+	// given offsets were sent. It returns ErrBookingChanged if the event was
+	// modified since it was read.
+	MarkRemindersSent(ctx context.Context, event *calendar.Event, offsets []time.Duration) (*calendar.Event, error)
+	// MarkFollowUpSent records on a booked event that the follow-up email was
+	// sent, or returns ErrBookingChanged like MarkRemindersSent.
+	MarkFollowUpSent(ctx context.Context, event *calendar.Event) (*calendar.Event, error)
+	// SetNoShow flags or unflags the client of a booked event as a no-show.
+	SetNoShow(ctx context.Context, eventID string, noShow bool) (*calendar.Event, error)
+	// RecordFeedback records the client's feedback on a booked event. It
+	// returns ErrFeedbackExists if there is feedback already and
+	// ErrBookingChanged like MarkRemindersSent.
+	RecordFeedback(ctx context.Context, event *calendar.Event, fb Feedback) (*calendar.Event, error)
+	// RemoveGuest takes a guest the client invited off the booked event
+	// eventID, or returns ErrGuestNotFound.
+	RemoveGuest(ctx context.Context, eventID, guest string) (*calendar.Event, error)
+	// BookingsOf returns the booked events whose client or one of whose
+	// guests has the given email address.
+	BookingsOf(ctx context.Context, email string) ([]*calendar.Event, error)
+	// EraseBooking removes the personal data from a past booked event. It
+	// returns ErrBookingChanged if the event was modified since it was read.
+	EraseBooking(ctx context.Context, event *calendar.Event) (*calendar.Event, error)
 	Location() *time.Location
 }
 
@@ -36,6 +134,10 @@ type gcalService struct {
 	calendarID           string
 	location             *time.Location
 	availableSlotSummary string
+	conflictCalendarIDs  []string
+	booking              config.BookingConfig
+	tokens               *bookingtoken.Signer
+	now                  func() time.Time
 }
 
 // BookingDetails contains information for a new booking.
@@ -45,6 +147,20 @@ type BookingDetails struct {
 	Email           string
 	Notes           string
 	VisitorTimezone string // IANA zone, e.g. "Europe/Athens"; empty string falls back to the calendar's zone
+	// SessionType optionally pins the session type the visitor saw when picking
+	// the slot. The slot itself decides the type; a mismatch fails the booking.
+	SessionType string
+	// Intake holds the answers to the session type's intake questionnaire, by
+	// field ID. Invalid answers fail the booking with config.IntakeErrors.
+	Intake map[string]string
+	// HoldToken is the token returned by HoldSlot, needed to book a held slot.
+	HoldToken string
+	// Conferencing optionally picks the video-conferencing provider, e.g.
+	// "jitsi". Empty uses the session type's provider or the default.
+	Conferencing string
+	// Guests are the email addresses of colleagues the client brings along.
+	// They become attendees of the event but cannot manage the booking.
+	Guests []string
 }
 
 // NewService creates a new calendar service client using Domain-Wide Delegation.
@@ -84,12 +200,23 @@ func NewService(ctx context.Context, cfg *config.Config) (Service, error) {
 		loc = time.UTC
 	}
 
+	return NewServiceWithClient(srv, cfg, loc), nil
+}
+
+// NewServiceWithClient creates a Service on top of an already authenticated
+// Calendar API client. NewService uses it after setting up DWD; tests use it
+// to point the service at a fake Calendar backend (see package gcaltest).
+func NewServiceWithClient(srv *calendar.Service, cfg *config.Config, loc *time.Location) Service {
 	return &gcalService{
 		calSvc:               srv,
 		calendarID:           cfg.GCal.CalendarID,
 		location:             loc,
 		availableSlotSummary: strings.TrimSpace(cfg.GCal.AvailableSlotSummary),
-	}, nil
+		conflictCalendarIDs:  cfg.GCal.ConflictCalendarIDs,
+		booking:              cfg.Booking,
+		tokens:               bookingtoken.NewSigner(cfg.Booking.Tokens),
+		now:                  time.Now,
+	}
 }
 
 // GetAvailability fetches available time slots for a given day.
@@ -97,111 +224,318 @@ func NewService(ctx context.Context, cfg *config.Config) (Service, error) {
 func (s *gcalService) GetAvailability(day time.Time) ([]*calendar.Event, error) {
 	loc := s.location
 	startOfDay := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
-	endOfDay := startOfDay.Add(24 * time.Hour)
+	endOfDay := startOfDay.AddDate(0, 0, 1)
+	return s.GetAvailabilityRange(startOfDay, endOfDay)
+}
 
-	events, err := s.calSvc.Events.List(s.calendarID).
-		TimeMin(startOfDay.Format(time.RFC3339)).
-		TimeMax(endOfDay.Format(time.RFC3339)).
+// GetAvailabilityRange fetches all available time slots that start in [from, to),
+// ordered by start time. Slots that clash with a conflict calendar or are held
+// by another visitor are left out.
+func (s *gcalService) GetAvailabilityRange(from, to time.Time) ([]*calendar.Event, error) {
+	slots, _, err := s.availableSlots(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return s.withoutHolds(slots, from, to)
+}
+
+// ConflictingSlots returns the slots in [from, to) that GetAvailabilityRange
+// leaves out because they clash with a conflict calendar.
+func (s *gcalService) ConflictingSlots(from, to time.Time) ([]*calendar.Event, error) {
+	_, conflicting, err := s.availableSlots(from, to)
+	return conflicting, err
+}
+
+// availableSlots lists the candidate slots in [from, to) — placeholders, or
+// computed slots in rules mode — and splits them into the bookable ones and
+// the ones that clash with the conflict calendars.
+func (s *gcalService) availableSlots(from, to time.Time) (available, conflicting []*calendar.Event, err error) {
+	var candidates []*calendar.Event
+	var padBefore, padAfter time.Duration
+	if s.rulesMode() {
+		candidates, err = s.ruleSlots(from, to)
+		padBefore, padAfter = s.booking.Availability.BufferBefore, s.booking.Availability.BufferAfter
+	} else {
+		candidates, err = s.placeholderSlots(from, to)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.splitConflicts(candidates, padBefore, padAfter)
+}
+
+// splitConflicts checks slots against the busy time of the conflict calendars
+// with one FreeBusy query. Each slot is padded by padBefore/padAfter first.
+func (s *gcalService) splitConflicts(slots []*calendar.Event, padBefore, padAfter time.Duration) (available, conflicting []*calendar.Event, err error) {
+	if len(s.conflictCalendarIDs) == 0 || len(slots) == 0 {
+		return slots, nil, nil
+	}
+
+	intervals := make([]availability.Interval, len(slots))
+	for i, slot := range slots {
+		start, err1 := time.Parse(time.RFC3339, slot.Start.DateTime)
+		end, err2 := time.Parse(time.RFC3339, slot.End.DateTime)
+		if err1 != nil || err2 != nil {
+			return nil, nil, fmt.Errorf("slot %s has an unparsable start or end time", slot.Id)
+		}
+		intervals[i] = availability.Interval{Start: start.Add(-padBefore), End: end.Add(padAfter)}
+	}
+	windowStart, windowEnd := intervals[0].Start, intervals[0].End
+	for _, iv := range intervals {
+		if iv.Start.Before(windowStart) {
+			windowStart = iv.Start
+		}
+		if iv.End.After(windowEnd) {
+			windowEnd = iv.End
+		}
+	}
+
+	busy, err := s.busyIntervals(windowStart, windowEnd, s.conflictCalendarIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, slot := range slots {
+		if availability.OverlapsAny(intervals[i], busy) {
+			conflicting = append(conflicting, slot)
+		} else {
+			available = append(available, slot)
+		}
+	}
+	if len(conflicting) > 0 {
+		slog.Info("Hid slots that clash with conflict calendars", "count", len(conflicting))
+	}
+	return available, conflicting, nil
+}
+
+// placeholderSlots lists the "Available" placeholder events that start in
+// [from, to) with a single paginated Events.List query, ordered by start time.
+func (s *gcalService) placeholderSlots(from, to time.Time) ([]*calendar.Event, error) {
+	var items []*calendar.Event
+	err := s.calSvc.Events.List(s.calendarID).
+		TimeMin(from.Format(time.RFC3339)).
+		TimeMax(to.Format(time.RFC3339)).
 		Q(s.availableSlotSummary). // Search for events with the "Available" summary
 		SingleEvents(true).
 		OrderBy("startTime").
-		Do()
+		MaxResults(2500).
+		Pages(context.Background(), func(events *calendar.Events) error {
+			items = append(items, events.Items...)
+			return nil
+		})
 	if err != nil {
 		return nil, fmt.Errorf("unable to retrieve availability events: %w", err)
 	}
 
 	// The original implementation returned []*calendar.TimePeriod, but the handler
-	// expects []*calendar.Event. We will return the events directly.
-	return events.Items, nil
+	// expects []*calendar.Event. We will return the events directly, keeping only
+	// real placeholders (the full-text search also matches e.g. booked events whose
+	// client name contains the summary) and annotating each with its session type.
+	var slots []*calendar.Event
+	for _, event := range items {
+		st, ok := s.sessionTypeFor(event)
+		if !ok {
+			continue
+		}
+		setPrivate(event, "session_type", st.ID)
+		slots = append(slots, event)
+	}
+	return slots, nil
+}
+
+// sessionTypeFor reports whether event is an "Available" placeholder and, if so,
+// which session type it offers. A placeholder is tagged either with a
+// "session_type" private property or with a suffix after the configured summary,
+// e.g. "AfB workshop"; an untagged placeholder offers the default session type.
+// Placeholders tagged with an unknown session type are not bookable.
+func (s *gcalService) sessionTypeFor(event *calendar.Event) (config.SessionType, bool) {
+	summary := strings.TrimSpace(event.Summary)
+	if !strings.HasPrefix(summary, s.availableSlotSummary) {
+		return config.SessionType{}, false
+	}
+	tag := summary[len(s.availableSlotSummary):]
+	if tag != "" {
+		// Require a separator so "AfB" does not match a summary like "AfBx".
+		if !strings.ContainsAny(tag[:1], " :-/([") {
+			return config.SessionType{}, false
+		}
+		tag = strings.Trim(tag, " :-/()[]")
+	}
+	if event.ExtendedProperties != nil && event.ExtendedProperties.Private["session_type"] != "" {
+		tag = event.ExtendedProperties.Private["session_type"]
+	}
+
+	st, ok := s.booking.SessionType(tag)
+	if !ok {
+		slog.Warn("Available slot is tagged with an unknown session type", "eventID", event.Id, "sessionType", tag)
+	}
+	return st, ok
+}
+
+// setPrivate sets a private extended property on event, creating the maps as needed.
+func setPrivate(event *calendar.Event, key, value string) {
+	if event.ExtendedProperties == nil {
+		event.ExtendedProperties = &calendar.EventExtendedProperties{}
+	}
+	if event.ExtendedProperties.Private == nil {
+		event.ExtendedProperties.Private = make(map[string]string)
+	}
+	event.ExtendedProperties.Private[key] = value
 }
 
 // BookSlot books a consultation by finding an "Available" event and updating it.
 // This provides an atomic way to claim a slot.
 func (s *gcalService) BookSlot(details BookingDetails) (*calendar.Event, error) {
-	slog.Info("Attempting to book event", "eventID", details.EventID)
+	return s.bookEvent(details.EventID, newClaim(details))
+}
+
+// newClaim builds the claim on a slot for a new booking with details.
+func newClaim(details BookingDetails) slotClaim {
+	private := map[string]string{
+		// Store booking details for later use (e.g., cancellation notifications).
+		"client_name":  details.Name,
+		"client_email": details.Email,
+		// VisitorTimezone is the IANA zone of the visitor's browser at booking
+		// time. We persist it so the cancellation email can render the slot
+		// in the visitor's local time even though the cancellation request
+		// comes via an email link with no live client context. The booking
+		// handler's resolveVisitorTimezone helper applies the same fallback
+		// rules if this string is empty or unknown.
+		"visitor_timezone": details.VisitorTimezone,
+	}
+	if len(details.Guests) > 0 {
+		private[guestsProperty] = strings.Join(details.Guests, ",")
+	}
+
+	// Generate a unique secret for this booking. The signed management tokens
+	// are bound to it (see bookingtoken.Binding); before tokens were signed, it
+	// was itself the token in the links.
+	cancellationUUID, err := uuid.NewRandom()
+	if err != nil {
+		// This is a server-side issue, but we shouldn't fail the whole booking for it.
+		// Log it and continue. The user just won't get a cancellation link.
+		slog.Warn("could not generate cancellation token UUID", "error", err)
+	} else {
+		private["cancellation_token"] = cancellationUUID.String()
+	}
+
+	description := fmt.Sprintf(
+		"Client Name: %s\nClient Email: %s\n\nNotes:\n%s",
+		details.Name,
+		details.Email,
+		details.Notes,
+	)
+	if len(details.Guests) > 0 {
+		description += "\n\nGuests: " + strings.Join(details.Guests, ", ")
+	}
+	intake := details.Intake
+	if intake == nil {
+		intake = map[string]string{}
+	}
+	return slotClaim{
+		SessionType:  details.SessionType,
+		ClientName:   details.Name,
+		Description:  description,
+		Private:      private,
+		Intake:       intake,
+		HoldToken:    details.HoldToken,
+		Conferencing: details.Conferencing,
+	}
+}
+
+// slotClaim describes the booking that is written onto a slot.
+type slotClaim struct {
+	SessionType string // optional; the slot must offer this session type
+	ClientName  string
+	Description string
+	Private     map[string]string
+	// Intake holds the answers to the intake questionnaire of a new booking.
+	// It is validated against the slot's session type once that is known. A
+	// nil map skips the questionnaire, e.g. for a reschedule, which carries
+	// the answers over in Private and Description.
+	Intake map[string]string
+	// HoldToken books a slot held by HoldSlot with this token.
+	HoldToken string
+	// Conferencing optionally picks the video-conferencing provider.
+	Conferencing string
+}
+
+// bookEvent books eventID for claim. In rules mode, computed slot IDs are
+// created on the fly (see bookRuleSlot); everything else is an "Available"
+// placeholder that is claimed in place.
+func (s *gcalService) bookEvent(eventID string, claim slotClaim) (*calendar.Event, error) {
+	if s.rulesMode() {
+		if _, ok := parseRuleSlotID(eventID); ok {
+			return s.bookRuleSlot(eventID, claim)
+		}
+	}
+	return s.claimSlot(eventID, claim)
+}
+
+// claimSlot verifies that eventID is still an "Available" slot and turns it into
+// the booking described by claim.
+func (s *gcalService) claimSlot(eventID string, claim slotClaim) (*calendar.Event, error) {
+	slog.Info("Attempting to book event", "eventID", eventID)
 
 	// 1. Get the event directly by its unique ID. This is more reliable than searching.
-	eventToBook, err := s.calSvc.Events.Get(s.calendarID, details.EventID).Do()
+	eventToBook, err := s.calSvc.Events.Get(s.calendarID, eventID).Do()
 	if err != nil {
 		// If the error is 404, it means the event doesn't exist, which we treat as a slot not found.
 		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
 			return nil, ErrSlotNotFound
 		}
-		return nil, fmt.Errorf("unable to retrieve event to book with ID %s: %w", details.EventID, err)
+		return nil, fmt.Errorf("unable to retrieve event to book with ID %s: %w", eventID, err)
 	}
 
 	slog.Info("Found available event to book", "eventID", eventToBook.Id)
 
 	// 2. Verify the event is indeed an available slot and not already booked.
 	// We trim the space from the calendar summary to be robust against accidental whitespace.
-	if strings.TrimSpace(eventToBook.Summary) != s.availableSlotSummary {
+	sessionType, ok := s.sessionTypeFor(eventToBook)
+	if !ok {
 		slog.Error("Slot verification failed", "eventSummary", strings.TrimSpace(eventToBook.Summary), "expectedSummary", s.availableSlotSummary)
 		return nil, ErrSlotNotFound
 	}
+	if claim.SessionType != "" && claim.SessionType != sessionType.ID {
+		slog.Error("Slot offers a different session type", "eventID", eventToBook.Id, "sessionType", sessionType.ID, "requested", claim.SessionType)
+		return nil, ErrSlotNotFound
+	}
 
-	// Generate a unique cancellation token for this booking.
-	cancellationUUID, err := uuid.NewRandom()
-	if err != nil {
-		// This is a server-side issue, but we shouldn't fail the whole booking for it.
-		// Log it and continue. The user just won't get a cancellation link.
-		slog.Warn("could not generate cancellation token UUID", "error", err)
-	} else {
-		cancellationToken := cancellationUUID.String()
-		if eventToBook.ExtendedProperties == nil {
-			eventToBook.ExtendedProperties = &calendar.EventExtendedProperties{}
-		}
-		if eventToBook.ExtendedProperties.Private == nil {
-			eventToBook.ExtendedProperties.Private = make(map[string]string)
-		}
-		// Store booking details for later use (e.g., cancellation notifications).
-		eventToBook.ExtendedProperties.Private["cancellation_token"] = cancellationToken
-		eventToBook.ExtendedProperties.Private["client_name"] = details.Name
-		eventToBook.ExtendedProperties.Private["client_email"] = details.Email
-		// VisitorTimezone is the IANA zone of the visitor's browser at booking
-		// time. We persist it so the cancellation email can render the slot
-		// in the visitor's local time even though the cancellation request
-		// comes via an email link with no live client context. The booking
-		// handler's resolveVisitorTimezone helper applies the same fallback
-		// rules if this string is empty or unknown.
-		eventToBook.ExtendedProperties.Private["visitor_timezone"] = details.VisitorTimezone
+	// A slot held by another visitor can only be booked with their hold token.
+	if token, held := s.activeHold(eventToBook); held && token != claim.HoldToken {
+		slog.Warn("Slot is held by another visitor", "eventID", eventToBook.Id)
+		return nil, ErrSlotNotFound
 	}
 
-	// 3. Update the event with the client's details.
-	eventToBook.Summary = fmt.Sprintf("Consultation: %s", details.Name)
-	eventToBook.Description = fmt.Sprintf(
-		"Client Name: %s\nClient Email: %s\n\nNotes:\n%s",
-		details.Name,
-		details.Email,
-		details.Notes,
-	)
-	// We do not add the client as an attendee directly, as this can require
-	// domain-wide delegation. Instead, we send an .ics attachment in the
-	// confirmation email. We will leave the existing attendees (i.e., the calendar owner) on the event.
-	// eventToBook.Attendees = nil
+	// The slot must not clash with the consultant's other calendars, even if it
+	// was offered before the clash appeared.
+	if _, conflicting, err := s.splitConflicts([]*calendar.Event{eventToBook}, 0, 0); err != nil {
+		return nil, err
+	} else if len(conflicting) > 0 {
+		slog.Warn("Slot clashes with a conflict calendar", "eventID", eventToBook.Id)
+		return nil, ErrSlotNotFound
+	}
 
-	// Request Google Meet conference data to be added to the event.
-	meetRequestID, err := uuid.NewRandom()
+	// 3. Update the event with the client's details.
+	provider, err := s.conferenceFor(sessionType, claim.Conferencing)
 	if err != nil {
-		slog.Warn("could not generate Meet request ID, skipping Meet link", "error", err)
-	} else {
-		eventToBook.ConferenceData = &calendar.ConferenceData{
-			CreateRequest: &calendar.CreateConferenceRequest{
-				RequestId: meetRequestID.String(),
-				ConferenceSolutionKey: &calendar.ConferenceSolutionKey{
-					Type: "hangoutsMeet",
-				},
-			},
-		}
+		return nil, err
+	}
+	if err := prepareBooking(eventToBook, sessionType, claim, provider); err != nil {
+		return nil, err
 	}
 
-	// 4. Atomically update the event. The ETag mechanism handled by the client library
-	// ensures that if the event was changed between our read and write, this will fail.
+	// 4. Atomically update the event. The If-Match precondition on the ETag we read
+	// in step 1 makes this a compare-and-swap: if anyone changed the event between
+	// our read and write (e.g. a concurrent booking of the same slot), the API
+	// rejects the update with 412 and only one visitor wins.
 	// ConferenceDataVersion(1) tells the API to process the ConferenceData create request.
-	updatedEvent, err := s.calSvc.Events.Update(s.calendarID, eventToBook.Id, eventToBook).ConferenceDataVersion(1).Do()
+	update := s.calSvc.Events.Update(s.calendarID, eventToBook.Id, eventToBook).ConferenceDataVersion(1)
+	update.Header().Set("If-Match", eventToBook.Etag)
+	updatedEvent, err := update.Do()
 
 	if err != nil {
 		// Check for a 409 Conflict or 412 Precondition Failed, which indicates the slot was just taken.
-		if gerr, ok := err.(*googleapi.Error); ok && (gerr.Code == http.StatusConflict || gerr.Code == http.StatusPreconditionFailed) {
+		if isConflict(err) {
 			return nil, ErrSlotNotFound
 		}
 		// This is a generic error for when the event update fails for reasons other than a conflict.
@@ -209,15 +543,359 @@ func (s *gcalService) BookSlot(details BookingDetails) (*calendar.Event, error)
 	}
 	slog.Info("Successfully updated event with booking details", "eventID", updatedEvent.Id)
 
-	// Return the updated event. The Google Meet link will be in ConferenceData / HangoutLink.
+	// Return the updated event. A Google Meet link will be in ConferenceData / HangoutLink.
 	return updatedEvent, nil
 }
 
-// CancelBooking finds an event by its cancellation token and reverts it to an available slot.
+// prepareBooking writes the booking described by claim onto event, which is
+// either an existing placeholder or a new rules-mode event, and attaches the
+// video conference of provider. The ICS UID and SEQUENCE are recorded on the
+// event so that later updates (e.g. a reschedule) can be sent to the client as
+// revisions of the same invitation.
+//
+// Intake answers are validated against sessionType, then stored both in the
+// description, for people reading the calendar, and as "intake_<field>"
+// private properties. Invalid answers are returned as config.IntakeErrors.
+func prepareBooking(event *calendar.Event, sessionType config.SessionType, claim slotClaim, provider conferencing.Provider) error {
+	description := claim.Description
+	var intake map[string]string
+	if claim.Intake != nil {
+		var err error
+		if intake, err = sessionType.ValidateIntake(claim.Intake); err != nil {
+			return err
+		}
+		var lines []string
+		for _, field := range sessionType.Intake {
+			if value, ok := intake[field.ID]; ok {
+				lines = append(lines, fmt.Sprintf("%s: %s", field.Label, value))
+			}
+		}
+		if len(lines) > 0 {
+			description += "\n\nIntake:\n" + strings.Join(lines, "\n")
+		}
+	}
+
+	slotSummary := event.Summary
+
+	if event.ExtendedProperties == nil {
+		event.ExtendedProperties = &calendar.EventExtendedProperties{}
+	}
+	if event.ExtendedProperties.Private == nil {
+		event.ExtendedProperties.Private = make(map[string]string)
+	}
+	for k, v := range claim.Private {
+		event.ExtendedProperties.Private[k] = v
+	}
+	for id, value := range intake {
+		event.ExtendedProperties.Private[IntakeProperty(id)] = value
+	}
+	clearHold(event.ExtendedProperties.Private)
+	event.ExtendedProperties.Private["session_type"] = sessionType.ID
+	// Remember the placeholder's summary (it may carry a session type tag) so
+	// that releasing the slot restores it exactly.
+	event.ExtendedProperties.Private["slot_summary"] = slotSummary
+	// A fresh booking starts a new invitation on this event's own UID. A
+	// reschedule passes the original UID and a bumped sequence through private.
+	// New rules-mode events have no UID yet; readers fall back to the event's.
+	if event.ExtendedProperties.Private["ics_uid"] == "" {
+		event.ExtendedProperties.Private["ics_uid"] = event.ICalUID
+		event.ExtendedProperties.Private["ics_sequence"] = "0"
+	}
+
+	event.Summary = fmt.Sprintf("%s: %s", sessionType.Name, claim.ClientName)
+	event.Description = description
+	// A booked slot is busy time, also for FreeBusy-based availability.
+	event.Transparency = "opaque"
+	// We do not add the client as an attendee directly, as this can require
+	// domain-wide delegation. Instead, we send an .ics attachment in the
+	// confirmation email. We will leave the existing attendees (i.e., the calendar owner) on the event.
+	// event.Attendees = nil
+	// The client's guests, carried in Private, are added as attendees; the
+	// calendar does not email them, the client forwards the invitation.
+	setGuests(event, Guests(event))
+
+	// A booking without a conference link is still a booking; the link can be
+	// sent separately.
+	if err := provider.Attach(event); err != nil {
+		slog.Warn("could not attach video conference, skipping it", "provider", provider.ID(), "error", err)
+	}
+	return nil
+}
+
+// conferenceFor picks the video-conferencing provider of a booking: the one
+// requested, else the session type's, else the configured default.
+func (s *gcalService) conferenceFor(sessionType config.SessionType, requested string) (conferencing.Provider, error) {
+	id := requested
+	if id == "" {
+		id = sessionType.Conferencing
+	}
+	if id == "" {
+		id = s.booking.Conferencing.Default
+	}
+	if id == "" {
+		id = config.ConferencingMeet
+	}
+	provider, ok := conferencing.Lookup(s.booking.Conferencing, id)
+	if !ok {
+		slog.Warn("Booking asks for an unknown conferencing provider", "provider", id)
+		return nil, ErrUnknownConferencing
+	}
+	return provider, nil
+}
+
+// FindBooking looks up a booking by its management token, e.g. for the page
+// where the visitor manages it. The event is returned as stored.
+func (s *gcalService) FindBooking(ctx context.Context, token string) (*calendar.Event, error) {
+	return s.bookingForToken(ctx, token, bookingtoken.ScopeView)
+}
+
+// FeedbackBooking looks up the booking a feedback link was sent for.
+func (s *gcalService) FeedbackBooking(ctx context.Context, token string) (*calendar.Event, error) {
+	return s.bookingForToken(ctx, token, bookingtoken.ScopeFeedback)
+}
+
+// ListBookings lists the booked consultations that start in [from, to),
+// ordered by start time.
+func (s *gcalService) ListBookings(from, to time.Time) ([]*calendar.Event, error) {
+	var bookings []*calendar.Event
+	err := s.calSvc.Events.List(s.calendarID).
+		TimeMin(from.Format(time.RFC3339)).
+		TimeMax(to.Format(time.RFC3339)).
+		SingleEvents(true).
+		OrderBy("startTime").
+		MaxResults(2500).
+		Pages(context.Background(), func(events *calendar.Events) error {
+			for _, event := range events.Items {
+				if event.ExtendedProperties == nil || event.ExtendedProperties.Private["client_email"] == "" {
+					continue
+				}
+				// TimeMin filters on the end time; skip bookings already under way.
+				start, err := time.Parse(time.RFC3339, event.Start.DateTime)
+				if err != nil || start.Before(from) {
+					continue
+				}
+				bookings = append(bookings, event)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve bookings: %w", err)
+	}
+	return bookings, nil
+}
+
+// GetBooking returns the booked event with the given ID, or ErrSlotNotFound
+// if there is no such event or it is not booked.
+func (s *gcalService) GetBooking(ctx context.Context, eventID string) (*calendar.Event, error) {
+	event, err := s.calSvc.Events.Get(s.calendarID, eventID).Context(ctx).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			return nil, ErrSlotNotFound
+		}
+		return nil, fmt.Errorf("unable to retrieve booking with ID %s: %w", eventID, err)
+	}
+	if event.ExtendedProperties == nil || event.ExtendedProperties.Private["client_email"] == "" {
+		return nil, ErrSlotNotFound
+	}
+	return event, nil
+}
+
+// CancelBooking finds an event by its management token and reverts it to an available slot.
 // It returns the original event details for notification purposes.
 func (s *gcalService) CancelBooking(ctx context.Context, token string) (*calendar.Event, error) {
-	slog.Info("Searching for event with cancellation token", "tokenPrefix", token[:8])
-	// 1. Find the event using the private extended property.
+	eventToCancel, err := s.bookingForToken(ctx, token, bookingtoken.ScopeCancel)
+	if err != nil {
+		return nil, err
+	}
+	return s.cancelBooking(eventToCancel, false)
+}
+
+// OverrideCancellation is CancelBooking for the admin, by event ID and without
+// the cancellation cutoff. A late cancellation is recorded on the released
+// slot and flagged on the returned snapshot, so the caller can report it; see
+// LateCancellation.
+func (s *gcalService) OverrideCancellation(ctx context.Context, eventID string) (*calendar.Event, error) {
+	eventToCancel, err := s.GetBooking(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return s.cancelBooking(eventToCancel, true)
+}
+
+func (s *gcalService) cancelBooking(eventToCancel *calendar.Event, override bool) (*calendar.Event, error) {
+	slog.Info("Found event to cancel", "eventID", eventToCancel.Id)
+
+	late := false
+	if err := s.checkCancellation(eventToCancel); err != nil {
+		if !override || !errors.Is(err, ErrCancellationClosed) {
+			return nil, err
+		}
+		late = true
+	}
+
+	// Preserve original details for notifications before modifying.
+	originalEvent := snapshotBooking(eventToCancel)
+
+	if late {
+		// Written in the same update that releases the slot, so the record
+		// exists exactly when the cancellation does.
+		setPrivate(eventToCancel, lateCancelledAtProperty, s.now().UTC().Format(time.RFC3339))
+	}
+	if err := s.releaseSlot(eventToCancel); err != nil {
+		return nil, err
+	}
+	if late {
+		setPrivate(originalEvent, lateCancellationProperty, "overridden")
+		slog.Warn("Late cancellation overridden by the admin", "eventID", originalEvent.Id, "start", originalEvent.Start.DateTime)
+	}
+	return originalEvent, nil
+}
+
+// lateCancellationProperty flags the snapshot returned for a booking that
+// the admin cancelled inside the cancellation cutoff.
+const lateCancellationProperty = "late_cancellation"
+
+// lateCancelledAtProperty records on a released slot when the admin last
+// cancelled its booking inside the cancellation cutoff. It stays on the slot,
+// also if the slot is booked again, as the durable record of the override.
+const lateCancelledAtProperty = "late_cancelled_at"
+
+// LateCancellation reports whether the snapshot returned by
+// OverrideCancellation is of a booking cancelled inside the cutoff.
+func LateCancellation(event *calendar.Event) bool {
+	return event.ExtendedProperties != nil && event.ExtendedProperties.Private[lateCancellationProperty] != ""
+}
+
+// checkCancellation applies the cancellation policy to a booked event. It
+// returns ErrBookingStarted once the booking has started and
+// ErrCancellationClosed inside the cutoff before that.
+func (s *gcalService) checkCancellation(event *calendar.Event) error {
+	start, err := time.Parse(time.RFC3339, event.Start.DateTime)
+	if err != nil {
+		return fmt.Errorf("booking %s has an unparsable start time: %w", event.Id, err)
+	}
+	now := s.now()
+	if !now.Before(start) {
+		slog.Warn("Booking has already started", "eventID", event.Id)
+		return ErrBookingStarted
+	}
+	if !now.Before(s.booking.Cancellation.Deadline(start)) {
+		slog.Warn("Booking is inside the cancellation cutoff", "eventID", event.Id)
+		return ErrCancellationClosed
+	}
+	return nil
+}
+
+// RescheduleBooking moves the booking identified by its management token onto newEventID. The new
+// slot is claimed before the old one is released, so the client never loses their
+// booking: if the new slot is taken, ErrSlotNotFound is returned and nothing changes;
+// if the old slot cannot be released, the new claim is rolled back.
+//
+// The client details, cancellation token and ICS UID are carried over to the new
+// event and the ICS sequence is incremented. It returns a snapshot of the previous
+// booking and the newly booked event.
+func (s *gcalService) RescheduleBooking(ctx context.Context, token, newEventID string) (*calendar.Event, *calendar.Event, error) {
+	current, err := s.bookingForToken(ctx, token, bookingtoken.ScopeReschedule)
+	if err != nil {
+		return nil, nil, err
+	}
+	if current.Id == newEventID {
+		return nil, nil, ErrSlotNotFound
+	}
+	// An occurrence of a series keeps the series' time of day; the client can
+	// only cancel it.
+	if _, ok := SeriesOf(current); ok {
+		return nil, nil, ErrSeriesOccurrence
+	}
+	// Moving a booking gives up its slot, so it is bound by the cancellation policy.
+	if err := s.checkCancellation(current); err != nil {
+		return nil, nil, err
+	}
+	slog.Info("Found event to reschedule", "eventID", current.Id, "newEventID", newEventID)
+
+	private := make(map[string]string, len(current.ExtendedProperties.Private))
+	for k, v := range current.ExtendedProperties.Private {
+		private[k] = v
+	}
+	if private["ics_uid"] == "" {
+		// Bookings made before the UID was recorded were sent with the event's own UID.
+		private["ics_uid"] = current.ICalUID
+	}
+	sequence, _ := strconv.Atoi(private["ics_sequence"])
+	private["ics_sequence"] = strconv.Itoa(sequence + 1)
+	// The booking moves to a new time, so its reminders are due again.
+	clearReminders(private)
+
+	previous := snapshotBooking(current)
+
+	// The new slot must offer the same session type as the booking being moved.
+	// The booking keeps its conferencing provider while that is still configured.
+	provider := private["conference_provider"]
+	if !s.booking.Conferencing.Has(provider) {
+		provider = ""
+	}
+	rescheduled, err := s.bookEvent(newEventID, slotClaim{
+		SessionType:  private["session_type"],
+		ClientName:   private["client_name"],
+		Description:  current.Description,
+		Private:      private,
+		Conferencing: provider,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.releaseSlot(current); err != nil {
+		slog.Error("Failed to release previous slot, rolling back reschedule", "eventID", current.Id, "newEventID", rescheduled.Id, "error", err)
+		if rbErr := s.releaseSlot(rescheduled); rbErr != nil {
+			slog.Error("Failed to roll back rescheduled slot", "eventID", rescheduled.Id, "error", rbErr)
+		}
+		return nil, nil, err
+	}
+	slog.Info("Successfully rescheduled booking", "fromEventID", current.Id, "toEventID", rescheduled.Id)
+
+	return previous, rescheduled, nil
+}
+
+// bookingForToken returns the booked event a management token grants scope on.
+// Signed tokens are verified before the calendar is called, and the event is
+// then read by its ID. Unsigned legacy tokens are searched for as before until
+// the migration ends (config.TokenConfig.LegacyUntil); they only ever managed
+// bookings, so they grant none of the other scopes.
+func (s *gcalService) bookingForToken(ctx context.Context, token string, scope bookingtoken.Scope) (*calendar.Event, error) {
+	if bookingtoken.IsLegacy(token) {
+		if !slices.Contains(bookingtoken.AllScopes, scope) {
+			return nil, ErrSlotNotFound
+		}
+		if until := s.booking.Tokens.LegacyUntil; !until.IsZero() && !s.now().Before(until) {
+			slog.Warn("Rejected legacy booking token after the migration", "tokenPrefix", tokenPrefix(token))
+			return nil, ErrSlotNotFound
+		}
+		return s.findByToken(token)
+	}
+
+	claims, err := s.tokens.Verify(token, scope, s.now())
+	if err != nil {
+		slog.Warn("Rejected booking token", "scope", scope, "error", err)
+		return nil, ErrSlotNotFound
+	}
+	event, err := s.GetBooking(ctx, claims.EventID)
+	if err != nil {
+		return nil, err
+	}
+	// The slot may have been released and booked by someone else since.
+	secret := event.ExtendedProperties.Private["cancellation_token"]
+	if secret == "" || bookingtoken.Binding(secret) != claims.Binding {
+		slog.Warn("Booking token is for an earlier booking of the event", "eventID", event.Id)
+		return nil, ErrSlotNotFound
+	}
+	return event, nil
+}
+
+// findByToken looks up a booked event using the legacy cancellation token
+// stored in its private extended properties.
+func (s *gcalService) findByToken(token string) (*calendar.Event, error) {
+	slog.Info("Searching for event with cancellation token", "tokenPrefix", tokenPrefix(token))
 	query := fmt.Sprintf("cancellation_token=%s", token)
 	events, err := s.calSvc.Events.List(s.calendarID).
 		PrivateExtendedProperty(query).
@@ -228,58 +906,101 @@ func (s *gcalService) CancelBooking(ctx context.Context, token string) (*calenda
 	}
 
 	if len(events.Items) == 0 {
-		slog.Warn("No event found for cancellation token", "tokenPrefix", token[:8])
+		slog.Warn("No event found for cancellation token", "tokenPrefix", tokenPrefix(token))
 		return nil, ErrSlotNotFound // Using existing error for "not found"
 	}
+	return events.Items[0], nil
+}
 
-	eventToCancel := events.Items[0]
-	slog.Info("Found event to cancel", "eventID", eventToCancel.Id)
-
-	// 2. Preserve original details for notifications before modifying.
-	// We retrieve the client details from the private properties we stored during booking.
-	var visitorTZ string
-	if eventToCancel.ExtendedProperties != nil && eventToCancel.ExtendedProperties.Private != nil {
-		visitorTZ = eventToCancel.ExtendedProperties.Private["visitor_timezone"]
-	}
-	originalEvent := &calendar.Event{
-		Id:      eventToCancel.Id,
-		Summary: eventToCancel.Summary,
-		Start:   eventToCancel.Start,
-		End:     eventToCancel.End,
+// snapshotBooking copies the details of a booked event that notifications need.
+// We retrieve the client details from the private properties we stored during booking.
+func snapshotBooking(event *calendar.Event) *calendar.Event {
+	var private map[string]string
+	if event.ExtendedProperties != nil {
+		private = event.ExtendedProperties.Private
+	}
+	return &calendar.Event{
+		Id:      event.Id,
+		Summary: event.Summary,
+		Start:   event.Start,
+		End:     event.End,
 		Attendees: []*calendar.EventAttendee{
 			{
-				DisplayName: eventToCancel.ExtendedProperties.Private["client_name"],
-				Email:       eventToCancel.ExtendedProperties.Private["client_email"],
+				DisplayName: private["client_name"],
+				Email:       private["client_email"],
 			},
 		},
 		ExtendedProperties: &calendar.EventExtendedProperties{
 			Private: map[string]string{
-				"visitor_timezone": visitorTZ,
+				"visitor_timezone": private["visitor_timezone"],
+				"ics_uid":          private["ics_uid"],
+				"ics_sequence":     private["ics_sequence"],
+				"session_type":     private["session_type"],
+				guestsProperty:     private[guestsProperty],
+				seriesIDProperty:   private[seriesIDProperty],
 			},
 		},
 	}
+}
 
-	// 3. Update the event to revert it to an "Available" slot.
-	eventToCancel.Summary = s.availableSlotSummary
-	eventToCancel.Description = "This slot is now available for booking."
+// releaseSlot reverts a booked event to an "Available" slot and persists it.
+func (s *gcalService) releaseSlot(event *calendar.Event) error {
+	event.Summary = s.availableSlotSummary
+	if event.ExtendedProperties != nil && event.ExtendedProperties.Private["slot_summary"] != "" {
+		event.Summary = event.ExtendedProperties.Private["slot_summary"]
+	}
+	if _, ok := parseRuleSlotID(event.Id); ok {
+		// A released rules-mode slot stays behind as a placeholder that does not
+		// block FreeBusy, so the slot is offered again and its ID can be reused.
+		event.Transparency = "transparent"
+	}
+	event.Description = "This slot is now available for booking."
 	// Do not modify the attendees list to avoid permission errors trying to remove the calendar owner.
-	// eventToCancel.Attendees = nil
+	// event.Attendees = nil
+	// Only the client's guests, which the booking added, are taken off again.
+	removeGuests(event)
 	// Conference data modification has been removed to align with the booking logic.
 	// The Meet link, if it was ever created, will remain on the reverted event.
-	// eventToCancel.ConferenceData = nil
-	delete(eventToCancel.ExtendedProperties.Private, "cancellation_token")
-	delete(eventToCancel.ExtendedProperties.Private, "client_name")
-	delete(eventToCancel.ExtendedProperties.Private, "client_email")
-	delete(eventToCancel.ExtendedProperties.Private, "visitor_timezone")
-
-	// 4. Persist the update to Google Calendar.
-	_, err = s.calSvc.Events.Update(s.calendarID, eventToCancel.Id, eventToCancel).Do()
+	// event.ConferenceData = nil
+	if event.ExtendedProperties != nil {
+		for _, key := range []string{"cancellation_token", "client_name", "client_email", "visitor_timezone", "ics_uid", "ics_sequence", "slot_summary"} {
+			delete(event.ExtendedProperties.Private, key)
+		}
+		clearReminders(event.ExtendedProperties.Private)
+		clearIntake(event.ExtendedProperties.Private)
+		clearHold(event.ExtendedProperties.Private)
+		clearSeries(event.ExtendedProperties.Private)
+	}
+	conferencing.Clear(event)
+
+	// Compare-and-swap on the ETag we read, so two concurrent cancellations (or a
+	// cancellation racing a reschedule) cannot both act on the same booking.
+	update := s.calSvc.Events.Update(s.calendarID, event.Id, event)
+	update.Header().Set("If-Match", event.Etag)
+	_, err := update.Do()
 	if err != nil {
-		return nil, fmt.Errorf("failed to update event to available: %w", err)
+		if isConflict(err) {
+			return ErrSlotNotFound
+		}
+		return fmt.Errorf("failed to update event to available: %w", err)
 	}
-	slog.Info("Successfully reverted event to an available slot", "eventID", eventToCancel.Id)
+	slog.Info("Successfully reverted event to an available slot", "eventID", event.Id)
+	return nil
+}
 
-	return originalEvent, nil
+// isConflict reports whether err is a 409 Conflict or 412 Precondition Failed
+// from the Calendar API, i.e. the event changed since we read it.
+func isConflict(err error) bool {
+	var gerr *googleapi.Error
+	return errors.As(err, &gerr) && (gerr.Code == http.StatusConflict || gerr.Code == http.StatusPreconditionFailed)
+}
+
+// tokenPrefix returns a short, log-safe prefix of a token.
+func tokenPrefix(token string) string {
+	if len(token) > 8 {
+		return token[:8]
+	}
+	return token
 }
 
 // Location returns the timezone of the calendar.
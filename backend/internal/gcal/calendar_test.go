@@ -0,0 +1,733 @@
+package gcal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/bookingtoken"
+	"ivmanto.com/backend/internal/conferencing"
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/gcal/gcaltest"
+)
+
+var testSessionTypes = []config.SessionType{
+	{ID: "intro", Name: "Intro Call", DurationMinutes: 30, Price: 0, Currency: "EUR"},
+	{ID: "review", Name: "Architecture Review", DurationMinutes: 90, Price: 450, Currency: "EUR"},
+	{ID: "workshop", Name: "Half-day Workshop", DurationMinutes: 240, Price: 1200, Currency: "EUR"},
+}
+
+func newTestService(t *testing.T, backend *gcaltest.Server) *gcalService {
+	t.Helper()
+	cfg := &config.Config{
+		GCal:    config.GCalConfig{CalendarID: "primary", AvailableSlotSummary: "AfB"},
+		Booking: config.BookingConfig{SessionTypes: testSessionTypes},
+	}
+	s := NewServiceWithClient(backend.CalendarService(t), cfg, time.UTC).(*gcalService)
+	// The fixtures book slots in June 2026; pin the clock before them so the
+	// cancellation policy sees them as upcoming.
+	s.now = func() time.Time { return time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC) }
+	return s
+}
+
+func placeholder(id, summary, start, end string) *calendar.Event {
+	return &calendar.Event{
+		Id:      id,
+		Summary: summary,
+		Start:   &calendar.EventDateTime{DateTime: start},
+		End:     &calendar.EventDateTime{DateTime: end},
+	}
+}
+
+// TestSessionTypeFor covers how placeholders are matched to the catalog:
+// untagged placeholders offer the first (default) type, a summary suffix
+// or a private property selects another, and unknown tags are not bookable.
+func TestSessionTypeFor(t *testing.T) {
+	s := newTestService(t, gcaltest.NewServer(t))
+
+	tagged := placeholder("p", "AfB", "", "")
+	tagged.ExtendedProperties = &calendar.EventExtendedProperties{Private: map[string]string{"session_type": "review"}}
+
+	tests := []struct {
+		name   string
+		event  *calendar.Event
+		wantID string
+		wantOK bool
+	}{
+		{"untagged", placeholder("a", " AfB ", "", ""), "intro", true},
+		{"suffix", placeholder("b", "AfB workshop", "", ""), "workshop", true},
+		{"bracketed suffix", placeholder("c", "AfB [review]", "", ""), "review", true},
+		{"private property", tagged, "review", true},
+		{"unknown tag", placeholder("d", "AfB dinner", "", ""), "", false},
+		{"no separator", placeholder("e", "AfBreview", "", ""), "", false},
+		{"booked event", placeholder("f", "Intro Call: AfB Fan", "", ""), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, ok := s.sessionTypeFor(tt.event)
+			if ok != tt.wantOK || st.ID != tt.wantID {
+				t.Errorf("got (%q, %v), want (%q, %v)", st.ID, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestBookSlot_UsesSessionTypeOfSlot books a workshop placeholder and checks
+// that the type drives the summary and is recorded on the event, and that
+// cancelling restores the tagged placeholder summary.
+func TestBookSlot_UsesSessionTypeOfSlot(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	backend.AddEvent(placeholder("ws1", "AfB workshop", "2026-06-15T09:00:00Z", "2026-06-15T13:00:00Z"))
+	s := newTestService(t, backend)
+
+	if _, err := s.BookSlot(BookingDetails{EventID: "ws1", Name: "Ada", Email: "ada@example.com", SessionType: "intro"}); err != ErrSlotNotFound {
+		t.Fatalf("expected ErrSlotNotFound for a session type mismatch, got %v", err)
+	}
+
+	event, err := s.BookSlot(BookingDetails{EventID: "ws1", Name: "Ada", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	if event.Summary != "Half-day Workshop: Ada" {
+		t.Errorf("unexpected summary %q", event.Summary)
+	}
+	if got := event.ExtendedProperties.Private["session_type"]; got != "workshop" {
+		t.Errorf("expected session_type workshop, got %q", got)
+	}
+
+	if _, err := s.CancelBooking(t.Context(), event.ExtendedProperties.Private["cancellation_token"]); err != nil {
+		t.Fatalf("CancelBooking failed: %v", err)
+	}
+	if got := backend.Event("ws1").Summary; got != "AfB workshop" {
+		t.Errorf("expected the tagged placeholder summary to be restored, got %q", got)
+	}
+}
+
+// TestRulesMode_BookCancelRebook walks a computed slot through its life:
+// it is offered, booking creates an event that blocks FreeBusy, cancelling
+// leaves a transparent placeholder so the slot is offered again, and
+// rebooking claims that placeholder instead of creating a duplicate.
+func TestRulesMode_BookCancelRebook(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	// Someone else's meeting at 10:00-10:30 blocks that slot.
+	backend.AddEvent(placeholder("meeting", "Team sync", "2026-06-15T10:00:00Z", "2026-06-15T10:30:00Z"))
+
+	cfg := &config.Config{
+		GCal: config.GCalConfig{CalendarID: "primary", AvailableSlotSummary: "AfB"},
+		Booking: config.BookingConfig{
+			AvailabilityMode: config.AvailabilityModeRules,
+			Availability: config.AvailabilityRules{
+				WorkingHours: []config.WorkingHours{{Weekday: time.Monday, Start: 9 * time.Hour, End: 11 * time.Hour}},
+				SlotLength:   30 * time.Minute,
+				MaxHorizon:   30 * 24 * time.Hour,
+			},
+		},
+	}
+	s := NewServiceWithClient(backend.CalendarService(t), cfg, time.UTC).(*gcalService)
+	s.now = func() time.Time { return time.Date(2026, 6, 14, 12, 0, 0, 0, time.UTC) }
+	day := time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	ids := func() []string {
+		t.Helper()
+		slots, err := s.GetAvailability(day)
+		if err != nil {
+			t.Fatalf("GetAvailability failed: %v", err)
+		}
+		var out []string
+		for _, e := range slots {
+			out = append(out, e.Start.DateTime[11:16])
+		}
+		return out
+	}
+
+	if got := ids(); len(got) != 3 || got[0] != "09:00" || got[1] != "09:30" || got[2] != "10:30" {
+		t.Fatalf("unexpected initial slots %v", got)
+	}
+
+	slotID := ruleSlotID(day.Add(9 * time.Hour))
+	event, err := s.BookSlot(BookingDetails{EventID: slotID, Name: "Ada", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	if event.Id != slotID || event.Transparency != "opaque" {
+		t.Errorf("expected an opaque event with ID %s, got %s (%s)", slotID, event.Id, event.Transparency)
+	}
+	if got := ids(); len(got) != 2 || got[0] != "09:30" {
+		t.Errorf("expected the booked slot to disappear, got %v", got)
+	}
+	if _, err := s.BookSlot(BookingDetails{EventID: slotID, Name: "Bob", Email: "bob@example.com"}); err != ErrSlotNotFound {
+		t.Errorf("expected ErrSlotNotFound when booking a taken slot, got %v", err)
+	}
+
+	if _, err := s.CancelBooking(t.Context(), event.ExtendedProperties.Private["cancellation_token"]); err != nil {
+		t.Fatalf("CancelBooking failed: %v", err)
+	}
+	if got := ids(); len(got) != 3 || got[0] != "09:00" {
+		t.Errorf("expected the cancelled slot to be offered again, got %v", got)
+	}
+
+	rebooked, err := s.BookSlot(BookingDetails{EventID: slotID, Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("rebooking failed: %v", err)
+	}
+	if rebooked.Summary != "Consultation: Bob" || rebooked.Transparency != "opaque" {
+		t.Errorf("unexpected rebooked event: %q (%s)", rebooked.Summary, rebooked.Transparency)
+	}
+}
+
+// TestConflictCalendars checks that a placeholder clashing with an event on
+// another of the consultant's calendars is hidden, reported and not bookable.
+func TestConflictCalendars(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	backend.AddEvent(placeholder("free", "AfB", "2026-06-15T09:00:00Z", "2026-06-15T09:30:00Z"))
+	backend.AddEvent(placeholder("clash", "AfB", "2026-06-15T10:00:00Z", "2026-06-15T10:30:00Z"))
+	backend.AddEventTo("personal", placeholder("dentist", "Dentist", "2026-06-15T10:15:00Z", "2026-06-15T11:00:00Z"))
+
+	s := newTestService(t, backend)
+	s.conflictCalendarIDs = []string{"personal"}
+	from := time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	slots, err := s.GetAvailabilityRange(from, to)
+	if err != nil {
+		t.Fatalf("GetAvailabilityRange failed: %v", err)
+	}
+	if len(slots) != 1 || slots[0].Id != "free" {
+		t.Fatalf("expected only the free slot, got %d slots", len(slots))
+	}
+	conflicting, err := s.ConflictingSlots(from, to)
+	if err != nil {
+		t.Fatalf("ConflictingSlots failed: %v", err)
+	}
+	if len(conflicting) != 1 || conflicting[0].Id != "clash" {
+		t.Fatalf("expected the clashing slot to be reported, got %d slots", len(conflicting))
+	}
+
+	if _, err := s.BookSlot(BookingDetails{EventID: "clash", Name: "Ada", Email: "ada@example.com"}); err != ErrSlotNotFound {
+		t.Fatalf("expected ErrSlotNotFound for a clashing slot, got %v", err)
+	}
+	if _, err := s.BookSlot(BookingDetails{EventID: "free", Name: "Ada", Email: "ada@example.com"}); err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+}
+
+// TestMarkRemindersSent records a reminder on a booking, rejects a second
+// write with the stale ETag and forgets the reminder when the slot is released.
+func TestMarkRemindersSent(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	backend.AddEvent(placeholder("slot", "AfB", "2026-06-15T09:00:00Z", "2026-06-15T09:30:00Z"))
+	backend.AddEvent(placeholder("other", "AfB", "2026-06-15T10:00:00Z", "2026-06-15T10:30:00Z"))
+	s := newTestService(t, backend)
+	booked, err := s.BookSlot(BookingDetails{EventID: "slot", Name: "Ada", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+
+	from := time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)
+	bookings, err := s.ListBookings(from, from.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("ListBookings failed: %v", err)
+	}
+	if len(bookings) != 1 || bookings[0].Id != "slot" {
+		t.Fatalf("expected only the booked slot, got %d events", len(bookings))
+	}
+
+	staleEtag := bookings[0].Etag
+	if _, err := s.MarkRemindersSent(t.Context(), bookings[0], []time.Duration{24 * time.Hour}); err != nil {
+		t.Fatalf("MarkRemindersSent failed: %v", err)
+	}
+	bookings[0].Etag = staleEtag
+	if _, err := s.MarkRemindersSent(t.Context(), bookings[0], []time.Duration{24 * time.Hour}); err != ErrBookingChanged {
+		t.Fatalf("expected ErrBookingChanged for a stale ETag, got %v", err)
+	}
+	if !ReminderSent(backend.Event("slot"), 24*time.Hour) {
+		t.Fatal("expected the reminder to be recorded on the event")
+	}
+
+	if _, _, err := s.RescheduleBooking(t.Context(), booked.ExtendedProperties.Private["cancellation_token"], "other"); err != nil {
+		t.Fatalf("RescheduleBooking failed: %v", err)
+	}
+	if ReminderSent(backend.Event("other"), 24*time.Hour) || ReminderSent(backend.Event("slot"), 24*time.Hour) {
+		t.Error("expected the reminder markers to be cleared after the reschedule")
+	}
+}
+
+// TestBookSlot_Intake rejects invalid intake answers for the slot's session
+// type and stores valid ones in the description and private properties.
+func TestBookSlot_Intake(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	backend.AddEvent(placeholder("rv1", "AfB review", "2026-06-15T09:00:00Z", "2026-06-15T10:30:00Z"))
+	s := newTestService(t, backend)
+	s.booking.SessionTypes = []config.SessionType{
+		testSessionTypes[0],
+		{ID: "review", Name: "Architecture Review", DurationMinutes: 90, Currency: "EUR", Intake: []config.IntakeField{
+			{ID: "company", Label: "Company", Required: true},
+			{ID: "team_size", Label: "Team size", Type: config.IntakeNumber},
+		}},
+	}
+
+	_, err := s.BookSlot(BookingDetails{EventID: "rv1", Name: "Ada", Email: "ada@example.com", Intake: map[string]string{"team_size": "many"}})
+	fieldErrs, ok := err.(config.IntakeErrors)
+	if !ok || fieldErrs["company"] == "" || fieldErrs["team_size"] == "" {
+		t.Fatalf("expected field errors for company and team_size, got %v", err)
+	}
+
+	event, err := s.BookSlot(BookingDetails{EventID: "rv1", Name: "Ada", Email: "ada@example.com", Intake: map[string]string{"company": "Acme", "team_size": "12"}})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	if !strings.Contains(event.Description, "Intake:\nCompany: Acme\nTeam size: 12") {
+		t.Errorf("expected the answers in the description, got %q", event.Description)
+	}
+	answers := IntakeAnswers(event, s.booking.SessionTypes[1])
+	if len(answers) != 2 || answers[0].Value != "Acme" || answers[1].Value != "12" {
+		t.Errorf("unexpected stored answers %+v", answers)
+	}
+}
+
+// TestHoldSlot holds a placeholder and a rules-mode slot, checks that both are
+// hidden and only bookable with the hold token, and that they are free again
+// once the hold expires.
+func TestHoldSlot(t *testing.T) {
+	for _, mode := range []string{config.AvailabilityModePlaceholder, config.AvailabilityModeRules} {
+		t.Run(mode, func(t *testing.T) {
+			backend := gcaltest.NewServer(t)
+			if mode == config.AvailabilityModePlaceholder {
+				backend.AddEvent(placeholder("slot", "AfB", "2026-06-15T09:00:00Z", "2026-06-15T09:30:00Z"))
+			}
+			s := newTestService(t, backend)
+			s.booking.HoldTTL = 5 * time.Minute
+			s.booking.AvailabilityMode = mode
+			s.booking.Availability = config.AvailabilityRules{
+				WorkingHours: []config.WorkingHours{{Weekday: time.Monday, Start: 9 * time.Hour, End: 9*time.Hour + 30*time.Minute}},
+				SlotLength:   30 * time.Minute,
+				MaxHorizon:   30 * 24 * time.Hour,
+			}
+			now := time.Date(2026, 6, 14, 12, 0, 0, 0, time.UTC)
+			s.now = func() time.Time { return now }
+			day := time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)
+
+			available := func() []*calendar.Event {
+				t.Helper()
+				slots, err := s.GetAvailability(day)
+				if err != nil {
+					t.Fatalf("GetAvailability failed: %v", err)
+				}
+				return slots
+			}
+			slots := available()
+			if len(slots) != 1 {
+				t.Fatalf("expected one slot, got %d", len(slots))
+			}
+			id := slots[0].Id
+
+			token, _, err := s.HoldSlot(t.Context(), id)
+			if err != nil {
+				t.Fatalf("HoldSlot failed: %v", err)
+			}
+			if _, _, err := s.HoldSlot(t.Context(), id); err != ErrSlotNotFound {
+				t.Errorf("expected a second hold to fail, got %v", err)
+			}
+			if got := available(); len(got) != 0 {
+				t.Errorf("expected the held slot to be hidden, got %d slots", len(got))
+			}
+			if _, err := s.BookSlot(BookingDetails{EventID: id, Name: "Bob", Email: "bob@example.com"}); err != ErrSlotNotFound {
+				t.Errorf("expected booking without the hold token to fail, got %v", err)
+			}
+
+			// Once the hold expires the slot is offered and bookable again.
+			now = now.Add(6 * time.Minute)
+			if got := available(); len(got) != 1 {
+				t.Errorf("expected the slot to be offered after the hold expired, got %d slots", len(got))
+			}
+			now = now.Add(-6 * time.Minute)
+
+			event, err := s.BookSlot(BookingDetails{EventID: id, Name: "Ada", Email: "ada@example.com", HoldToken: token})
+			if err != nil {
+				t.Fatalf("BookSlot with the hold token failed: %v", err)
+			}
+			if _, ok := event.ExtendedProperties.Private[holdTokenProperty]; ok {
+				t.Error("expected the hold to be cleared from the booked event")
+			}
+		})
+	}
+}
+
+// TestBookSlot_Conferencing books with each kind of provider: the session
+// type's room, a requested Jitsi room that survives a reschedule, and the
+// Google Meet default. Releasing a slot clears the conference it carried.
+func TestBookSlot_Conferencing(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	backend.AddEvent(placeholder("review1", "AfB review", "2026-06-15T09:00:00Z", "2026-06-15T10:30:00Z"))
+	backend.AddEvent(placeholder("intro1", "AfB", "2026-06-16T09:00:00Z", "2026-06-16T09:30:00Z"))
+	backend.AddEvent(placeholder("intro2", "AfB", "2026-06-17T09:00:00Z", "2026-06-17T09:30:00Z"))
+	s := newTestService(t, backend)
+	s.booking.Conferencing = config.ConferencingConfig{
+		Default:  config.ConferencingMeet,
+		Rooms:    map[string]string{"zoom": "https://zoom.us/j/123"},
+		JitsiURL: "https://meet.example.com",
+	}
+	s.booking.SessionTypes = append([]config.SessionType(nil), testSessionTypes...)
+	s.booking.SessionTypes[1].Conferencing = "zoom"
+
+	review, err := s.BookSlot(BookingDetails{EventID: "review1", Name: "Ada", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	if review.Location != "https://zoom.us/j/123" || conferencing.Link(review) != review.Location || conferencing.Name(review) != "Zoom" {
+		t.Errorf("expected the session type's Zoom room, got location %q, link %q, name %q", review.Location, conferencing.Link(review), conferencing.Name(review))
+	}
+	if review.ConferenceData != nil {
+		t.Error("expected no Google Meet conference for a Zoom booking")
+	}
+
+	if _, err := s.BookSlot(BookingDetails{EventID: "intro1", Name: "Bob", Email: "bob@example.com", Conferencing: "teams"}); err != ErrUnknownConferencing {
+		t.Fatalf("expected ErrUnknownConferencing, got %v", err)
+	}
+	intro, err := s.BookSlot(BookingDetails{EventID: "intro1", Name: "Bob", Email: "bob@example.com", Conferencing: config.ConferencingJitsi})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	room := conferencing.Link(intro)
+	if !strings.HasPrefix(room, "https://meet.example.com/ivmanto-") || intro.Location != room || conferencing.Name(intro) != "Jitsi Meet" {
+		t.Fatalf("expected a Jitsi room, got location %q, link %q", intro.Location, room)
+	}
+
+	_, moved, err := s.RescheduleBooking(t.Context(), intro.ExtendedProperties.Private["cancellation_token"], "intro2")
+	if err != nil {
+		t.Fatalf("RescheduleBooking failed: %v", err)
+	}
+	if got := conferencing.Link(moved); got != room || moved.Location != room {
+		t.Errorf("expected the rescheduled booking to keep room %q, got %q", room, got)
+	}
+	if released := backend.Event("intro1"); released.Location != "" || conferencing.Link(released) != "" {
+		t.Errorf("expected the released slot to lose its room, got location %q", released.Location)
+	}
+
+	if _, err := s.CancelBooking(t.Context(), moved.ExtendedProperties.Private["cancellation_token"]); err != nil {
+		t.Fatalf("CancelBooking failed: %v", err)
+	}
+	meet, err := s.BookSlot(BookingDetails{EventID: "intro2", Name: "Cy", Email: "cy@example.com"})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	if meet.Location != "" || meet.ConferenceData == nil || meet.ConferenceData.CreateRequest == nil {
+		t.Errorf("expected a Google Meet conference request by default, got location %q", meet.Location)
+	}
+}
+
+// TestManagementTokens checks signed tokens against a booking: scope and
+// binding are enforced, a bad token is rejected without calling the calendar,
+// and legacy tokens work until the migration ends.
+func TestManagementTokens(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	backend.AddEvent(placeholder("slot", "AfB", "2026-06-15T09:00:00Z", "2026-06-15T09:30:00Z"))
+	s := newTestService(t, backend)
+	s.tokens = bookingtoken.NewSigner(config.TokenConfig{Keys: []config.TokenKey{{ID: "k1", Secret: strings.Repeat("s", 32)}}})
+
+	event, err := s.BookSlot(BookingDetails{EventID: "slot", Name: "Ada", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	secret := event.ExtendedProperties.Private["cancellation_token"]
+	sign := func(binding string, scopes ...bookingtoken.Scope) string {
+		t.Helper()
+		token, err := s.tokens.Sign(bookingtoken.Claims{EventID: "slot", Binding: binding, Scopes: scopes, Expires: time.Date(2026, 6, 16, 0, 0, 0, 0, time.UTC)})
+		if err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+		return token
+	}
+	viewOnly := sign(bookingtoken.Binding(secret), bookingtoken.ScopeView)
+	full := sign(bookingtoken.Binding(secret), bookingtoken.AllScopes...)
+
+	if found, err := s.FindBooking(t.Context(), viewOnly); err != nil || found.Id != "slot" {
+		t.Fatalf("expected the view token to find the booking, got %v", err)
+	}
+	if _, err := s.CancelBooking(t.Context(), viewOnly); err != ErrSlotNotFound {
+		t.Errorf("expected a view token not to cancel, got %v", err)
+	}
+	if _, err := s.FindBooking(t.Context(), sign(bookingtoken.Binding("other"), bookingtoken.ScopeView)); err != ErrSlotNotFound {
+		t.Errorf("expected a token bound to another booking to be rejected, got %v", err)
+	}
+
+	// The legacy token keeps working until the migration ends.
+	if _, err := s.FindBooking(t.Context(), secret); err != nil {
+		t.Errorf("expected the legacy token to work, got %v", err)
+	}
+	if _, err := s.FeedbackBooking(t.Context(), secret); err != ErrSlotNotFound {
+		t.Errorf("expected the legacy token not to grant feedback, got %v", err)
+	}
+	s.booking.Tokens.LegacyUntil = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := s.FindBooking(t.Context(), secret); err != ErrSlotNotFound {
+		t.Errorf("expected the legacy token to be rejected after the migration, got %v", err)
+	}
+
+	if _, err := s.CancelBooking(t.Context(), full); err != nil {
+		t.Fatalf("CancelBooking failed: %v", err)
+	}
+	if _, err := s.BookSlot(BookingDetails{EventID: "slot", Name: "Bob", Email: "bob@example.com"}); err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	if _, err := s.FindBooking(t.Context(), full); err != ErrSlotNotFound {
+		t.Errorf("expected the token of the cancelled booking not to find the new one, got %v", err)
+	}
+
+	// Invalid and expired tokens are rejected before the calendar is called.
+	backend.Close()
+	s.now = func() time.Time { return time.Date(2026, 6, 16, 0, 0, 0, 0, time.UTC) }
+	for _, token := range []string{full, "v1.k1.e30.c2ln"} {
+		if _, err := s.FindBooking(t.Context(), token); err != ErrSlotNotFound {
+			t.Errorf("expected ErrSlotNotFound without a calendar call, got %v", err)
+		}
+	}
+}
+
+// TestBookSlot_Guests checks that the client's guests become attendees next
+// to the calendar owner, follow a reschedule, and are the only attendees taken
+// off again.
+func TestBookSlot_Guests(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	owner := &calendar.EventAttendee{Email: "owner@example.com", Organizer: true}
+	slot := placeholder("slot1", "AfB", "2026-06-15T09:00:00Z", "2026-06-15T09:30:00Z")
+	slot.Attendees = []*calendar.EventAttendee{owner}
+	backend.AddEvent(slot)
+	other := placeholder("slot2", "AfB", "2026-06-16T09:00:00Z", "2026-06-16T09:30:00Z")
+	other.Attendees = []*calendar.EventAttendee{owner}
+	backend.AddEvent(other)
+	s := newTestService(t, backend)
+
+	attendees := func(event *calendar.Event) []string {
+		var emails []string
+		for _, a := range event.Attendees {
+			emails = append(emails, a.Email)
+		}
+		return emails
+	}
+
+	event, err := s.BookSlot(BookingDetails{EventID: "slot1", Name: "Ada", Email: "ada@example.com", Guests: []string{"bob@example.com", "cy@example.com"}})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	if got := strings.Join(attendees(event), " "); got != "owner@example.com bob@example.com cy@example.com" {
+		t.Errorf("expected the owner and both guests as attendees, got %q", got)
+	}
+	if got := Guests(event); len(got) != 2 || got[0] != "bob@example.com" {
+		t.Errorf("expected the guests on the booking, got %v", got)
+	}
+
+	_, moved, err := s.RescheduleBooking(t.Context(), event.ExtendedProperties.Private["cancellation_token"], "slot2")
+	if err != nil {
+		t.Fatalf("RescheduleBooking failed: %v", err)
+	}
+	if got := strings.Join(attendees(moved), " "); got != "owner@example.com bob@example.com cy@example.com" {
+		t.Errorf("expected the guests to follow the reschedule, got %q", got)
+	}
+	released := backend.Event("slot1")
+	if got := strings.Join(attendees(released), " "); got != "owner@example.com" || len(Guests(released)) != 0 {
+		t.Errorf("expected only the owner on the released slot, got %q", got)
+	}
+
+	updated, err := s.RemoveGuest(t.Context(), "slot2", "BOB@example.com")
+	if err != nil {
+		t.Fatalf("RemoveGuest failed: %v", err)
+	}
+	if got := strings.Join(attendees(updated), " "); got != "owner@example.com cy@example.com" || len(Guests(updated)) != 1 {
+		t.Errorf("expected bob to be removed, got attendees %q", got)
+	}
+	if _, err := s.RemoveGuest(t.Context(), "slot2", "bob@example.com"); err != ErrGuestNotFound {
+		t.Errorf("expected ErrGuestNotFound, got %v", err)
+	}
+}
+
+// TestEraseBooking finds a booking by its client and its guest, erases it, and
+// checks that the erased event keeps its time but is no longer a booking.
+func TestEraseBooking(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	backend.AddEvent(placeholder("past", "AfB review", "2026-05-15T09:00:00Z", "2026-05-15T10:30:00Z"))
+	backend.AddEvent(placeholder("other", "AfB", "2026-05-16T09:00:00Z", "2026-05-16T09:30:00Z"))
+	s := newTestService(t, backend)
+
+	for _, details := range []BookingDetails{
+		{EventID: "past", Name: "Ada", Email: "ada@example.com", Notes: "Call me Ada", Guests: []string{"bob@example.com"}},
+		{EventID: "other", Name: "Cy", Email: "cy@example.com", Guests: []string{"Ada@Example.com"}},
+	} {
+		if _, err := s.BookSlot(details); err != nil {
+			t.Fatalf("BookSlot failed: %v", err)
+		}
+	}
+
+	found, err := s.BookingsOf(t.Context(), "ADA@example.com")
+	if err != nil || len(found) != 2 || found[0].Id != "past" || found[1].Id != "other" {
+		t.Fatalf("expected the booking and the one Ada is a guest of, got %d (%v)", len(found), err)
+	}
+
+	erased, err := s.EraseBooking(t.Context(), found[0])
+	if err != nil {
+		t.Fatalf("EraseBooking failed: %v", err)
+	}
+	if !Erased(erased) || strings.Contains(erased.Summary, "Ada") || strings.Contains(erased.Description, "Ada") || len(erased.Attendees) != 0 {
+		t.Errorf("expected the personal data to be gone, got summary %q, description %q", erased.Summary, erased.Description)
+	}
+	for _, key := range []string{"client_name", "client_email", "cancellation_token", guestsProperty} {
+		if erased.ExtendedProperties.Private[key] != "" {
+			t.Errorf("expected %s to be erased", key)
+		}
+	}
+	if erased.Start.DateTime != "2026-05-15T09:00:00Z" || erased.ExtendedProperties.Private["session_type"] != "review" {
+		t.Errorf("expected the time and session type to be kept, got %s, %q", erased.Start.DateTime, erased.ExtendedProperties.Private["session_type"])
+	}
+	if _, err := s.GetBooking(t.Context(), "past"); err != ErrSlotNotFound {
+		t.Errorf("expected the erased event to no longer be a booking, got %v", err)
+	}
+	if found, _ := s.BookingsOf(t.Context(), "ada@example.com"); len(found) != 1 {
+		t.Errorf("expected only the booking Ada is a guest of to be left, got %d", len(found))
+	}
+}
+
+func TestBookSeries(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	for _, day := range []string{"08", "15", "22", "29"} {
+		backend.AddEvent(placeholder("w"+day, "AfB", "2026-06-"+day+"T09:00:00Z", "2026-06-"+day+"T09:30:00Z"))
+	}
+	backend.AddEvent(placeholder("review", "AfB review", "2026-07-06T09:00:00Z", "2026-07-06T10:30:00Z"))
+	s := newTestService(t, backend)
+	ctx := t.Context()
+	details := BookingDetails{EventID: "w08", Name: "Ada", Email: "ada@example.com"}
+
+	// The slot on 6 July offers another session type, so a fifth week is missing.
+	_, err := s.BookSeries(ctx, details, Recurrence{IntervalWeeks: 1, Count: 5})
+	var unavailable *SeriesUnavailableError
+	if !errors.As(err, &unavailable) || len(unavailable.Starts) != 1 || !unavailable.Starts[0].Equal(time.Date(2026, 7, 6, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected 6 July to be unavailable, got %v", err)
+	}
+	if _, err := s.GetBooking(ctx, "w08"); err != ErrSlotNotFound {
+		t.Errorf("expected nothing to be booked, got %v", err)
+	}
+	if _, err := s.BookSeries(ctx, details, Recurrence{IntervalWeeks: 1, Count: MaxSeriesOccurrences + 1}); err != ErrInvalidRecurrence {
+		t.Errorf("expected too long a series to be rejected, got %v", err)
+	}
+
+	biweekly, err := s.BookSeries(ctx, details, Recurrence{IntervalWeeks: 2, Until: time.Date(2026, 6, 22, 0, 0, 0, 0, time.UTC)})
+	if err != nil || len(biweekly) != 2 || biweekly[1].Id != "w22" {
+		t.Fatalf("expected 8 and 22 June to be booked, got %d (%v)", len(biweekly), err)
+	}
+	series, ok := SeriesOf(biweekly[1])
+	if !ok || series.IntervalWeeks != 2 || series.Count != 2 || !series.Start.Equal(time.Date(2026, 6, 8, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected series %+v", series)
+	}
+	first, second := biweekly[0].ExtendedProperties.Private, biweekly[1].ExtendedProperties.Private
+	if first["ics_uid"] != second["ics_uid"] || first["cancellation_token"] == second["cancellation_token"] {
+		t.Errorf("expected a shared UID and separate tokens, got %v and %v", first, second)
+	}
+
+	// 8 June has started; the occurrence on 22 June is cancelled on its own
+	// with the first occurrence's token.
+	s.now = func() time.Time { return time.Date(2026, 6, 8, 9, 10, 0, 0, time.UTC) }
+	token := first["cancellation_token"]
+	if _, err := s.CancelOccurrence(ctx, token, "w15"); err != ErrSlotNotFound {
+		t.Errorf("expected a slot outside the series to be refused, got %v", err)
+	}
+	if _, err := s.CancelOccurrence(ctx, token, "w22"); err != nil {
+		t.Fatalf("CancelOccurrence failed: %v", err)
+	}
+	if occurrences, _ := s.SeriesBookings(ctx, series.ID); len(occurrences) != 1 || occurrences[0].Id != "w08" {
+		t.Errorf("expected only the first occurrence to be left, got %d", len(occurrences))
+	}
+	if _, _, err := s.CancelSeries(ctx, token); err != ErrBookingStarted {
+		t.Errorf("expected nothing left to cancel, got %v", err)
+	}
+}
+
+func TestCancelSeries(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	for _, day := range []string{"08", "15", "22"} {
+		backend.AddEvent(placeholder("w"+day, "AfB", "2026-06-"+day+"T09:00:00Z", "2026-06-"+day+"T09:30:00Z"))
+	}
+	s := newTestService(t, backend)
+	ctx := t.Context()
+	booked, err := s.BookSeries(ctx, BookingDetails{EventID: "w08", Name: "Ada", Email: "ada@example.com"}, Recurrence{IntervalWeeks: 1, Count: 3})
+	if err != nil {
+		t.Fatalf("BookSeries failed: %v", err)
+	}
+	token := booked[2].ExtendedProperties.Private["cancellation_token"]
+	if _, _, err := s.RescheduleBooking(ctx, token, "w15"); err != ErrSeriesOccurrence {
+		t.Errorf("expected an occurrence not to be rescheduled, got %v", err)
+	}
+
+	s.now = func() time.Time { return time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC) }
+	cancelled, kept, err := s.CancelSeries(ctx, token)
+	if err != nil || len(cancelled) != 2 || len(kept) != 1 || kept[0].Id != "w08" {
+		t.Fatalf("expected the two upcoming occurrences to be cancelled, got %d cancelled, %d kept (%v)", len(cancelled), len(kept), err)
+	}
+	if private := backend.Event("w15").ExtendedProperties.Private; private[seriesIDProperty] != "" || private["client_email"] != "" {
+		t.Errorf("expected the slot to be released, got %v", private)
+	}
+	if _, ok := SeriesOf(cancelled[0]); !ok {
+		t.Error("expected the snapshot to name the series")
+	}
+}
+
+// TestRescheduleBooking moves a booking onto another slot, carrying the client
+// and the ICS identity over and releasing the old slot.
+func TestRescheduleBooking(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	backend.AddEvent(placeholder("slot1", "AfB", "2026-06-15T09:00:00Z", "2026-06-15T09:30:00Z"))
+	backend.AddEvent(placeholder("slot2", "AfB", "2026-06-16T09:00:00Z", "2026-06-16T09:30:00Z"))
+	s := newTestService(t, backend)
+
+	booked, err := s.BookSlot(BookingDetails{EventID: "slot1", Name: "Ada", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	token := booked.ExtendedProperties.Private["cancellation_token"]
+	previous, moved, err := s.RescheduleBooking(t.Context(), token, "slot2")
+	if err != nil {
+		t.Fatalf("RescheduleBooking failed: %v", err)
+	}
+	if previous.Id != "slot1" || moved.Id != "slot2" {
+		t.Fatalf("expected slot1 to move to slot2, got %s to %s", previous.Id, moved.Id)
+	}
+	private := backend.Event("slot2").ExtendedProperties.Private
+	if private["client_email"] != "ada@example.com" || private["cancellation_token"] != token || private["ics_uid"] != booked.ExtendedProperties.Private["ics_uid"] || private["ics_sequence"] != "1" {
+		t.Errorf("expected the booking to move with its token and ICS UID, got %v", private)
+	}
+	released := backend.Event("slot1")
+	if released.Summary != "AfB" || released.ExtendedProperties.Private["client_email"] != "" {
+		t.Errorf("expected the old slot to be released, got %q with %v", released.Summary, released.ExtendedProperties.Private)
+	}
+}
+
+// TestRescheduleBooking_RollsBack changes the old slot while the booking is
+// being moved, so releasing it fails with 412 Precondition Failed, and checks
+// that the new slot is released again and the old booking is left intact.
+func TestRescheduleBooking_RollsBack(t *testing.T) {
+	backend := gcaltest.NewServer(t)
+	backend.AddEvent(placeholder("slot1", "AfB", "2026-06-15T09:00:00Z", "2026-06-15T09:30:00Z"))
+	backend.AddEvent(placeholder("slot2", "AfB", "2026-06-16T09:00:00Z", "2026-06-16T09:30:00Z"))
+	s := newTestService(t, backend)
+
+	booked, err := s.BookSlot(BookingDetails{EventID: "slot1", Name: "Ada", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	token := booked.ExtendedProperties.Private["cancellation_token"]
+	backend.TouchBeforeNextUpdate("slot1")
+	if _, _, err := s.RescheduleBooking(t.Context(), token, "slot2"); err != ErrSlotNotFound {
+		t.Fatalf("expected ErrSlotNotFound, got %v", err)
+	}
+
+	released := backend.Event("slot2")
+	if released.Summary != "AfB" || released.ExtendedProperties.Private["client_email"] != "" || released.ExtendedProperties.Private["cancellation_token"] != "" {
+		t.Errorf("expected the new slot to be released again, got %q with %v", released.Summary, released.ExtendedProperties.Private)
+	}
+	kept := backend.Event("slot1")
+	if kept.Summary == "AfB" || kept.ExtendedProperties.Private["client_email"] != "ada@example.com" || kept.ExtendedProperties.Private["cancellation_token"] != token {
+		t.Errorf("expected the old booking to be intact, got %q with %v", kept.Summary, kept.ExtendedProperties.Private)
+	}
+	if slots, err := s.GetAvailability(time.Date(2026, 6, 16, 0, 0, 0, 0, time.UTC)); err != nil || len(slots) != 1 || slots[0].Id != "slot2" {
+		t.Errorf("expected the new slot to be offered again, got %d slots (%v)", len(slots), err)
+	}
+}
@@ -0,0 +1,88 @@
+package gcal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// erasedProperty records when the personal data of a past booking was erased.
+// The event stays in the calendar as busy time with its session type, but is
+// no longer a booking: ListBookings and GetBooking skip it.
+const erasedProperty = "erased_at"
+
+// Erased reports whether the personal data of event was erased by
+// EraseBooking.
+func Erased(event *calendar.Event) bool {
+	return event.ExtendedProperties != nil && event.ExtendedProperties.Private[erasedProperty] != ""
+}
+
+// BookingsOf returns the booked events, past and upcoming, whose client or one
+// of whose guests has the given email address, ordered by start time. Email
+// addresses are compared case-insensitively, so the whole calendar is read
+// rather than filtered by the Calendar API; this is meant for the rare
+// erasure request, not for regular lookups.
+func (s *gcalService) BookingsOf(ctx context.Context, email string) ([]*calendar.Event, error) {
+	var bookings []*calendar.Event
+	err := s.calSvc.Events.List(s.calendarID).
+		SingleEvents(true).
+		OrderBy("startTime").
+		MaxResults(2500).
+		Pages(ctx, func(events *calendar.Events) error {
+			for _, event := range events.Items {
+				if event.ExtendedProperties == nil || event.ExtendedProperties.Private["client_email"] == "" {
+					continue
+				}
+				if strings.EqualFold(event.ExtendedProperties.Private["client_email"], email) ||
+					slices.ContainsFunc(Guests(event), func(g string) bool { return strings.EqualFold(g, email) }) {
+					bookings = append(bookings, event)
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("unable to search bookings: %w", err)
+	}
+	return bookings, nil
+}
+
+// EraseBooking removes the personal data of the client and their guests from
+// a past booked event: the name, email address, notes, intake answers,
+// feedback comment and guests, and the management token. The times, session
+// type, conference and feedback rating are kept, so the event still blocks
+// its slot and counts in statistics. Like the other updates it is a
+// compare-and-swap; a booking that changed since it was read returns
+// ErrBookingChanged.
+func (s *gcalService) EraseBooking(ctx context.Context, event *calendar.Event) (*calendar.Event, error) {
+	sessionType, ok := s.booking.SessionType(event.ExtendedProperties.Private["session_type"])
+	if !ok {
+		sessionType, _ = s.booking.SessionType("")
+	}
+	erasedAt := s.now().UTC()
+
+	removeGuests(event)
+	for _, key := range []string{"cancellation_token", "client_name", "client_email", "visitor_timezone", feedbackCommentProperty} {
+		delete(event.ExtendedProperties.Private, key)
+	}
+	clearIntake(event.ExtendedProperties.Private)
+	setPrivate(event, erasedProperty, erasedAt.Format(time.RFC3339))
+	event.Summary = sessionType.Name + ": personal data erased"
+	event.Description = fmt.Sprintf("The personal data of this booking was erased on %s.", erasedAt.Format("2006-01-02"))
+
+	update := s.calSvc.Events.Update(s.calendarID, event.Id, event).Context(ctx)
+	update.Header().Set("If-Match", event.Etag)
+	updated, err := update.Do()
+	if err != nil {
+		if isConflict(err) {
+			return nil, ErrBookingChanged
+		}
+		return nil, fmt.Errorf("failed to erase booking: %w", err)
+	}
+	slog.Info("Erased personal data of booking", "eventID", event.Id)
+	return updated, nil
+}
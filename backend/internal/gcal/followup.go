@@ -0,0 +1,131 @@
+package gcal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// Private extended properties recording what happened after a consultation.
+const (
+	// followUpProperty records when the thank-you email with the feedback
+	// link was sent.
+	followUpProperty = "followup_sent"
+	// noShowProperty records when the admin flagged that the client did not
+	// attend.
+	noShowProperty = "no_show"
+	// feedbackRatingProperty, feedbackCommentProperty and feedbackAtProperty
+	// hold the feedback the client gave through the follow-up email's link.
+	feedbackRatingProperty  = "feedback_rating"
+	feedbackCommentProperty = "feedback_comment"
+	feedbackAtProperty      = "feedback_at"
+)
+
+// Feedback is what a client said about a consultation after it took place.
+type Feedback struct {
+	Rating int
+	// Comment is at most MaxPropertyValue characters long. It is removed
+	// when the booking is erased; the rating is kept.
+	Comment     string
+	SubmittedAt time.Time
+}
+
+// FollowUpSent reports whether the follow-up email was already recorded on
+// event by MarkFollowUpSent.
+func FollowUpSent(event *calendar.Event) bool {
+	return event.ExtendedProperties != nil && event.ExtendedProperties.Private[followUpProperty] != ""
+}
+
+// NoShow reports whether the admin flagged the client of event as a no-show.
+func NoShow(event *calendar.Event) bool {
+	return event.ExtendedProperties != nil && event.ExtendedProperties.Private[noShowProperty] != ""
+}
+
+// FeedbackOf returns the feedback recorded on event by RecordFeedback, and
+// whether there is any.
+func FeedbackOf(event *calendar.Event) (Feedback, bool) {
+	if event.ExtendedProperties == nil || event.ExtendedProperties.Private[feedbackRatingProperty] == "" {
+		return Feedback{}, false
+	}
+	private := event.ExtendedProperties.Private
+	rating, _ := strconv.Atoi(private[feedbackRatingProperty])
+	submittedAt, _ := time.Parse(time.RFC3339, private[feedbackAtProperty])
+	return Feedback{Rating: rating, Comment: private[feedbackCommentProperty], SubmittedAt: submittedAt}, true
+}
+
+// MarkFollowUpSent records on event that the follow-up email was sent. Like
+// MarkRemindersSent it is a compare-and-swap on the event's ETag, so of several
+// instances racing for the same follow-up only one wins; the others get
+// ErrBookingChanged and must not send it.
+func (s *gcalService) MarkFollowUpSent(ctx context.Context, event *calendar.Event) (*calendar.Event, error) {
+	setPrivate(event, followUpProperty, s.now().UTC().Format(time.RFC3339))
+
+	update := s.calSvc.Events.Update(s.calendarID, event.Id, event).Context(ctx)
+	update.Header().Set("If-Match", event.Etag)
+	updated, err := update.Do()
+	if err != nil {
+		if isConflict(err) {
+			return nil, ErrBookingChanged
+		}
+		return nil, fmt.Errorf("failed to record sent follow-up: %w", err)
+	}
+	slog.Info("Recorded sent follow-up", "eventID", event.Id)
+	return updated, nil
+}
+
+// SetNoShow flags or unflags the client of the booked event eventID as a
+// no-show. Clients flagged as no-shows get no follow-up email.
+func (s *gcalService) SetNoShow(ctx context.Context, eventID string, noShow bool) (*calendar.Event, error) {
+	event, err := s.GetBooking(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if noShow {
+		setPrivate(event, noShowProperty, s.now().UTC().Format(time.RFC3339))
+	} else {
+		delete(event.ExtendedProperties.Private, noShowProperty)
+	}
+
+	update := s.calSvc.Events.Update(s.calendarID, event.Id, event).Context(ctx)
+	update.Header().Set("If-Match", event.Etag)
+	updated, err := update.Do()
+	if err != nil {
+		if isConflict(err) {
+			return nil, ErrBookingChanged
+		}
+		return nil, fmt.Errorf("failed to record no-show: %w", err)
+	}
+	slog.Info("Recorded no-show", "eventID", event.Id, "noShow", noShow)
+	return updated, nil
+}
+
+// RecordFeedback records fb on the booked event, or returns ErrFeedbackExists
+// if the client gave feedback on it already. Like MarkFollowUpSent it is a
+// compare-and-swap on the event's ETag, so of two submissions racing for the
+// same booking only one is recorded; the other gets ErrBookingChanged.
+func (s *gcalService) RecordFeedback(ctx context.Context, event *calendar.Event, fb Feedback) (*calendar.Event, error) {
+	if _, ok := FeedbackOf(event); ok {
+		return nil, ErrFeedbackExists
+	}
+	setPrivate(event, feedbackRatingProperty, strconv.Itoa(fb.Rating))
+	if fb.Comment != "" {
+		setPrivate(event, feedbackCommentProperty, fb.Comment)
+	}
+	setPrivate(event, feedbackAtProperty, fb.SubmittedAt.UTC().Format(time.RFC3339))
+
+	update := s.calSvc.Events.Update(s.calendarID, event.Id, event).Context(ctx)
+	update.Header().Set("If-Match", event.Etag)
+	updated, err := update.Do()
+	if err != nil {
+		if isConflict(err) {
+			return nil, ErrBookingChanged
+		}
+		return nil, fmt.Errorf("failed to record feedback: %w", err)
+	}
+	slog.Info("Recorded feedback", "eventID", event.Id, "rating", fb.Rating)
+	return updated, nil
+}
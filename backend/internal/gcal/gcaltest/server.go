@@ -0,0 +1,97 @@
+// Package gcaltest provides a fake of the Google Calendar REST API for tests.
+// It serves a memcal.Backend over HTTP, so the Calendar client goes through
+// the same request encoding as against the real API. The helpers without a
+// calendar argument use DefaultCalendar.
+package gcaltest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+	"ivmanto.com/backend/internal/gcal/memcal"
+)
+
+// DefaultCalendar is the calendar ID used by AddEvent and Event.
+const DefaultCalendar = "primary"
+
+// Server is a fake Calendar API backend holding events in memory.
+type Server struct {
+	*httptest.Server
+	backend *memcal.Backend
+
+	mu      sync.Mutex
+	touched map[string]bool // events to change before their next update
+}
+
+// NewServer starts a fake Calendar backend. It is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	backend, err := memcal.New("")
+	if err != nil {
+		t.Fatalf("could not create fake calendar backend: %v", err)
+	}
+	s := &Server{backend: backend, touched: make(map[string]bool)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /calendars/{calendarId}/events/{eventId}", s.handleUpdate)
+	mux.Handle("/", backend)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// handleUpdate changes the event's ETag first if TouchBeforeNextUpdate asked
+// for it, so an update conditional on the ETag the client read fails.
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	calendarID, id := r.PathValue("calendarId"), r.PathValue("eventId")
+	s.mu.Lock()
+	touch := s.touched[id]
+	delete(s.touched, id)
+	s.mu.Unlock()
+	if event := s.backend.Event(calendarID, id); touch && event != nil {
+		s.AddEventTo(calendarID, event)
+	}
+	s.backend.ServeHTTP(w, r)
+}
+
+// TouchBeforeNextUpdate simulates a concurrent edit of the event with the given
+// ID: just before the next update of the event, the event is stored again
+// unchanged with a fresh ETag. An update sent with If-Match then fails with
+// 412 Precondition Failed.
+func (s *Server) TouchBeforeNextUpdate(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.touched[id] = true
+}
+
+// CalendarService returns a Calendar API client that talks to the fake backend.
+func (s *Server) CalendarService(t testing.TB) *calendar.Service {
+	srv, err := calendar.NewService(context.Background(),
+		option.WithEndpoint(s.URL+"/"),
+		option.WithHTTPClient(s.Server.Client()),
+		option.WithoutAuthentication(),
+	)
+	if err != nil {
+		t.Fatalf("could not create calendar client for fake backend: %v", err)
+	}
+	return srv
+}
+
+// AddEvent stores a copy of event in DefaultCalendar, assigning it a fresh ETag.
+func (s *Server) AddEvent(event *calendar.Event) {
+	s.AddEventTo(DefaultCalendar, event)
+}
+
+// AddEventTo stores a copy of event in the given calendar.
+func (s *Server) AddEventTo(calendarID string, event *calendar.Event) {
+	// An in-memory backend has no file to fail writing to.
+	_ = s.backend.AddEvent(calendarID, event)
+}
+
+// Event returns a copy of the event with the given ID in DefaultCalendar, or nil.
+func (s *Server) Event(id string) *calendar.Event {
+	return s.backend.Event(DefaultCalendar, id)
+}
@@ -0,0 +1,77 @@
+package gcal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// guestsProperty records the guests the client invited, comma-separated. The
+// guests are also attendees of the event; the property tells them apart from
+// attendees that were on the slot before, e.g. the calendar owner, so that
+// only the guests are carried over on a reschedule and taken off on release.
+const guestsProperty = "guest_emails"
+
+// Guests returns the guests the client invited to the booked event.
+func Guests(event *calendar.Event) []string {
+	if event.ExtendedProperties == nil || event.ExtendedProperties.Private[guestsProperty] == "" {
+		return nil
+	}
+	return strings.Split(event.ExtendedProperties.Private[guestsProperty], ",")
+}
+
+// setGuests records guests on event and makes them its attendees, replacing
+// the guests recorded before.
+func setGuests(event *calendar.Event, guests []string) {
+	removeGuests(event)
+	if len(guests) == 0 {
+		return
+	}
+	setPrivate(event, guestsProperty, strings.Join(guests, ","))
+	for _, guest := range guests {
+		event.Attendees = append(event.Attendees, &calendar.EventAttendee{Email: guest, ResponseStatus: "needsAction"})
+	}
+}
+
+// removeGuests takes the recorded guests off the attendees of event.
+func removeGuests(event *calendar.Event) {
+	guests := Guests(event)
+	if len(guests) == 0 {
+		return
+	}
+	event.Attendees = slices.DeleteFunc(event.Attendees, func(a *calendar.EventAttendee) bool {
+		return slices.ContainsFunc(guests, func(g string) bool { return strings.EqualFold(g, a.Email) })
+	})
+	delete(event.ExtendedProperties.Private, guestsProperty)
+}
+
+// RemoveGuest takes guest off the booked event eventID, or returns
+// ErrGuestNotFound if they are not one of its guests.
+func (s *gcalService) RemoveGuest(ctx context.Context, eventID, guest string) (*calendar.Event, error) {
+	event, err := s.GetBooking(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	guests := Guests(event)
+	i := slices.IndexFunc(guests, func(g string) bool { return strings.EqualFold(g, guest) })
+	if i < 0 {
+		return nil, ErrGuestNotFound
+	}
+	setGuests(event, slices.Delete(guests, i, i+1))
+
+	update := s.calSvc.Events.Update(s.calendarID, event.Id, event).Context(ctx)
+	update.Header().Set("If-Match", event.Etag)
+	updated, err := update.Do()
+	if err != nil {
+		if isConflict(err) {
+			return nil, ErrBookingChanged
+		}
+		return nil, fmt.Errorf("failed to remove guest: %w", err)
+	}
+	slog.Info("Removed guest from booking", "eventID", event.Id)
+	return updated, nil
+}
@@ -0,0 +1,200 @@
+package gcal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Private extended properties of a held slot. A hold is active until
+// holdExpiresProperty; after that the slot is free again without any cleanup.
+// holdMarkerProperty lets held rules-mode slots be found with one List query.
+const (
+	holdTokenProperty   = "hold_token"
+	holdExpiresProperty = "hold_expires"
+	holdMarkerProperty  = "held"
+)
+
+// activeHold returns the token of the hold on event, if it has one that has
+// not expired yet.
+func (s *gcalService) activeHold(event *calendar.Event) (string, bool) {
+	if event.ExtendedProperties == nil {
+		return "", false
+	}
+	private := event.ExtendedProperties.Private
+	expires, err := time.Parse(time.RFC3339, private[holdExpiresProperty])
+	if err != nil || !s.now().Before(expires) {
+		return "", false
+	}
+	return private[holdTokenProperty], true
+}
+
+// clearHold removes the hold properties from private.
+func clearHold(private map[string]string) {
+	delete(private, holdTokenProperty)
+	delete(private, holdExpiresProperty)
+	delete(private, holdMarkerProperty)
+}
+
+// HoldSlot reserves an available slot for the configured hold TTL, so the
+// visitor can fill in the booking form without losing it. While the hold is
+// active the slot is left out of GetAvailabilityRange and BookSlot only books
+// it with the returned token. Expired holds need no release; they are ignored.
+func (s *gcalService) HoldSlot(ctx context.Context, eventID string) (string, time.Time, error) {
+	token, err := uuid.NewRandom()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not generate hold token: %w", err)
+	}
+	expires := s.now().Add(s.booking.HoldTTL).UTC()
+	hold := map[string]string{
+		holdTokenProperty:   token.String(),
+		holdExpiresProperty: expires.Format(time.RFC3339),
+		holdMarkerProperty:  "true",
+	}
+
+	if s.rulesMode() {
+		if _, ok := parseRuleSlotID(eventID); ok {
+			err = s.holdRuleSlot(ctx, eventID, hold)
+			return token.String(), expires, err
+		}
+	}
+	err = s.holdPlaceholder(ctx, eventID, hold)
+	return token.String(), expires, err
+}
+
+// holdPlaceholder writes hold onto an existing "Available" event with a
+// compare-and-swap on its ETag.
+func (s *gcalService) holdPlaceholder(ctx context.Context, eventID string, hold map[string]string) error {
+	event, err := s.calSvc.Events.Get(s.calendarID, eventID).Context(ctx).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			return ErrSlotNotFound
+		}
+		return fmt.Errorf("unable to retrieve event to hold with ID %s: %w", eventID, err)
+	}
+	if _, ok := s.sessionTypeFor(event); !ok {
+		return ErrSlotNotFound
+	}
+	if _, held := s.activeHold(event); held {
+		slog.Info("Slot is already held", "eventID", eventID)
+		return ErrSlotNotFound
+	}
+	if _, conflicting, err := s.splitConflicts([]*calendar.Event{event}, 0, 0); err != nil {
+		return err
+	} else if len(conflicting) > 0 {
+		return ErrSlotNotFound
+	}
+
+	for k, v := range hold {
+		setPrivate(event, k, v)
+	}
+	update := s.calSvc.Events.Update(s.calendarID, event.Id, event).Context(ctx)
+	update.Header().Set("If-Match", event.Etag)
+	if _, err := update.Do(); err != nil {
+		if isConflict(err) {
+			return ErrSlotNotFound
+		}
+		return fmt.Errorf("failed to hold event: %w", err)
+	}
+	slog.Info("Slot held", "eventID", eventID, "expires", hold[holdExpiresProperty])
+	return nil
+}
+
+// ReleaseHold ends the hold with token on eventID before it expires, so the
+// slot is offered again. It does nothing if the slot is no longer held with
+// token, e.g. because the hold expired or the slot was booked with it.
+func (s *gcalService) ReleaseHold(ctx context.Context, eventID, token string) error {
+	event, err := s.calSvc.Events.Get(s.calendarID, eventID).Context(ctx).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("unable to retrieve held event with ID %s: %w", eventID, err)
+	}
+	if held, ok := s.activeHold(event); !ok || held != token {
+		return nil
+	}
+
+	clearHold(event.ExtendedProperties.Private)
+	update := s.calSvc.Events.Update(s.calendarID, event.Id, event).Context(ctx)
+	update.Header().Set("If-Match", event.Etag)
+	if _, err := update.Do(); err != nil {
+		if isConflict(err) {
+			return ErrBookingChanged
+		}
+		return fmt.Errorf("failed to release hold: %w", err)
+	}
+	slog.Info("Hold released", "eventID", eventID)
+	return nil
+}
+
+// holdRuleSlot holds a computed rules-mode slot. The slot has no event yet, so
+// it is created as a transparent placeholder with the slot's deterministic ID,
+// the state a released rules-mode booking is left in. If the ID already
+// exists, that placeholder is held like any other.
+func (s *gcalService) holdRuleSlot(ctx context.Context, eventID string, hold map[string]string) error {
+	start, _ := parseRuleSlotID(eventID)
+	slots, _, err := s.availableSlots(start, start.Add(time.Second))
+	if err != nil {
+		return err
+	}
+	if len(slots) == 0 || slots[0].Id != eventID {
+		return ErrSlotNotFound
+	}
+
+	event := slots[0]
+	event.Transparency = "transparent"
+	event.Description = "This slot is now available for booking."
+	for k, v := range hold {
+		setPrivate(event, k, v)
+	}
+	if _, err := s.calSvc.Events.Insert(s.calendarID, event).Context(ctx).Do(); err != nil {
+		if isConflict(err) {
+			return s.holdPlaceholder(ctx, eventID, hold)
+		}
+		return fmt.Errorf("failed to create held event: %w", err)
+	}
+	slog.Info("Rules-mode slot held", "eventID", eventID, "expires", hold[holdExpiresProperty])
+	return nil
+}
+
+// withoutHolds drops the slots in [from, to) that are held. Placeholder slots
+// carry their hold themselves; computed rules-mode slots are matched against
+// the held events found with one List query.
+func (s *gcalService) withoutHolds(slots []*calendar.Event, from, to time.Time) ([]*calendar.Event, error) {
+	held := make(map[string]bool)
+	if s.rulesMode() && len(slots) > 0 {
+		err := s.calSvc.Events.List(s.calendarID).
+			TimeMin(from.Format(time.RFC3339)).
+			TimeMax(to.Format(time.RFC3339)).
+			PrivateExtendedProperty(holdMarkerProperty+"=true").
+			SingleEvents(true).
+			MaxResults(2500).
+			Pages(context.Background(), func(events *calendar.Events) error {
+				for _, event := range events.Items {
+					if _, ok := s.activeHold(event); ok {
+						held[event.Id] = true
+					}
+				}
+				return nil
+			})
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve held slots: %w", err)
+		}
+	}
+
+	var free []*calendar.Event
+	for _, slot := range slots {
+		if _, ok := s.activeHold(slot); ok || held[slot.Id] {
+			continue
+		}
+		free = append(free, slot)
+	}
+	return free, nil
+}
@@ -0,0 +1,49 @@
+package gcal
+
+import (
+	"strings"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/config"
+)
+
+// intakePropertyPrefix starts the private extended properties that hold the
+// answers to a booking's intake questionnaire, e.g. "intake_company".
+const intakePropertyPrefix = "intake_"
+
+// IntakeProperty is the private extended property holding the answer to the
+// intake field with the given ID.
+func IntakeProperty(fieldID string) string {
+	return intakePropertyPrefix + fieldID
+}
+
+// IntakeAnswer is the answer to one intake question of a booking.
+type IntakeAnswer struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// IntakeAnswers returns the intake answers stored on a booked event, in the
+// order of the questionnaire of sessionType. Unanswered questions are left out.
+func IntakeAnswers(event *calendar.Event, sessionType config.SessionType) []IntakeAnswer {
+	if event.ExtendedProperties == nil {
+		return nil
+	}
+	var answers []IntakeAnswer
+	for _, field := range sessionType.Intake {
+		if value := event.ExtendedProperties.Private[IntakeProperty(field.ID)]; value != "" {
+			answers = append(answers, IntakeAnswer{ID: field.ID, Label: field.Label, Value: value})
+		}
+	}
+	return answers
+}
+
+// clearIntake removes the intake answers from private when a slot is released.
+func clearIntake(private map[string]string) {
+	for key := range private {
+		if strings.HasPrefix(key, intakePropertyPrefix) {
+			delete(private, key)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+// Package memcal is an in-memory fake of the Google Calendar REST API. It
+// implements just enough of the events resource for gcal.Service: get, list,
+// insert and update, with ETag/If-Match preconditions enforced the way the
+// real API does, plus FreeBusy queries. Events are kept per calendar ID and,
+// when a file is given, saved to it as JSON after every change.
+//
+// gcal.NewMemoryService runs the real gcal.Service on top of a Backend for
+// offline development; package gcaltest serves one over HTTP for tests.
+package memcal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+	"ivmanto.com/backend/internal/jsonfile"
+)
+
+// Backend is a fake Calendar API backend holding events in memory.
+type Backend struct {
+	path string
+	mux  *http.ServeMux
+
+	mu      sync.Mutex
+	events  map[string]map[string]*calendar.Event // calendar ID -> event ID -> event
+	version int
+}
+
+// New creates a Backend. If path is set, the events it holds are loaded as
+// the initial state and every change is saved back to it. The file maps
+// calendar IDs to lists of events in the Calendar API's JSON format; events
+// without an ID or ETag get one when they are loaded. An empty path keeps the
+// events in memory only.
+func New(path string) (*Backend, error) {
+	b := &Backend{path: path, events: make(map[string]map[string]*calendar.Event)}
+	b.mux = http.NewServeMux()
+	b.mux.HandleFunc("GET /calendars/{calendarId}/events", b.handleList)
+	b.mux.HandleFunc("POST /calendars/{calendarId}/events", b.handleInsert)
+	b.mux.HandleFunc("GET /calendars/{calendarId}/events/{eventId}", b.handleGet)
+	b.mux.HandleFunc("PUT /calendars/{calendarId}/events/{eventId}", b.handleUpdate)
+	b.mux.HandleFunc("POST /freeBusy", b.handleFreeBusy)
+
+	if path == "" {
+		return b, nil
+	}
+	var stored map[string][]*calendar.Event
+	if err := jsonfile.Load(path, &stored); err != nil {
+		return nil, fmt.Errorf("loading calendar: %w", err)
+	}
+	for calendarID, events := range stored {
+		for _, event := range events {
+			b.put(calendarID, event)
+		}
+	}
+	return b, nil
+}
+
+// ServeHTTP serves the Calendar API requests.
+func (b *Backend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mux.ServeHTTP(w, r)
+}
+
+// CalendarService returns a Calendar API client that calls the backend in
+// process, without opening a port.
+func (b *Backend) CalendarService(ctx context.Context) (*calendar.Service, error) {
+	return calendar.NewService(ctx,
+		option.WithEndpoint("http://memcal/"),
+		option.WithHTTPClient(&http.Client{Transport: handlerTransport{b}}),
+		option.WithoutAuthentication(),
+	)
+}
+
+// handlerTransport is an http.RoundTripper that answers requests with a handler.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}
+
+// AddEvent stores a copy of event in the given calendar, assigning it a fresh ETag.
+func (b *Backend) AddEvent(calendarID string, event *calendar.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.put(calendarID, clone(event))
+	return b.persist()
+}
+
+// Event returns a copy of the event with the given ID, or nil.
+func (b *Backend) Event(calendarID, id string) *calendar.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if e, ok := b.events[calendarID][id]; ok {
+		return clone(e)
+	}
+	return nil
+}
+
+// Len returns the number of events in the given calendar.
+func (b *Backend) Len(calendarID string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.events[calendarID])
+}
+
+// put stores event under a new ETag. The caller must hold b.mu.
+func (b *Backend) put(calendarID string, event *calendar.Event) {
+	b.version++
+	event.Etag = fmt.Sprintf(`"%d"`, b.version)
+	if event.Id == "" {
+		event.Id = fmt.Sprintf("evt%d", b.version)
+	}
+	if event.ICalUID == "" {
+		event.ICalUID = event.Id + "@google.com"
+	}
+	if b.events[calendarID] == nil {
+		b.events[calendarID] = make(map[string]*calendar.Event)
+	}
+	b.events[calendarID][event.Id] = event
+}
+
+// persist writes all events to the file. The caller must hold b.mu.
+func (b *Backend) persist() error {
+	if b.path == "" {
+		return nil
+	}
+	stored := make(map[string][]*calendar.Event, len(b.events))
+	for calendarID, events := range b.events {
+		items := make([]*calendar.Event, 0, len(events))
+		for _, event := range events {
+			items = append(items, event)
+		}
+		sortByStart(items)
+		stored[calendarID] = items
+	}
+	if err := jsonfile.Save(b.path, stored); err != nil {
+		return fmt.Errorf("saving calendar: %w", err)
+	}
+	return nil
+}
+
+func (b *Backend) handleGet(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	event, ok := b.events[r.PathValue("calendarId")][r.PathValue("eventId")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, event)
+}
+
+func (b *Backend) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	var event calendar.Event
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request")
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	calendarID, id := r.PathValue("calendarId"), r.PathValue("eventId")
+	current, ok := b.events[calendarID][id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if match := r.Header.Get("If-Match"); match != "" && match != current.Etag {
+		writeError(w, http.StatusPreconditionFailed, "Precondition Failed")
+		return
+	}
+
+	event.Id = id
+	event.ICalUID = current.ICalUID
+	addConference(&event)
+	b.put(calendarID, &event)
+	if err := b.persist(); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, &event)
+}
+
+func (b *Backend) handleInsert(w http.ResponseWriter, r *http.Request) {
+	var event calendar.Event
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request")
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	calendarID := r.PathValue("calendarId")
+	if _, exists := b.events[calendarID][event.Id]; exists && event.Id != "" {
+		// Like the real API, a client-chosen ID can only be used once.
+		writeError(w, http.StatusConflict, "The requested identifier already exists.")
+		return
+	}
+	addConference(&event)
+	b.put(calendarID, &event)
+	if err := b.persist(); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, &event)
+}
+
+// addConference fills in a fake Meet link when the event requests one.
+func addConference(event *calendar.Event) {
+	if event.ConferenceData != nil && event.ConferenceData.CreateRequest != nil {
+		link := "https://meet.google.com/fake-" + event.Id
+		event.HangoutLink = link
+		event.ConferenceData.EntryPoints = []*calendar.EntryPoint{{EntryPointType: "video", Uri: link}}
+	}
+}
+
+// handleFreeBusy reports every non-transparent event of the requested
+// calendars that overlaps the query window as busy.
+func (b *Backend) handleFreeBusy(w http.ResponseWriter, r *http.Request) {
+	var req calendar.FreeBusyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request")
+		return
+	}
+	timeMin, _ := time.Parse(time.RFC3339, req.TimeMin)
+	timeMax, _ := time.Parse(time.RFC3339, req.TimeMax)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	resp := &calendar.FreeBusyResponse{Calendars: make(map[string]calendar.FreeBusyCalendar)}
+	for _, item := range req.Items {
+		busy := []*calendar.TimePeriod{}
+		for _, event := range b.events[item.Id] {
+			if event.Transparency == "transparent" || event.Status == "cancelled" || event.Start == nil || event.End == nil {
+				continue
+			}
+			start, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+			end, _ := time.Parse(time.RFC3339, event.End.DateTime)
+			if start.Before(timeMax) && end.After(timeMin) {
+				busy = append(busy, &calendar.TimePeriod{Start: event.Start.DateTime, End: event.End.DateTime})
+			}
+		}
+		resp.Calendars[item.Id] = calendar.FreeBusyCalendar{Busy: busy}
+	}
+	writeJSON(w, resp)
+}
+
+func (b *Backend) handleList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	timeMin, _ := time.Parse(time.RFC3339, query.Get("timeMin"))
+	timeMax, _ := time.Parse(time.RFC3339, query.Get("timeMax"))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	items := []*calendar.Event{}
+	for _, event := range b.events[r.PathValue("calendarId")] {
+		if q := query.Get("q"); q != "" && !strings.Contains(event.Summary, q) {
+			continue
+		}
+		if !matchesPrivateProperties(event, query["privateExtendedProperty"]) {
+			continue
+		}
+		if event.Start != nil && event.End != nil {
+			start, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+			end, _ := time.Parse(time.RFC3339, event.End.DateTime)
+			if !timeMin.IsZero() && !end.After(timeMin) {
+				continue
+			}
+			if !timeMax.IsZero() && !start.Before(timeMax) {
+				continue
+			}
+		}
+		items = append(items, event)
+	}
+	sortByStart(items)
+	writeJSON(w, &calendar.Events{Items: items})
+}
+
+func matchesPrivateProperties(event *calendar.Event, filters []string) bool {
+	for _, filter := range filters {
+		key, value, _ := strings.Cut(filter, "=")
+		if event.ExtendedProperties == nil || event.ExtendedProperties.Private[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func sortByStart(items []*calendar.Event) {
+	start := func(e *calendar.Event) string {
+		if e.Start == nil {
+			return ""
+		}
+		t, _ := time.Parse(time.RFC3339, e.Start.DateTime)
+		return t.UTC().Format(time.RFC3339)
+	}
+	sort.Slice(items, func(i, j int) bool { return start(items[i]) < start(items[j]) })
+}
+
+func clone(event *calendar.Event) *calendar.Event {
+	data, _ := json.Marshal(event)
+	var out calendar.Event
+	_ = json.Unmarshal(data, &out)
+	return &out
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{"code": code, "message": message},
+	})
+}
@@ -0,0 +1,77 @@
+package gcal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/gcal/memcal"
+)
+
+// memorySeedDays and memorySeedHours describe the "Available" slots a new
+// memory calendar starts with: every weekday of the next two weeks at these
+// wall-clock hours of the calendar's timezone.
+const memorySeedDays = 14
+
+var memorySeedHours = []int{10, 11, 14, 15}
+
+// NewMemoryService creates a Service for local development that needs no
+// Google credentials. It runs the regular booking logic against an in-memory
+// calendar (see package memcal) in cfg.GCal.MemoryTimezone, so holds,
+// bookings, cancellations and reschedules behave as they do in production.
+//
+// If cfg.GCal.MemoryFile is set, the calendar is loaded from and saved to that
+// JSON file. In placeholder mode, a calendar without any events is seeded with
+// "Available" slots for the next two weeks.
+func NewMemoryService(ctx context.Context, cfg *config.Config) (Service, error) {
+	loc, err := time.LoadLocation(cfg.GCal.MemoryTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("could not load timezone %q for the memory calendar: %w", cfg.GCal.MemoryTimezone, err)
+	}
+	backend, err := memcal.New(cfg.GCal.MemoryFile)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Booking.AvailabilityMode != config.AvailabilityModeRules && backend.Len(cfg.GCal.CalendarID) == 0 {
+		if err := seedPlaceholders(backend, cfg, loc, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	srv, err := backend.CalendarService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create client for the memory calendar: %w", err)
+	}
+	slog.Info("Using the in-memory booking calendar", "file", cfg.GCal.MemoryFile, "timezone", loc.String())
+	return NewServiceWithClient(srv, cfg, loc), nil
+}
+
+// seedPlaceholders adds "Available" slots of the default session type to the
+// booking calendar, starting the day after now.
+func seedPlaceholders(backend *memcal.Backend, cfg *config.Config, loc *time.Location, now time.Time) error {
+	sessionType, _ := cfg.Booking.SessionType("")
+	length := time.Duration(sessionType.DurationMinutes) * time.Minute
+	today := now.In(loc)
+	for d := 1; d <= memorySeedDays; d++ {
+		day := time.Date(today.Year(), today.Month(), today.Day()+d, 0, 0, 0, 0, loc)
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		for _, hour := range memorySeedHours {
+			start := time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, loc)
+			err := backend.AddEvent(cfg.GCal.CalendarID, &calendar.Event{
+				Summary:      cfg.GCal.AvailableSlotSummary,
+				Start:        &calendar.EventDateTime{DateTime: start.Format(time.RFC3339), TimeZone: loc.String()},
+				End:          &calendar.EventDateTime{DateTime: start.Add(length).Format(time.RFC3339), TimeZone: loc.String()},
+				Transparency: "transparent",
+			})
+			if err != nil {
+				return fmt.Errorf("could not seed the memory calendar: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package gcal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ivmanto.com/backend/internal/config"
+)
+
+// TestMemoryService_BookingSurvivesRestart seeds a memory calendar backed by
+// a file, books and cancels a slot across two service instances, and checks
+// that the seeded slots follow the calendar's timezone.
+func TestMemoryService_BookingSurvivesRestart(t *testing.T) {
+	cfg := &config.Config{
+		GCal: config.GCalConfig{
+			CalendarID:           "primary",
+			AvailableSlotSummary: "AfB",
+			Backend:              config.GCalBackendMemory,
+			MemoryFile:           filepath.Join(t.TempDir(), "calendar.json"),
+			MemoryTimezone:       "Europe/Berlin",
+		},
+		Booking: config.BookingConfig{SessionTypes: testSessionTypes},
+	}
+	svc, err := NewMemoryService(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("NewMemoryService failed: %v", err)
+	}
+	loc := svc.Location()
+	from := time.Now().In(loc)
+	to := from.AddDate(0, 0, memorySeedDays+1)
+
+	slots, err := svc.GetAvailabilityRange(from, to)
+	if err != nil {
+		t.Fatalf("GetAvailabilityRange failed: %v", err)
+	}
+	if len(slots) < 8*len(memorySeedHours) {
+		t.Fatalf("expected the seeded weekday slots, got %d", len(slots))
+	}
+	start, _ := time.Parse(time.RFC3339, slots[0].Start.DateTime)
+	if start.In(loc).Hour() != memorySeedHours[0] {
+		t.Errorf("expected the first slot at %d:00 %s, got %v", memorySeedHours[0], loc, start.In(loc))
+	}
+
+	booked, err := svc.BookSlot(BookingDetails{EventID: slots[0].Id, Name: "Ada", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("BookSlot failed: %v", err)
+	}
+	if booked.HangoutLink == "" {
+		t.Error("expected the booking to get a Meet link")
+	}
+	token := booked.ExtendedProperties.Private["cancellation_token"]
+
+	// A restarted service loads the booking from the file instead of seeding again.
+	svc, err = NewMemoryService(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("reopening the memory calendar failed: %v", err)
+	}
+	if _, err := svc.BookSlot(BookingDetails{EventID: slots[0].Id, Name: "Bob", Email: "bob@example.com"}); err != ErrSlotNotFound {
+		t.Errorf("expected the booked slot to stay taken, got %v", err)
+	}
+	if remaining, _ := svc.GetAvailabilityRange(from, to); len(remaining) != len(slots)-1 {
+		t.Errorf("expected %d slots after the booking, got %d", len(slots)-1, len(remaining))
+	}
+	if _, err := svc.CancelBooking(t.Context(), token); err != nil {
+		t.Fatalf("CancelBooking failed: %v", err)
+	}
+	if remaining, _ := svc.GetAvailabilityRange(from, to); len(remaining) != len(slots) {
+		t.Errorf("expected the cancelled slot to be available again, got %d of %d slots", len(remaining), len(slots))
+	}
+}
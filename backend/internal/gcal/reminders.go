@@ -0,0 +1,60 @@
+package gcal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// reminderPropertyPrefix starts the private extended properties that record
+// which reminder emails were sent for a booking, e.g. "reminder_1440m".
+const reminderPropertyPrefix = "reminder_"
+
+// ReminderProperty is the private extended property recording the reminder
+// sent offset before the start of a booking.
+func ReminderProperty(offset time.Duration) string {
+	return fmt.Sprintf("%s%dm", reminderPropertyPrefix, int(offset/time.Minute))
+}
+
+// ReminderSent reports whether the reminder for offset was already recorded
+// on event by MarkRemindersSent.
+func ReminderSent(event *calendar.Event, offset time.Duration) bool {
+	return event.ExtendedProperties != nil && event.ExtendedProperties.Private[ReminderProperty(offset)] != ""
+}
+
+// clearReminders removes the sent-reminder markers from private, so that a
+// released or moved booking gets its reminders again.
+func clearReminders(private map[string]string) {
+	for key := range private {
+		if strings.HasPrefix(key, reminderPropertyPrefix) {
+			delete(private, key)
+		}
+	}
+}
+
+// MarkRemindersSent records on event that the reminders for offsets were sent.
+// The update is a compare-and-swap on the ETag the event was read with, so when
+// several instances race for the same reminder only one of them wins; the
+// others get ErrBookingChanged and must not send it.
+func (s *gcalService) MarkRemindersSent(ctx context.Context, event *calendar.Event, offsets []time.Duration) (*calendar.Event, error) {
+	sentAt := s.now().UTC().Format(time.RFC3339)
+	for _, offset := range offsets {
+		setPrivate(event, ReminderProperty(offset), sentAt)
+	}
+
+	update := s.calSvc.Events.Update(s.calendarID, event.Id, event).Context(ctx)
+	update.Header().Set("If-Match", event.Etag)
+	updated, err := update.Do()
+	if err != nil {
+		if isConflict(err) {
+			return nil, ErrBookingChanged
+		}
+		return nil, fmt.Errorf("failed to record sent reminders: %w", err)
+	}
+	slog.Info("Recorded sent reminders", "eventID", event.Id, "count", len(offsets))
+	return updated, nil
+}
@@ -0,0 +1,148 @@
+package gcal
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"ivmanto.com/backend/internal/availability"
+	"ivmanto.com/backend/internal/config"
+)
+
+// ruleSlotPrefix marks the IDs of slots computed in rules mode. The rest of the
+// ID is the slot's start as a Unix timestamp, so the ID is deterministic and can
+// be used as the Calendar event ID: the API only accepts one event per ID, which
+// makes creating the booking race-safe. Event IDs may only contain the
+// base32hex characters a-v and 0-9.
+const ruleSlotPrefix = "ivm"
+
+func ruleSlotID(start time.Time) string {
+	return ruleSlotPrefix + strconv.FormatInt(start.Unix(), 10)
+}
+
+// parseRuleSlotID returns the start time encoded in a rules-mode slot ID.
+func parseRuleSlotID(id string) (time.Time, bool) {
+	if !strings.HasPrefix(id, ruleSlotPrefix) {
+		return time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(id[len(ruleSlotPrefix):], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
+
+func (s *gcalService) rulesMode() bool {
+	return s.booking.AvailabilityMode == config.AvailabilityModeRules
+}
+
+// busyIntervals returns the busy time of the given calendars in [from, to),
+// as reported by a single FreeBusy query.
+func (s *gcalService) busyIntervals(from, to time.Time, calendarIDs []string) ([]availability.Interval, error) {
+	req := &calendar.FreeBusyRequest{
+		TimeMin: from.Format(time.RFC3339),
+		TimeMax: to.Format(time.RFC3339),
+	}
+	for _, id := range calendarIDs {
+		req.Items = append(req.Items, &calendar.FreeBusyRequestItem{Id: id})
+	}
+
+	resp, err := s.calSvc.Freebusy.Query(req).Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to query free/busy information: %w", err)
+	}
+
+	var busy []availability.Interval
+	for id, cal := range resp.Calendars {
+		for _, e := range cal.Errors {
+			// An unreadable calendar must not silently look free.
+			return nil, fmt.Errorf("free/busy query failed for calendar %s: %s", id, e.Reason)
+		}
+		for _, period := range cal.Busy {
+			start, err1 := time.Parse(time.RFC3339, period.Start)
+			end, err2 := time.Parse(time.RFC3339, period.End)
+			if err1 != nil || err2 != nil {
+				slog.Warn("Skipping unparsable busy period", "calendar", id, "start", period.Start, "end", period.End)
+				continue
+			}
+			busy = append(busy, availability.Interval{Start: start, End: end})
+		}
+	}
+	return busy, nil
+}
+
+// ruleSlots computes the bookable slots that start in [from, to) from the
+// configured availability rules and the booking calendar's busy time. The
+// slots are returned as unsaved placeholder events offering the default
+// session type, with IDs from ruleSlotID.
+func (s *gcalService) ruleSlots(from, to time.Time) ([]*calendar.Event, error) {
+	rules := s.booking.Availability
+	// Widen the FreeBusy window so busy time just outside the range still
+	// counts against the buffers of the first and last slot. Conflict calendars
+	// are checked separately so that the slots they hide can be reported.
+	busy, err := s.busyIntervals(from.Add(-rules.BufferBefore), to.Add(rules.SlotLength+rules.BufferAfter), []string{s.calendarID})
+	if err != nil {
+		return nil, err
+	}
+
+	sessionType, _ := s.booking.SessionType("")
+	var events []*calendar.Event
+	for _, slot := range availability.Slots(rules, s.location, from, to, s.now(), busy) {
+		events = append(events, &calendar.Event{
+			Id:      ruleSlotID(slot.Start),
+			Summary: s.availableSlotSummary,
+			Start:   &calendar.EventDateTime{DateTime: slot.Start.In(s.location).Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: slot.End.In(s.location).Format(time.RFC3339)},
+			ExtendedProperties: &calendar.EventExtendedProperties{
+				Private: map[string]string{"session_type": sessionType.ID},
+			},
+		})
+	}
+	return events, nil
+}
+
+// bookRuleSlot books a rules-mode slot. The slot is re-checked against the
+// rules and FreeBusy (including the conflict calendars), then created as a new event with the slot's deterministic
+// ID. If that ID already exists, the slot was booked and released before and
+// remains as a transparent placeholder, which is claimed like any other.
+func (s *gcalService) bookRuleSlot(eventID string, claim slotClaim) (*calendar.Event, error) {
+	start, _ := parseRuleSlotID(eventID)
+	slots, _, err := s.availableSlots(start, start.Add(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	if len(slots) == 0 || slots[0].Id != eventID {
+		slog.Warn("Rules-mode slot is not bookable", "eventID", eventID)
+		return nil, ErrSlotNotFound
+	}
+	slot := slots[0]
+
+	sessionType, _ := s.booking.SessionType("")
+	if claim.SessionType != "" && claim.SessionType != sessionType.ID {
+		slog.Error("Slot offers a different session type", "eventID", eventID, "sessionType", sessionType.ID, "requested", claim.SessionType)
+		return nil, ErrSlotNotFound
+	}
+
+	provider, err := s.conferenceFor(sessionType, claim.Conferencing)
+	if err != nil {
+		return nil, err
+	}
+	event := &calendar.Event{Id: eventID, Start: slot.Start, End: slot.End}
+	if err := prepareBooking(event, sessionType, claim, provider); err != nil {
+		return nil, err
+	}
+
+	created, err := s.calSvc.Events.Insert(s.calendarID, event).ConferenceDataVersion(1).Do()
+	if err != nil {
+		if isConflict(err) {
+			slog.Info("Rules-mode slot event already exists, claiming it", "eventID", eventID)
+			return s.claimSlot(eventID, claim)
+		}
+		return nil, fmt.Errorf("failed to create event during booking: %w", err)
+	}
+	slog.Info("Successfully created event for rules-mode booking", "eventID", created.Id)
+	return created, nil
+}
@@ -0,0 +1,352 @@
+package gcal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+	"ivmanto.com/backend/internal/bookingtoken"
+)
+
+// MaxSeriesOccurrences caps the number of bookings in a recurring series, so
+// a single request cannot block the calendar for months.
+const MaxSeriesOccurrences = 12
+
+// Private extended properties shared by the occurrences of a recurring series.
+// They also share the ICS UID, so the client's calendar shows one recurring
+// event.
+const (
+	seriesIDProperty       = "series_id"
+	seriesIntervalProperty = "series_interval" // weeks between occurrences
+	seriesCountProperty    = "series_count"    // occurrences booked, cancelled ones included
+	seriesStartProperty    = "series_start"    // start of the first occurrence, RFC 3339
+)
+
+// Recurrence is the rule of a recurring series of bookings: every
+// IntervalWeeks weeks at the time of day of the first slot, in the calendar's
+// timezone, either Count times or up to and including the day of Until.
+// Exactly one of Count and Until is set.
+type Recurrence struct {
+	IntervalWeeks int // 1 for weekly, 2 for biweekly
+	Count         int
+	Until         time.Time
+}
+
+// starts returns the starts of the occurrences of r beginning at first, or
+// ErrInvalidRecurrence if r does not describe 2 to MaxSeriesOccurrences of
+// them. Occurrences keep the wall-clock time of first across DST changes.
+func (r Recurrence) starts(first time.Time) ([]time.Time, error) {
+	if r.IntervalWeeks < 1 || (r.Count == 0) == r.Until.IsZero() {
+		return nil, ErrInvalidRecurrence
+	}
+	var starts []time.Time
+	for i := 0; r.Count == 0 || i < r.Count; i++ {
+		start := first.AddDate(0, 0, 7*r.IntervalWeeks*i)
+		if r.Count == 0 && !start.Before(r.Until.AddDate(0, 0, 1)) {
+			break
+		}
+		if len(starts) == MaxSeriesOccurrences {
+			return nil, ErrInvalidRecurrence
+		}
+		starts = append(starts, start)
+	}
+	if len(starts) < 2 {
+		return nil, ErrInvalidRecurrence
+	}
+	return starts, nil
+}
+
+// SeriesUnavailableError is returned by BookSeries when some occurrences of
+// the series cannot be booked. Nothing is booked then.
+type SeriesUnavailableError struct {
+	Starts []time.Time // the starts of the occurrences that are not available
+}
+
+func (e *SeriesUnavailableError) Error() string {
+	return fmt.Sprintf("%d occurrence(s) of the series are not available", len(e.Starts))
+}
+
+// Unwrap makes a SeriesUnavailableError match ErrSlotNotFound.
+func (e *SeriesUnavailableError) Unwrap() error {
+	return ErrSlotNotFound
+}
+
+// Series describes the recurring series a booked event belongs to.
+type Series struct {
+	ID            string
+	IntervalWeeks int
+	Count         int       // occurrences booked, cancelled ones included
+	Start         time.Time // start of the first occurrence
+}
+
+// SeriesOf returns the series event is an occurrence of, if any.
+func SeriesOf(event *calendar.Event) (Series, bool) {
+	if event.ExtendedProperties == nil || event.ExtendedProperties.Private[seriesIDProperty] == "" {
+		return Series{}, false
+	}
+	private := event.ExtendedProperties.Private
+	series := Series{ID: private[seriesIDProperty]}
+	series.IntervalWeeks, _ = strconv.Atoi(private[seriesIntervalProperty])
+	series.Count, _ = strconv.Atoi(private[seriesCountProperty])
+	series.Start, _ = time.Parse(time.RFC3339, private[seriesStartProperty])
+	return series, true
+}
+
+// Starts returns the starts of all occurrences of the series, cancelled ones
+// included. loc is the calendar's timezone, in which the occurrences keep
+// their time of day.
+func (s Series) Starts(loc *time.Location) []time.Time {
+	starts := make([]time.Time, s.Count)
+	for i := range starts {
+		starts[i] = s.Start.In(loc).AddDate(0, 0, 7*s.IntervalWeeks*i)
+	}
+	return starts
+}
+
+// clearSeries removes the series properties from a released slot.
+func clearSeries(private map[string]string) {
+	for _, key := range []string{seriesIDProperty, seriesIntervalProperty, seriesCountProperty, seriesStartProperty} {
+		delete(private, key)
+	}
+}
+
+// BookSeries books the slot details.EventID and the available slots that
+// start at the same time of day every rule.IntervalWeeks weeks after it. All
+// slots must offer the session type of the first one. Every occurrence is
+// checked before the first is booked; if one is not available, or is taken
+// while the series is being booked, the occurrences booked so far are
+// released again and a *SeriesUnavailableError names the missing ones.
+//
+// Each occurrence is a booking of its own, with its own management token and
+// reminders, and shares the series properties and ICS UID with the others.
+func (s *gcalService) BookSeries(ctx context.Context, details BookingDetails, rule Recurrence) ([]*calendar.Event, error) {
+	eventIDs, starts, sessionType, err := s.seriesSlots(ctx, details.EventID, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	seriesID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate series ID: %w", err)
+	}
+	series := map[string]string{
+		seriesIDProperty:       seriesID.String(),
+		seriesIntervalProperty: strconv.Itoa(rule.IntervalWeeks),
+		seriesCountProperty:    strconv.Itoa(len(starts)),
+		seriesStartProperty:    starts[0].Format(time.RFC3339),
+		"ics_uid":              seriesID.String() + "@ivmanto.com",
+		"ics_sequence":         "0",
+	}
+
+	var booked []*calendar.Event
+	for i, eventID := range eventIDs {
+		occurrence := details
+		occurrence.EventID = eventID
+		if i > 0 {
+			// The hold, if any, is on the first slot only.
+			occurrence.HoldToken = ""
+			occurrence.SessionType = sessionType
+		}
+		claim := newClaim(occurrence)
+		for k, v := range series {
+			claim.Private[k] = v
+		}
+		event, err := s.bookEvent(eventID, claim)
+		if err != nil {
+			s.releaseSeries(booked)
+			if i > 0 && errors.Is(err, ErrSlotNotFound) {
+				return nil, &SeriesUnavailableError{Starts: []time.Time{starts[i]}}
+			}
+			return nil, err
+		}
+		booked = append(booked, event)
+	}
+	slog.Info("Successfully booked series", "seriesID", seriesID.String(), "occurrences", len(booked))
+	return booked, nil
+}
+
+// SeriesEventIDs returns the IDs of the slots BookSeries would book for
+// eventID and rule, first eventID itself, or a *SeriesUnavailableError naming
+// the occurrences that are not available. Nothing is booked or held.
+func (s *gcalService) SeriesEventIDs(ctx context.Context, eventID string, rule Recurrence) ([]string, error) {
+	eventIDs, _, _, err := s.seriesSlots(ctx, eventID, rule)
+	return eventIDs, err
+}
+
+// seriesSlots finds the available slots of the occurrences of rule starting
+// with the slot eventID, with their starts and the session type they share.
+func (s *gcalService) seriesSlots(ctx context.Context, eventID string, rule Recurrence) ([]string, []time.Time, string, error) {
+	first, sessionType, err := s.slotToBook(ctx, eventID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	starts, err := rule.starts(first.In(s.location))
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	slots, err := s.GetAvailabilityRange(starts[1], starts[len(starts)-1].Add(time.Second))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	byStart := make(map[int64]*calendar.Event, len(slots))
+	for _, slot := range slots {
+		if slot.ExtendedProperties.Private["session_type"] != sessionType {
+			continue
+		}
+		if start, err := time.Parse(time.RFC3339, slot.Start.DateTime); err == nil {
+			byStart[start.Unix()] = slot
+		}
+	}
+	eventIDs := []string{eventID}
+	var missing []time.Time
+	for _, start := range starts[1:] {
+		slot, ok := byStart[start.Unix()]
+		if !ok {
+			missing = append(missing, start)
+			continue
+		}
+		eventIDs = append(eventIDs, slot.Id)
+	}
+	if len(missing) > 0 {
+		slog.Warn("Series is not available", "eventID", eventID, "missing", len(missing))
+		return nil, nil, "", &SeriesUnavailableError{Starts: missing}
+	}
+	return eventIDs, starts, sessionType, nil
+}
+
+// slotToBook returns the start and session type of the slot eventID, without
+// checking that it is still available; booking it does.
+func (s *gcalService) slotToBook(ctx context.Context, eventID string) (time.Time, string, error) {
+	if s.rulesMode() {
+		if start, ok := parseRuleSlotID(eventID); ok {
+			sessionType, _ := s.booking.SessionType("")
+			return start, sessionType.ID, nil
+		}
+	}
+	event, err := s.calSvc.Events.Get(s.calendarID, eventID).Context(ctx).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			return time.Time{}, "", ErrSlotNotFound
+		}
+		return time.Time{}, "", fmt.Errorf("unable to retrieve event to book with ID %s: %w", eventID, err)
+	}
+	sessionType, ok := s.sessionTypeFor(event)
+	if !ok {
+		return time.Time{}, "", ErrSlotNotFound
+	}
+	start, err := time.Parse(time.RFC3339, event.Start.DateTime)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("slot %s has an unparsable start time: %w", eventID, err)
+	}
+	return start, sessionType.ID, nil
+}
+
+// releaseSeries rolls back the occurrences booked by a failed BookSeries.
+func (s *gcalService) releaseSeries(booked []*calendar.Event) {
+	for _, event := range booked {
+		if err := s.releaseSlot(event); err != nil {
+			slog.Error("Failed to roll back occurrence of series", "eventID", event.Id, "error", err)
+		}
+	}
+}
+
+// SeriesBookings lists the booked occurrences of the series seriesID. Cancelled
+// occurrences were released and no longer carry the series ID.
+func (s *gcalService) SeriesBookings(ctx context.Context, seriesID string) ([]*calendar.Event, error) {
+	var bookings []*calendar.Event
+	err := s.calSvc.Events.List(s.calendarID).
+		PrivateExtendedProperty(seriesIDProperty+"="+seriesID).
+		SingleEvents(true).
+		OrderBy("startTime").
+		MaxResults(2500).
+		Pages(ctx, func(events *calendar.Events) error {
+			for _, event := range events.Items {
+				if event.ExtendedProperties.Private["client_email"] != "" {
+					bookings = append(bookings, event)
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list occurrences of series: %w", err)
+	}
+	return bookings, nil
+}
+
+// CancelOccurrence cancels the occurrence eventID of the series of the booking
+// the token was issued for, under the cancellation policy. The token of any
+// occurrence can cancel the others, as the client received one link for the
+// whole series.
+func (s *gcalService) CancelOccurrence(ctx context.Context, token, eventID string) (*calendar.Event, error) {
+	current, err := s.bookingForToken(ctx, token, bookingtoken.ScopeCancel)
+	if err != nil {
+		return nil, err
+	}
+	if current.Id == eventID {
+		return s.cancelBooking(current, false)
+	}
+	series, ok := SeriesOf(current)
+	if !ok {
+		return nil, ErrSlotNotFound
+	}
+	occurrence, err := s.GetBooking(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if other, ok := SeriesOf(occurrence); !ok || other.ID != series.ID {
+		slog.Warn("Event is not an occurrence of the booking's series", "eventID", eventID, "seriesID", series.ID)
+		return nil, ErrSlotNotFound
+	}
+	return s.cancelBooking(occurrence, false)
+}
+
+// CancelSeries cancels every occurrence of the series of the booking the token
+// was issued for whose cancellation deadline has not passed. Occurrences that
+// have started or are inside the cutoff are kept. If none could be cancelled,
+// the policy error of the token's own booking is returned.
+func (s *gcalService) CancelSeries(ctx context.Context, token string) ([]*calendar.Event, []*calendar.Event, error) {
+	current, err := s.bookingForToken(ctx, token, bookingtoken.ScopeCancel)
+	if err != nil {
+		return nil, nil, err
+	}
+	series, ok := SeriesOf(current)
+	if !ok {
+		return nil, nil, ErrNotSeries
+	}
+	occurrences, err := s.SeriesBookings(ctx, series.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cancelled, kept []*calendar.Event
+	for _, occurrence := range occurrences {
+		if err := s.checkCancellation(occurrence); errors.Is(err, ErrBookingStarted) || errors.Is(err, ErrCancellationClosed) {
+			kept = append(kept, occurrence)
+			continue
+		}
+		snapshot, err := s.cancelBooking(occurrence, false)
+		if errors.Is(err, ErrSlotNotFound) {
+			// Cancelled or changed concurrently; it is no longer booked.
+			continue
+		}
+		if err != nil {
+			return cancelled, kept, err
+		}
+		cancelled = append(cancelled, snapshot)
+	}
+	if len(cancelled) == 0 {
+		if err := s.checkCancellation(current); err != nil {
+			return nil, kept, err
+		}
+	}
+	slog.Info("Cancelled series", "seriesID", series.ID, "cancelled", len(cancelled), "kept", len(kept))
+	return cancelled, kept, nil
+}
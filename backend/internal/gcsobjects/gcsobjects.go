@@ -0,0 +1,110 @@
+// Package gcsobjects keeps the data of the stores that are shared between
+// instances of the service as objects in a Cloud Storage bucket. Writes can be
+// made conditional on an object's generation, so instances changing the same
+// object at once do not overwrite each other.
+package gcsobjects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+)
+
+var (
+	// ErrNotFound is returned by Objects.Get when there is no object.
+	ErrNotFound = errors.New("object not found")
+	// ErrGenerationMismatch is returned by Objects.Put when the object is not
+	// at the expected generation.
+	ErrGenerationMismatch = errors.New("object generation mismatch")
+)
+
+// Objects is the part of a Cloud Storage bucket the stores use. Generations
+// identify versions of an object, as in Cloud Storage; generation 0 stands
+// for an object that does not exist.
+type Objects interface {
+	// Get returns the content and generation of the object name, or
+	// ErrNotFound.
+	Get(ctx context.Context, name string) ([]byte, int64, error)
+	// Put writes the object name if it is at generation, or returns
+	// ErrGenerationMismatch.
+	Put(ctx context.Context, name string, data []byte, generation int64) error
+	// Delete removes the object name. A missing object is not an error.
+	Delete(ctx context.Context, name string) error
+	// List returns the names of the objects starting with prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// bucket implements Objects with a Cloud Storage bucket.
+type bucket struct {
+	handle *storage.BucketHandle
+}
+
+// NewBucket returns the Objects of the Cloud Storage bucket name.
+func NewBucket(client *storage.Client, name string) Objects {
+	return bucket{client.Bucket(name)}
+}
+
+func (b bucket) Get(ctx context.Context, name string) ([]byte, int64, error) {
+	r, err := b.handle.Object(name).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, 0, ErrNotFound
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("opening object %q: %w", name, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading object %q: %w", name, err)
+	}
+	return data, r.Attrs.Generation, nil
+}
+
+func (b bucket) Put(ctx context.Context, name string, data []byte, generation int64) error {
+	conditions := storage.Conditions{GenerationMatch: generation}
+	if generation == 0 {
+		conditions = storage.Conditions{DoesNotExist: true}
+	}
+	w := b.handle.Object(name).If(conditions).NewWriter(ctx)
+	w.ContentType = "application/json"
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("writing object %q: %w", name, err)
+	}
+	if err := w.Close(); err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == 412 {
+			return ErrGenerationMismatch
+		}
+		return fmt.Errorf("writing object %q: %w", name, err)
+	}
+	return nil
+}
+
+func (b bucket) Delete(ctx context.Context, name string) error {
+	err := b.handle.Object(name).Delete(ctx)
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("deleting object %q: %w", name, err)
+	}
+	return nil
+}
+
+func (b bucket) List(ctx context.Context, prefix string) ([]string, error) {
+	it := b.handle.Objects(ctx, &storage.Query{Prefix: prefix})
+	var names []string
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return names, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("listing objects: %w", err)
+		}
+		names = append(names, attrs.Name)
+	}
+}
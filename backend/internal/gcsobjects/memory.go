@@ -0,0 +1,65 @@
+package gcsobjects
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Memory is an in-memory bucket that checks generations the way Cloud
+// Storage does, for tests and local development.
+type Memory struct {
+	mu      sync.Mutex
+	version int64
+	data    map[string][]byte
+	gens    map[string]int64
+}
+
+// NewMemory returns an empty in-memory bucket.
+func NewMemory() *Memory {
+	return &Memory{data: make(map[string][]byte), gens: make(map[string]int64)}
+}
+
+func (m *Memory) Get(ctx context.Context, name string) ([]byte, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.data[name]
+	if !ok {
+		return nil, 0, ErrNotFound
+	}
+	return data, m.gens[name], nil
+}
+
+func (m *Memory) Put(ctx context.Context, name string, data []byte, generation int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.gens[name] != generation {
+		return ErrGenerationMismatch
+	}
+	m.version++
+	m.data[name] = data
+	m.gens[name] = m.version
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, name)
+	delete(m.gens, name)
+	return nil
+}
+
+func (m *Memory) List(ctx context.Context, prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var names []string
+	for name := range m.data {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
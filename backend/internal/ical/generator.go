@@ -21,9 +21,47 @@ type EventDetails struct {
 	// Timezone is the visitor's IANA timezone (e.g. "Europe/Athens"). When
 	// non-empty, an X-WR-TIMEZONE header is emitted at the VCALENDAR level
 	// so older Outlook/iOS clients render the event in the visitor's zone
-	// rather than the calendar owner's. The DTSTART/DTEND fields are always
-	// UTC (Z-suffixed), which is RFC-5545 compliant for all modern clients.
+	// rather than the calendar owner's. The DTSTART/DTEND fields are UTC
+	// (Z-suffixed), which is RFC-5545 compliant for all modern clients,
+	// unless the event recurs (see Recurrence).
 	Timezone string
+	// Sequence is the revision number of the invitation. It starts at 0 and
+	// is incremented whenever an already-sent event (same UID) is updated,
+	// e.g. on a reschedule, so clients replace the old entry instead of
+	// adding a second one.
+	Sequence int
+	// Guests are further attendees the client invited, e.g. colleagues.
+	Guests []string
+	// Recurrence makes the event a recurring series starting at StartTime,
+	// or is nil for a single event.
+	Recurrence *Recurrence
+}
+
+// Recurrence describes a weekly series of events.
+type Recurrence struct {
+	IntervalWeeks int
+	Count         int // occurrences, cancelled ones included
+	// Location is the timezone in which the series keeps its time of day.
+	// The times of a recurring event are written in it, with a VTIMEZONE,
+	// so a DST change does not move the later occurrences. Nil or UTC
+	// writes them in UTC.
+	Location *time.Location
+	// ExDates are the starts of the cancelled occurrences.
+	ExDates []time.Time
+}
+
+// zoneID returns the TZID of the series' timezone, or "" if its times are
+// written in UTC.
+func (r *Recurrence) zoneID() string {
+	if r == nil || r.Location == nil {
+		return ""
+	}
+	switch name := r.Location.String(); name {
+	case "UTC", "Local":
+		return ""
+	default:
+		return name
+	}
 }
 
 // Attachment represents an email attachment.
@@ -37,6 +75,80 @@ func timeToUTCiCalFormat(t time.Time) string {
 	return t.UTC().Format("20060102T150405Z")
 }
 
+// dateTimeProperty renders the property name with the times t: in UTC, or
+// as local times in the zone of the recurrence rec with a TZID parameter.
+func dateTimeProperty(name string, rec *Recurrence, t ...time.Time) string {
+	tzid := rec.zoneID()
+	values := make([]string, len(t))
+	for i := range t {
+		if tzid == "" {
+			values[i] = timeToUTCiCalFormat(t[i])
+		} else {
+			values[i] = t[i].In(rec.Location).Format("20060102T150405")
+		}
+	}
+	if tzid != "" {
+		name += ";TZID=" + tzid
+	}
+	return fmt.Sprintf("%s:%s\r\n", name, strings.Join(values, ","))
+}
+
+// writeTimezone writes the VTIMEZONE defining loc from from until to: the
+// offset in effect at from and each transition after it.
+func writeTimezone(b *bytes.Buffer, loc *time.Location, from, to time.Time) {
+	b.WriteString("BEGIN:VTIMEZONE\r\n")
+	b.WriteString(fmt.Sprintf("TZID:%s\r\n", loc.String()))
+	from = from.In(loc)
+	_, offset := from.Zone()
+	writeObservance(b, from, offset)
+	// Zones change their offset at most once a day; the transition is then
+	// narrowed down to the second.
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		next := day.Add(24 * time.Hour)
+		if _, nextOffset := next.Zone(); nextOffset == offset {
+			continue
+		}
+		before, after := day, next
+		for after.Sub(before) > time.Second {
+			mid := before.Add(after.Sub(before) / 2)
+			if _, midOffset := mid.Zone(); midOffset == offset {
+				before = mid
+			} else {
+				after = mid
+			}
+		}
+		writeObservance(b, after, offset)
+		_, offset = after.Zone()
+	}
+	b.WriteString("END:VTIMEZONE\r\n")
+}
+
+// writeObservance writes the STANDARD or DAYLIGHT component for the offset of
+// loc that starts at onset, switching from offsetFrom.
+func writeObservance(b *bytes.Buffer, onset time.Time, offsetFrom int) {
+	name, offset := onset.Zone()
+	kind := "STANDARD"
+	if onset.IsDST() {
+		kind = "DAYLIGHT"
+	}
+	b.WriteString(fmt.Sprintf("BEGIN:%s\r\n", kind))
+	// The onset is the local time before the transition.
+	b.WriteString(fmt.Sprintf("DTSTART:%s\r\n", onset.In(time.FixedZone("", offsetFrom)).Format("20060102T150405")))
+	b.WriteString(fmt.Sprintf("TZOFFSETFROM:%s\r\n", formatOffset(offsetFrom)))
+	b.WriteString(fmt.Sprintf("TZOFFSETTO:%s\r\n", formatOffset(offset)))
+	b.WriteString(fmt.Sprintf("TZNAME:%s\r\n", escapeString(name)))
+	b.WriteString(fmt.Sprintf("END:%s\r\n", kind))
+}
+
+// formatOffset renders a UTC offset in seconds as iCalendar does, e.g. "+0200".
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
+
 // escapeString escapes characters in a string according to iCalendar specs.
 func escapeString(s string) string {
 	s = strings.ReplaceAll(s, "\\", "\\\\")
@@ -58,18 +170,32 @@ func Generate(details EventDetails) string {
 	if tz := strings.TrimSpace(details.Timezone); tz != "" {
 		b.WriteString(fmt.Sprintf("X-WR-TIMEZONE:%s\r\n", escapeString(tz)))
 	}
+	rec := details.Recurrence
+	if rec.zoneID() != "" {
+		last := details.EndTime.AddDate(0, 0, 7*rec.IntervalWeeks*(rec.Count-1))
+		writeTimezone(&b, rec.Location, details.StartTime, last)
+	}
 	b.WriteString("BEGIN:VEVENT\r\n")
 	b.WriteString(fmt.Sprintf("UID:%s\r\n", details.UID))
 	b.WriteString(fmt.Sprintf("DTSTAMP:%s\r\n", timeToUTCiCalFormat(time.Now())))
-	b.WriteString(fmt.Sprintf("DTSTART:%s\r\n", timeToUTCiCalFormat(details.StartTime)))
-	b.WriteString(fmt.Sprintf("DTEND:%s\r\n", timeToUTCiCalFormat(details.EndTime)))
+	b.WriteString(dateTimeProperty("DTSTART", rec, details.StartTime))
+	b.WriteString(dateTimeProperty("DTEND", rec, details.EndTime))
+	if rec != nil {
+		b.WriteString(fmt.Sprintf("RRULE:FREQ=WEEKLY;INTERVAL=%d;COUNT=%d\r\n", rec.IntervalWeeks, rec.Count))
+		if len(rec.ExDates) > 0 {
+			b.WriteString(dateTimeProperty("EXDATE", rec, rec.ExDates...))
+		}
+	}
 	b.WriteString(fmt.Sprintf("SUMMARY:%s\r\n", escapeString(details.Summary)))
 	b.WriteString(fmt.Sprintf("DESCRIPTION:%s\r\n", escapeString(details.Description)))
 	b.WriteString(fmt.Sprintf("LOCATION:%s\r\n", escapeString(details.Location)))
 	b.WriteString(fmt.Sprintf("ORGANIZER;CN=IVMANTO:mailto:no-reply@ivmanto.com\r\n")) // Using a generic organizer
 	b.WriteString(fmt.Sprintf("ATTENDEE;CN=%s;ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:%s\r\n", escapeString(details.Name), details.Email))
+	for _, guest := range details.Guests {
+		b.WriteString(fmt.Sprintf("ATTENDEE;ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:%s\r\n", guest))
+	}
 	b.WriteString("STATUS:CONFIRMED\r\n")
-	b.WriteString("SEQUENCE:0\r\n")
+	b.WriteString(fmt.Sprintf("SEQUENCE:%d\r\n", details.Sequence))
 	b.WriteString("END:VEVENT\r\n")
 	b.WriteString("END:VCALENDAR\r\n")
 
@@ -84,3 +84,94 @@ func TestGenerate_EscapesIANACharacters(t *testing.T) {
 		t.Errorf("expected escaped IANA value, got:\n%s", out)
 	}
 }
+
+// TestGenerate_EmitsSequence verifies that a rescheduled invitation carries
+// its revision number, so calendar clients update the existing entry with
+// the same UID instead of creating a duplicate.
+func TestGenerate_EmitsSequence(t *testing.T) {
+	start := time.Date(2026, 6, 15, 13, 30, 0, 0, time.UTC)
+	end := time.Date(2026, 6, 15, 14, 0, 0, 0, time.UTC)
+
+	out := Generate(EventDetails{
+		UID:       "test-uid@ivmanto.com",
+		StartTime: start,
+		EndTime:   end,
+		Summary:   "Consultation",
+		Name:      "Visitor",
+		Email:     "visitor@example.com",
+		Sequence:  2,
+	})
+
+	if !strings.Contains(out, "SEQUENCE:2\r\n") {
+		t.Errorf("expected SEQUENCE:2, got:\n%s", out)
+	}
+	if !strings.Contains(out, "UID:test-uid@ivmanto.com\r\n") {
+		t.Errorf("expected the original UID to be kept, got:\n%s", out)
+	}
+}
+
+// TestGenerate_EmitsGuestAttendees lists the client's guests as attendees, so
+// the forwarded invitation names everyone who is invited.
+func TestGenerate_EmitsGuestAttendees(t *testing.T) {
+	start := time.Date(2026, 6, 15, 13, 30, 0, 0, time.UTC)
+
+	out := Generate(EventDetails{
+		UID:       "test-uid@ivmanto.com",
+		StartTime: start,
+		EndTime:   start.Add(30 * time.Minute),
+		Summary:   "Consultation",
+		Name:      "Visitor",
+		Email:     "visitor@example.com",
+		Guests:    []string{"bob@example.com", "cy@example.com"},
+	})
+
+	for _, guest := range []string{"bob@example.com", "cy@example.com"} {
+		if !strings.Contains(out, "mailto:"+guest+"\r\n") {
+			t.Errorf("expected an ATTENDEE line for %s, got:\n%s", guest, out)
+		}
+	}
+}
+
+// TestGenerate_EmitsRecurrence verifies that a series is written in its
+// timezone, with a VTIMEZONE covering the DST change during the series, so
+// the occurrences after it keep their time of day.
+func TestGenerate_EmitsRecurrence(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("tzdata not available: %v", err)
+	}
+	start := time.Date(2026, 10, 12, 15, 30, 0, 0, berlin)
+
+	out := Generate(EventDetails{
+		UID:       "series-uid@ivmanto.com",
+		StartTime: start,
+		EndTime:   start.Add(30 * time.Minute),
+		Summary:   "Consultation",
+		Name:      "Visitor",
+		Email:     "visitor@example.com",
+		Recurrence: &Recurrence{
+			IntervalWeeks: 2,
+			Count:         4,
+			Location:      berlin,
+			ExDates:       []time.Time{start.AddDate(0, 0, 14), start.AddDate(0, 0, 28)},
+		},
+	})
+
+	for _, line := range []string{
+		"TZID:Europe/Berlin",
+		"DTSTART:20261025T030000", // the switch to CET, in CEST
+		"TZOFFSETFROM:+0200",
+		"TZOFFSETTO:+0100",
+		"DTSTART;TZID=Europe/Berlin:20261012T153000",
+		"DTEND;TZID=Europe/Berlin:20261012T160000",
+		"RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4",
+		"EXDATE;TZID=Europe/Berlin:20261026T153000,20261109T153000",
+	} {
+		if !strings.Contains(out, line+"\r\n") {
+			t.Errorf("expected %q, got:\n%s", line, out)
+		}
+	}
+	if strings.Index(out, "END:VTIMEZONE") > strings.Index(out, "BEGIN:VEVENT") {
+		t.Errorf("expected the VTIMEZONE before the event, got:\n%s", out)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"cloud.google.com/go/vertexai/genai"
 	"ivmanto.com/backend/internal/email"
+	"ivmanto.com/backend/internal/webhook"
 )
 
 // ModelName is the specific Vertex AI model to use for generating ideas.
@@ -22,10 +23,11 @@ type Handler struct {
 	genaiClient    *genai.Client
 	emailSvc       email.Service
 	promptTemplate string
+	webhooks       *webhook.Dispatcher
 }
 
 // NewHandler creates a new ideas handler.
-func NewHandler(logger *slog.Logger, genaiClient *genai.Client, emailSvc email.Service, promptTemplate string) *Handler {
+func NewHandler(logger *slog.Logger, genaiClient *genai.Client, emailSvc email.Service, promptTemplate string, webhooks *webhook.Dispatcher) *Handler {
 	// Provide a robust default if the prompt isn't configured via environment variables.
 	if promptTemplate == "" {
 		logger.Warn("Generate ideas prompt template is not configured, using default.")
@@ -37,6 +39,7 @@ func NewHandler(logger *slog.Logger, genaiClient *genai.Client, emailSvc email.S
 		genaiClient:    genaiClient,
 		emailSvc:       emailSvc,
 		promptTemplate: promptTemplate,
+		webhooks:       webhooks,
 	}
 }
 
@@ -178,6 +181,7 @@ func (h *Handler) handleEmailIdeas(w http.ResponseWriter, r *http.Request) {
 		h.respondError(w, http.StatusInternalServerError, "Failed to send email")
 		return
 	}
+	h.webhooks.Send(webhook.EventIdeasEmailed, req)
 
 	h.respondJSON(w, http.StatusOK, map[string]string{"message": "Email sent successfully"})
 }
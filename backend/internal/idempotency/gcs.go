@@ -0,0 +1,156 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"ivmanto.com/backend/internal/gcsobjects"
+)
+
+// reserveAttempts bounds how often Reserve retries when other instances
+// change the same key under it.
+const reserveAttempts = 3
+
+// gcsStore keeps each key as a JSON object in a Cloud Storage bucket, so all
+// instances of the service share the keys. Writes are conditional on the
+// object's generation, which makes Reserve safe against the same key arriving
+// at two instances at once.
+type gcsStore struct {
+	objects gcsobjects.Objects
+	prefix  string
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewGCSStore creates a Store that keeps keys for ttl as objects under prefix,
+// e.g. "idempotency/", in the Cloud Storage bucket. Expired objects are
+// replaced when their key is used again and are otherwise left behind; a
+// lifecycle rule on the bucket should delete objects under prefix some days
+// after they were last written.
+func NewGCSStore(client *storage.Client, bucket, prefix string, ttl time.Duration) Store {
+	return newGCSStore(gcsobjects.NewBucket(client, bucket), prefix, ttl)
+}
+
+func newGCSStore(objects gcsobjects.Objects, prefix string, ttl time.Duration) *gcsStore {
+	return &gcsStore{objects: objects, prefix: prefix, ttl: ttl, now: time.Now}
+}
+
+// name returns the object name of key. Keys are chosen by clients, so they
+// are hashed rather than used in the name.
+func (s *gcsStore) name(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return s.prefix + hex.EncodeToString(sum[:]) + ".json"
+}
+
+// read returns the record stored under name and its generation.
+func (s *gcsStore) read(ctx context.Context, name string) (Record, int64, error) {
+	data, generation, err := s.objects.Get(ctx, name)
+	if err != nil {
+		return Record{}, 0, err
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return Record{}, 0, fmt.Errorf("parsing idempotency record %s: %w", name, err)
+	}
+	return record, generation, nil
+}
+
+// write stores record under name if the object is at generation.
+func (s *gcsStore) write(ctx context.Context, name string, record Record, generation int64) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encoding idempotency record: %w", err)
+	}
+	return s.objects.Put(ctx, name, data, generation)
+}
+
+// Reserve creates the object of key unless it holds an unexpired record. An
+// expired record is replaced only if it did not change since it was read.
+func (s *gcsStore) Reserve(ctx context.Context, key, fingerprint string) (*Record, error) {
+	name := s.name(key)
+	reserved := Record{Fingerprint: fingerprint, Expires: s.now().Add(s.ttl)}
+	var generation int64
+	for range reserveAttempts {
+		err := s.write(ctx, name, reserved, generation)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, gcsobjects.ErrGenerationMismatch) {
+			return nil, fmt.Errorf("reserving idempotency key: %w", err)
+		}
+		record, current, err := s.read(ctx, name)
+		switch {
+		case errors.Is(err, gcsobjects.ErrNotFound):
+			generation = 0
+		case err != nil:
+			return nil, fmt.Errorf("reading idempotency key: %w", err)
+		case s.now().Before(record.Expires):
+			return &record, nil
+		default:
+			generation = current
+		}
+	}
+	return nil, fmt.Errorf("reserving idempotency key: still contended after %d attempts", reserveAttempts)
+}
+
+// Complete stores response for key and restarts its TTL. It does nothing if
+// key was released or replaced by another request in the meantime.
+func (s *gcsStore) Complete(ctx context.Context, key string, response Response) error {
+	name := s.name(key)
+	record, generation, err := s.read(ctx, name)
+	if errors.Is(err, gcsobjects.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading idempotency key: %w", err)
+	}
+	record.Response = &response
+	record.Expires = s.now().Add(s.ttl)
+	if err := s.write(ctx, name, record, generation); err != nil && !errors.Is(err, gcsobjects.ErrGenerationMismatch) {
+		return fmt.Errorf("storing idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release removes the object of key.
+func (s *gcsStore) Release(ctx context.Context, key string) error {
+	if err := s.objects.Delete(ctx, s.name(key)); err != nil {
+		return fmt.Errorf("releasing idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Forget reads every record under the prefix and removes those whose
+// response contains text.
+func (s *gcsStore) Forget(ctx context.Context, text string) (int, error) {
+	names, err := s.objects.List(ctx, s.prefix)
+	if err != nil {
+		return 0, fmt.Errorf("listing idempotency keys: %w", err)
+	}
+	needle := bytes.ToLower([]byte(text))
+	forgotten := 0
+	for _, name := range names {
+		record, _, err := s.read(ctx, name)
+		if errors.Is(err, gcsobjects.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return forgotten, err
+		}
+		if record.Response == nil || !bytes.Contains(bytes.ToLower(record.Response.Body), needle) {
+			continue
+		}
+		if err := s.objects.Delete(ctx, name); err != nil {
+			return forgotten, fmt.Errorf("deleting idempotency key: %w", err)
+		}
+		forgotten++
+	}
+	return forgotten, nil
+}
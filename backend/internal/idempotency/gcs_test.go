@@ -0,0 +1,94 @@
+package idempotency
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"ivmanto.com/backend/internal/gcsobjects"
+)
+
+// TestGCSStore_SharedBetweenInstances reserves one key from several instances
+// at once, completes it on the winner and checks that every instance sees the
+// response until it expires.
+func TestGCSStore_SharedBetweenInstances(t *testing.T) {
+	bucket := gcsobjects.NewMemory()
+	instances := make([]*gcsStore, 5)
+	for i := range instances {
+		instances[i] = newGCSStore(bucket, "idempotency/", time.Hour)
+	}
+	ctx := t.Context()
+
+	var wg sync.WaitGroup
+	won := make([]bool, len(instances))
+	for i, store := range instances {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			record, err := store.Reserve(ctx, "k", "fp")
+			won[i] = record == nil && err == nil
+		}()
+	}
+	wg.Wait()
+	winner := -1
+	for i, ok := range won {
+		if ok && winner >= 0 {
+			t.Fatalf("expected one instance to reserve the key, got %v", won)
+		}
+		if ok {
+			winner = i
+		}
+	}
+	if winner < 0 {
+		t.Fatal("expected one instance to reserve the key, got none")
+	}
+
+	if err := instances[winner].Complete(ctx, "k", Response{Status: 201, Body: []byte(`{}`)}); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	other := instances[(winner+1)%len(instances)]
+	record, err := other.Reserve(ctx, "k", "other")
+	if err != nil || record == nil || record.Response == nil || record.Response.Status != 201 || record.Fingerprint != "fp" {
+		t.Fatalf("expected the stored response on another instance, got %+v (%v)", record, err)
+	}
+
+	other.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if record, err := other.Reserve(ctx, "k", "other"); record != nil || err != nil {
+		t.Errorf("expected the expired key to be reserved again, got %+v (%v)", record, err)
+	}
+	if err := other.Release(ctx, "k"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if record, _ := instances[winner].Reserve(ctx, "k", "fp"); record != nil {
+		t.Errorf("expected the released key to be free, got %+v", record)
+	}
+}
+
+// TestGCSStore_Forget removes the stored responses naming an address and
+// leaves the others and requests still in progress alone.
+func TestGCSStore_Forget(t *testing.T) {
+	bucket := gcsobjects.NewMemory()
+	bucket.Put(t.Context(), "other/unrelated.json", []byte(`{"email":"ada@example.com"}`), 0)
+	store := newGCSStore(bucket, "idempotency/", time.Hour)
+	ctx := t.Context()
+	for key, body := range map[string]string{"ada": `{"email":"Ada@Example.com"}`, "bob": `{"email":"bob@example.com"}`} {
+		store.Reserve(ctx, key, "fp")
+		store.Complete(ctx, key, Response{Status: 201, Body: []byte(body)})
+	}
+	store.Reserve(ctx, "pending", "fp")
+
+	if n, err := store.Forget(ctx, "ada@example.com"); err != nil || n != 1 {
+		t.Fatalf("expected one record to be forgotten, got %d (%v)", n, err)
+	}
+	if record, _ := store.Reserve(ctx, "ada", "fp"); record != nil {
+		t.Errorf("expected the forgotten key to be free, got %+v", record)
+	}
+	for _, key := range []string{"bob", "pending"} {
+		if record, _ := store.Reserve(ctx, key, "fp"); record == nil {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+	if _, _, err := bucket.Get(ctx, "other/unrelated.json"); err != nil {
+		t.Errorf("expected objects outside the prefix to be left alone, got %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+// Package idempotency remembers the responses to requests sent with an
+// Idempotency-Key header, so a retried request is answered with the first
+// response instead of being processed again.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"ivmanto.com/backend/internal/jsonfile"
+)
+
+// Response is a stored response to replay.
+type Response struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body"`
+}
+
+// Record is what a Store keeps for one key.
+type Record struct {
+	// Fingerprint identifies the request the key was first used with.
+	Fingerprint string `json:"fingerprint"`
+	// Response is nil while the first request is still being processed.
+	Response *Response `json:"response,omitempty"`
+	Expires  time.Time `json:"expires"`
+}
+
+// Store defines the interface for idempotency key storage. Keys expire after
+// the TTL the store was created with, whether or not a response was stored.
+type Store interface {
+	// Reserve claims key for the request with fingerprint. For a new or
+	// expired key it records the request as in progress and returns nil;
+	// otherwise it returns the existing record and changes nothing.
+	Reserve(ctx context.Context, key, fingerprint string) (*Record, error)
+	// Complete stores the response to the request that reserved key.
+	Complete(ctx context.Context, key string, response Response) error
+	// Release forgets key, so the request can be retried with it.
+	Release(ctx context.Context, key string) error
+	// Forget removes the records whose stored response contains text,
+	// ignoring case, e.g. the email address of a client who asked to be
+	// forgotten, and returns how many it removed.
+	Forget(ctx context.Context, text string) (int, error)
+}
+
+// memoryStore keeps the records in memory.
+type memoryStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+// NewMemoryStore creates a Store that keeps keys in memory for ttl. Keys are
+// lost on restart and not shared between instances.
+func NewMemoryStore(ttl time.Duration) Store {
+	return newMemoryStore(ttl)
+}
+
+func newMemoryStore(ttl time.Duration) *memoryStore {
+	return &memoryStore{ttl: ttl, now: time.Now, records: make(map[string]Record)}
+}
+
+// Reserve claims key unless it holds an unexpired record. Expired records
+// are pruned on the way.
+func (s *memoryStore) Reserve(ctx context.Context, key, fingerprint string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reserve(key, fingerprint), nil
+}
+
+// reserve implements Reserve. The caller must hold s.mu.
+func (s *memoryStore) reserve(key, fingerprint string) *Record {
+	now := s.now()
+	for k, record := range s.records {
+		if !now.Before(record.Expires) {
+			delete(s.records, k)
+		}
+	}
+	if record, ok := s.records[key]; ok {
+		return &record
+	}
+	s.records[key] = Record{Fingerprint: fingerprint, Expires: now.Add(s.ttl)}
+	return nil
+}
+
+// Complete stores response for key and restarts its TTL.
+func (s *memoryStore) Complete(ctx context.Context, key string, response Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.complete(key, response)
+	return nil
+}
+
+// complete implements Complete. The caller must hold s.mu.
+func (s *memoryStore) complete(key string, response Response) {
+	record, ok := s.records[key]
+	if !ok {
+		return
+	}
+	record.Response = &response
+	record.Expires = s.now().Add(s.ttl)
+	s.records[key] = record
+}
+
+// Release removes key.
+func (s *memoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// Forget removes the records whose response contains text.
+func (s *memoryStore) Forget(ctx context.Context, text string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.forget(text), nil
+}
+
+// forget implements Forget. The caller must hold s.mu.
+func (s *memoryStore) forget(text string) int {
+	needle := bytes.ToLower([]byte(text))
+	forgotten := 0
+	for key, record := range s.records {
+		if record.Response != nil && bytes.Contains(bytes.ToLower(record.Response.Body), needle) {
+			delete(s.records, key)
+			forgotten++
+		}
+	}
+	return forgotten
+}
+
+// fileStore is a memoryStore that mirrors its records to a JSON file, so
+// stored responses survive restarts.
+type fileStore struct {
+	*memoryStore
+	path string
+}
+
+// NewFileStore creates a Store that keeps keys for ttl in the JSON file at
+// path, loading the records it already holds.
+func NewFileStore(path string, ttl time.Duration) (Store, error) {
+	s := &fileStore{memoryStore: newMemoryStore(ttl), path: path}
+	if err := jsonfile.Load(path, &s.records); err != nil {
+		return nil, fmt.Errorf("loading idempotency records: %w", err)
+	}
+	return s, nil
+}
+
+// Reserve claims key and persists the records.
+func (s *fileStore) Reserve(ctx context.Context, key, fingerprint string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record := s.reserve(key, fingerprint); record != nil {
+		return record, nil
+	}
+	if err := s.persist(); err != nil {
+		delete(s.records, key)
+		return nil, err
+	}
+	return nil, nil
+}
+
+// Complete stores response for key and persists the records.
+func (s *fileStore) Complete(ctx context.Context, key string, response Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.complete(key, response)
+	return s.persist()
+}
+
+// Release removes key and persists the records.
+func (s *fileStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return s.persist()
+}
+
+// Forget removes the records whose response contains text and persists the
+// rest.
+func (s *fileStore) Forget(ctx context.Context, text string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	forgotten := s.forget(text)
+	if forgotten == 0 {
+		return 0, nil
+	}
+	return forgotten, s.persist()
+}
+
+// persist writes the records to the file. The caller must hold s.mu.
+func (s *fileStore) persist() error {
+	if err := jsonfile.Save(s.path, s.records); err != nil {
+		return fmt.Errorf("saving idempotency records: %w", err)
+	}
+	return nil
+}
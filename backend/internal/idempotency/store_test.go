@@ -0,0 +1,80 @@
+package idempotency
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFileStore_PersistsAndExpires completes a key, reopens the store on the
+// same file and checks the response is still there until the TTL passes.
+func TestFileStore_PersistsAndExpires(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idempotency.json")
+	store, err := NewFileStore(path, time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	ctx := t.Context()
+
+	if record, err := store.Reserve(ctx, "k", "fp"); record != nil || err != nil {
+		t.Fatalf("expected a new key to be reserved, got %+v (%v)", record, err)
+	}
+	if err := store.Complete(ctx, "k", Response{Status: 201, ContentType: "application/json", Body: []byte(`{}`)}); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if record, _ := store.Reserve(ctx, "gone", "fp"); record != nil {
+		t.Fatalf("expected a second key to be reserved, got %+v", record)
+	}
+	if err := store.Release(ctx, "gone"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+
+	reopened, err := NewFileStore(path, time.Hour)
+	if err != nil {
+		t.Fatalf("reopening the store failed: %v", err)
+	}
+	record, err := reopened.Reserve(ctx, "k", "other")
+	if err != nil || record == nil || record.Response == nil || record.Response.Status != 201 || record.Fingerprint != "fp" {
+		t.Fatalf("expected the stored response, got %+v (%v)", record, err)
+	}
+	if record, _ := reopened.Reserve(ctx, "gone", "fp"); record != nil {
+		t.Errorf("expected the released key to be free, got %+v", record)
+	}
+
+	reopened.(*fileStore).now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if record, _ := reopened.Reserve(ctx, "k", "other"); record != nil {
+		t.Errorf("expected the key to have expired, got %+v", record)
+	}
+}
+
+// TestFileStore_Forget removes the stored responses naming an address, also
+// after a reopen, and leaves requests still in progress alone.
+func TestFileStore_Forget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idempotency.json")
+	store, err := NewFileStore(path, time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	ctx := t.Context()
+	for key, body := range map[string]string{"ada": `{"email":"Ada@Example.com"}`, "bob": `{"email":"bob@example.com"}`} {
+		store.Reserve(ctx, key, "fp")
+		store.Complete(ctx, key, Response{Status: 201, Body: []byte(body)})
+	}
+	store.Reserve(ctx, "pending", "fp")
+
+	if n, err := store.Forget(ctx, "ada@example.com"); err != nil || n != 1 {
+		t.Fatalf("expected one record to be forgotten, got %d (%v)", n, err)
+	}
+	reopened, err := NewFileStore(path, time.Hour)
+	if err != nil {
+		t.Fatalf("reopening the store failed: %v", err)
+	}
+	if record, _ := reopened.Reserve(ctx, "ada", "fp"); record != nil {
+		t.Errorf("expected the forgotten key to be free, got %+v", record)
+	}
+	for _, key := range []string{"bob", "pending"} {
+		if record, _ := reopened.Reserve(ctx, key, "fp"); record == nil {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package jsonfile
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestSaveAndLoad saves a list, loads it back and checks that no temporary
+// file is left behind.
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entries.json")
+	want := []string{"a", "b"}
+	if err := Save(path, want); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	var got []string
+	if err := Load(path, &got); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected no temporary file, got %v", err)
+	}
+}
+
+// TestLoad_MissingAndInvalid loads a file that does not exist and one that is
+// not JSON.
+func TestLoad_MissingAndInvalid(t *testing.T) {
+	dir := t.TempDir()
+	got := []string{"kept"}
+	if err := Load(filepath.Join(dir, "missing.json"), &got); err != nil || len(got) != 1 {
+		t.Errorf("expected a missing file to leave the value alone, got %v (%v)", got, err)
+	}
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(invalid, &got); err == nil {
+		t.Error("expected an error for a file that is not JSON")
+	}
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"ivmanto.com/backend/internal/idempotency"
+)
+
+// Limits of requests sent with an Idempotency-Key header.
+const (
+	maxIdempotencyKey = 255
+	maxIdempotentBody = 1 << 20
+)
+
+// responseRecorder passes a response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(code int) {
+	if rr.status == 0 {
+		rr.status = code
+	}
+	rr.ResponseWriter.WriteHeader(code)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
+
+// Idempotent makes requests that carry an Idempotency-Key header safe to
+// retry. The first response for a key is stored and replayed, marked with an
+// Idempotent-Replayed header, for later requests with the same key, method,
+// path and body. Reusing a key for a different body gets 422, and a request
+// whose key is still being processed gets 409.
+//
+// Server errors (5xx) are not stored, so the request can be retried with the
+// same key. Requests without the header are passed through unchanged, as are
+// all requests when the store fails.
+func Idempotent(store idempotency.Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			writeJSONError(w, http.StatusBadRequest, "Idempotency-Key must not be longer than 255 characters.")
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if len(body) > maxIdempotentBody {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are scoped to the endpoint, so clients may reuse them across endpoints.
+		storeKey := r.Method + " " + r.URL.Path + " " + key
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+
+		record, err := store.Reserve(r.Context(), storeKey, fingerprint)
+		if err != nil {
+			slog.Error("Failed to reserve idempotency key, processing request without it", "path", r.URL.Path, "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if record != nil {
+			switch {
+			case record.Fingerprint != fingerprint:
+				writeJSONError(w, http.StatusUnprocessableEntity, "This Idempotency-Key was already used for a different request.")
+			case record.Response == nil:
+				w.Header().Set("Retry-After", "1")
+				writeJSONError(w, http.StatusConflict, "A request with this Idempotency-Key is still being processed.")
+			default:
+				if record.Response.ContentType != "" {
+					w.Header().Set("Content-Type", record.Response.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(record.Response.Status)
+				w.Write(record.Response.Body)
+			}
+			return
+		}
+
+		rr := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rr, r)
+		if rr.status == 0 {
+			rr.status = http.StatusOK
+		}
+
+		if rr.status >= http.StatusInternalServerError {
+			err = store.Release(r.Context(), storeKey)
+		} else {
+			err = store.Complete(r.Context(), storeKey, idempotency.Response{
+				Status:      rr.status,
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        rr.body.Bytes(),
+			})
+		}
+		if err != nil {
+			slog.Error("Failed to store idempotent response", "path", r.URL.Path, "error", err)
+		}
+	})
+}
+
+// writeJSONError writes a JSON error body like the handlers do.
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(`{"message":"` + message + `"}` + "\n"))
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ivmanto.com/backend/internal/idempotency"
+)
+
+// TestIdempotent_ReplaysFirstResponse sends a request twice with the same
+// key, then with a different body, then without a key, and checks which of
+// them reach the handler.
+func TestIdempotent_ReplaysFirstResponse(t *testing.T) {
+	var calls atomic.Int32
+	handler := Idempotent(idempotency.NewMemoryStore(time.Hour), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"call":%d}`, n)
+	}))
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/booking/book", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("k1", `{"eventId":"slot1"}`)
+	retry := send("k1", `{"eventId":"slot1"}`)
+	if first.Code != http.StatusCreated || retry.Code != http.StatusCreated || retry.Body.String() != `{"call":1}` {
+		t.Errorf("expected the retry to replay the first response, got %d %q and %d %q", first.Code, first.Body, retry.Code, retry.Body)
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" || retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected replay headers %v", retry.Header())
+	}
+	if rec := send("k1", `{"eventId":"slot2"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a reused key with another body, got %d", rec.Code)
+	}
+	send("", `{"eventId":"slot1"}`)
+	send("k2", `{"eventId":"slot1"}`)
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 requests to reach the handler, got %d", got)
+	}
+}
+
+// TestIdempotent_InProgressAndServerErrors checks that a concurrent retry is
+// turned away while the first request runs, and that a server error leaves the
+// key free for another attempt.
+func TestIdempotent_InProgressAndServerErrors(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var fail atomic.Bool
+	fail.Store(true)
+	handler := Idempotent(idempotency.NewMemoryStore(time.Hour), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" && fail.Load() {
+			close(started)
+			<-release
+		}
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	send := func(path string) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("{}"))
+		req.Header.Set("Idempotency-Key", "k")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	done := make(chan int)
+	go func() { done <- send("/slow") }()
+	<-started
+	if code := send("/slow"); code != http.StatusConflict {
+		t.Errorf("expected 409 while the first request runs, got %d", code)
+	}
+	close(release)
+	if code := <-done; code != http.StatusInternalServerError {
+		t.Fatalf("expected the first request to fail, got %d", code)
+	}
+
+	fail.Store(false)
+	if code := send("/fast"); code != http.StatusOK {
+		t.Errorf("expected the same key to work on another path, got %d", code)
+	}
+	if code := send("/slow"); code == http.StatusConflict {
+		t.Error("expected the key to be released after the server error")
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter allows each client a fixed number of requests per window. The
+// counts are kept in memory, so each instance of the service limits the
+// requests it receives on its own.
+type RateLimiter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*rateWindow
+}
+
+// rateWindow counts the requests of one client since start.
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// NewRateLimiter creates a RateLimiter allowing limit requests per window.
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		now:     time.Now,
+		clients: make(map[string]*rateWindow),
+	}
+}
+
+// Allow counts a request by client and reports whether it is within the
+// limit. If it is not, retryAfter is the time until the client's window ends.
+func (l *RateLimiter) Allow(client string) (ok bool, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for key, w := range l.clients {
+		if now.Sub(w.start) >= l.window {
+			delete(l.clients, key)
+		}
+	}
+	w := l.clients[client]
+	if w == nil {
+		w = &rateWindow{start: now}
+		l.clients[client] = w
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// RateLimit answers 429 Too Many Requests, with a Retry-After header, to the
+// requests of clients that exceed l. Clients are told apart by client, e.g.
+// by their IP address.
+func RateLimit(l *RateLimiter, client func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, retryAfter := l.Allow(client(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			writeJSONError(w, http.StatusTooManyRequests, "Too many requests. Please try again later.")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
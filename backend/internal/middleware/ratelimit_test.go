@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRateLimit_LimitsEachClient sends requests from two clients and checks
+// that only the one over the limit is turned away until its window ends.
+func TestRateLimit_LimitsEachClient(t *testing.T) {
+	limiter := NewRateLimiter(2, time.Hour)
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+	handler := RateLimit(limiter, func(r *http.Request) string { return r.RemoteAddr }, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	send := func(client string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/booking/waitlist", nil)
+		req.RemoteAddr = client
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := send("a"); rec.Code != http.StatusCreated {
+			t.Fatalf("expected request %d to pass, got %d", i+1, rec.Code)
+		}
+	}
+	now = now.Add(15 * time.Minute)
+	if rec := send("a"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "2700" {
+		t.Errorf("expected 429 with Retry-After 2700, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := send("b"); rec.Code != http.StatusCreated {
+		t.Errorf("expected another client to pass, got %d", rec.Code)
+	}
+	now = now.Add(time.Hour)
+	if rec := send("a"); rec.Code != http.StatusCreated {
+		t.Errorf("expected the limit to reset after the window, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package waitlist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/storage"
+	"ivmanto.com/backend/internal/gcsobjects"
+)
+
+// gcsStore keeps each entry as a JSON object in a Cloud Storage bucket, so
+// all instances of the service share the waitlist and the slots its offers
+// reserve. An entry's Version is the generation of its object.
+type gcsStore struct {
+	objects gcsobjects.Objects
+	prefix  string
+}
+
+// NewGCSStore creates a Store that keeps entries as objects under prefix,
+// e.g. "waitlist/", in the Cloud Storage bucket.
+func NewGCSStore(client *storage.Client, bucket, prefix string) Store {
+	return newGCSStore(gcsobjects.NewBucket(client, bucket), prefix)
+}
+
+func newGCSStore(objects gcsobjects.Objects, prefix string) *gcsStore {
+	return &gcsStore{objects: objects, prefix: prefix}
+}
+
+// List reads every entry under the prefix.
+func (s *gcsStore) List(ctx context.Context) ([]Entry, error) {
+	names, err := s.objects.List(ctx, s.prefix)
+	if err != nil {
+		return nil, fmt.Errorf("listing waitlist: %w", err)
+	}
+	var entries []Entry
+	for _, name := range names {
+		data, generation, err := s.objects.Get(ctx, name)
+		if errors.Is(err, gcsobjects.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading waitlist entry: %w", err)
+		}
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("parsing waitlist entry %s: %w", name, err)
+		}
+		entry.Version = generation
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Save writes the object of entry if it is still at entry.Version.
+func (s *gcsStore) Save(ctx context.Context, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encoding waitlist entry: %w", err)
+	}
+	err = s.objects.Put(ctx, s.prefix+entry.ID+".json", data, entry.Version)
+	if errors.Is(err, gcsobjects.ErrGenerationMismatch) {
+		return ErrEntryChanged
+	}
+	if err != nil {
+		return fmt.Errorf("saving waitlist entry: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the object of the entry with the given ID.
+func (s *gcsStore) Delete(ctx context.Context, id string) error {
+	if err := s.objects.Delete(ctx, s.prefix+id+".json"); err != nil {
+		return fmt.Errorf("deleting waitlist entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package waitlist
+
+import (
+	"testing"
+
+	"ivmanto.com/backend/internal/gcsobjects"
+)
+
+// TestGCSStore_SharedBetweenInstances saves an entry on one instance, updates
+// it on another and checks that the first instance's stale copy is rejected.
+func TestGCSStore_SharedBetweenInstances(t *testing.T) {
+	bucket := gcsobjects.NewMemory()
+	one, two := newGCSStore(bucket, "waitlist/"), newGCSStore(bucket, "waitlist/")
+	ctx := t.Context()
+
+	if err := one.Save(ctx, Entry{ID: "a", Email: "ada@example.com"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	stale, _ := one.List(ctx)
+	entries, err := two.List(ctx)
+	if err != nil || len(entries) != 1 || entries[0].Email != "ada@example.com" {
+		t.Fatalf("expected the entry on the other instance, got %+v (%v)", entries, err)
+	}
+	entries[0].Offers = []Offer{{Token: "tok", EventIDs: []string{"e1"}}}
+	if err := two.Save(ctx, entries[0]); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := one.Save(ctx, stale[0]); err != ErrEntryChanged {
+		t.Errorf("expected the stale entry to be rejected, got %v", err)
+	}
+	if err := one.Save(ctx, Entry{ID: "a", Email: "ada@example.com"}); err != ErrEntryChanged {
+		t.Errorf("expected a new entry with a taken ID to be rejected, got %v", err)
+	}
+
+	if err := one.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if entries, _ := two.List(ctx); len(entries) != 0 {
+		t.Errorf("expected the entry to be gone on both instances, got %+v", entries)
+	}
+}
@@ -0,0 +1,160 @@
+// Package waitlist stores the visitors who asked to be told when a slot opens
+// up on days that were fully booked.
+package waitlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"ivmanto.com/backend/internal/jsonfile"
+)
+
+// Entry is one visitor waiting for a slot between From and To.
+type Entry struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	// From and To are the preferred days (YYYY-MM-DD, inclusive) in Timezone.
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Timezone  string    `json:"timezone"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Open are the slots in the range that were already open when the
+	// visitor joined. They could be booked right away, so they are never
+	// offered; only slots that open up later are.
+	Open []string `json:"open,omitempty"`
+	// Offers are the priority booking links sent to the visitor so far.
+	Offers []Offer `json:"offers,omitempty"`
+	// Version identifies the stored version of the entry, as returned by
+	// List; 0 for an entry that was never saved. Save only replaces the
+	// version it was read at.
+	Version int64 `json:"-"`
+}
+
+// ErrEntryChanged is returned by Store.Save when the entry was changed or
+// deleted since it was read, e.g. by another instance of the service.
+var ErrEntryChanged = errors.New("waitlist entry changed since it was read")
+
+// Offer is a priority booking link sent for newly opened slots. Until it
+// expires, only the holder of its token (or of another offer for the same
+// slot) can book those slots.
+type Offer struct {
+	Token    string    `json:"token"`
+	EventIDs []string  `json:"eventIds"`
+	Expires  time.Time `json:"expires"`
+}
+
+// Includes reports whether eventID is one of the offered slots.
+func (o Offer) Includes(eventID string) bool {
+	for _, id := range o.EventIDs {
+		if id == eventID {
+			return true
+		}
+	}
+	return false
+}
+
+// Offered reports whether eventID was already offered to the visitor, or was
+// open when they joined.
+func (e Entry) Offered(eventID string) bool {
+	for _, id := range e.Open {
+		if id == eventID {
+			return true
+		}
+	}
+	for _, offer := range e.Offers {
+		if offer.Includes(eventID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Store defines the interface for waitlist storage operations.
+type Store interface {
+	// List returns all entries.
+	List(ctx context.Context) ([]Entry, error)
+	// Save adds entry or replaces the entry with the same ID. It returns
+	// ErrEntryChanged unless the stored entry is still at entry.Version.
+	Save(ctx context.Context, entry Entry) error
+	// Delete removes the entry with the given ID. Deleting an unknown ID is not an error.
+	Delete(ctx context.Context, id string) error
+}
+
+// fileStore keeps the entries in memory and, when a path is set, mirrors them
+// to a JSON file so they survive restarts during local development. Each
+// instance of the service has its own; see NewGCSStore for a shared store.
+type fileStore struct {
+	path string
+
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewFileStore creates a Store backed by the JSON file at path, loading any
+// entries it already holds. An empty path keeps the entries in memory only.
+func NewFileStore(path string) (Store, error) {
+	s := &fileStore{path: path}
+	if path == "" {
+		return s, nil
+	}
+	if err := jsonfile.Load(path, &s.entries); err != nil {
+		return nil, fmt.Errorf("loading waitlist: %w", err)
+	}
+	return s, nil
+}
+
+// List returns a copy of all entries.
+func (s *fileStore) List(ctx context.Context) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Entry(nil), s.entries...), nil
+}
+
+// Save adds entry or replaces the entry with the same ID and persists the list.
+func (s *fileStore) Save(ctx context.Context, entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		if s.entries[i].ID == entry.ID {
+			if s.entries[i].Version != entry.Version {
+				return ErrEntryChanged
+			}
+			entry.Version++
+			s.entries[i] = entry
+			return s.persist()
+		}
+	}
+	if entry.Version != 0 {
+		return ErrEntryChanged
+	}
+	entry.Version = 1
+	s.entries = append(s.entries, entry)
+	return s.persist()
+}
+
+// Delete removes the entry with the given ID and persists the list.
+func (s *fileStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		if s.entries[i].ID == id {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			return s.persist()
+		}
+	}
+	return nil
+}
+
+// persist writes the entries to the file. The caller must hold s.mu.
+func (s *fileStore) persist() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := jsonfile.Save(s.path, s.entries); err != nil {
+		return fmt.Errorf("saving waitlist: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package waitlist
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFileStore_PersistsAcrossReopen saves, replaces and deletes entries and
+// checks that a store reopened on the same file sees the result.
+func TestFileStore_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "waitlist.json")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	ctx := t.Context()
+
+	for _, entry := range []Entry{
+		{ID: "a", Email: "ada@example.com", From: "2026-06-15", To: "2026-06-16"},
+		{ID: "b", Email: "bob@example.com", From: "2026-06-20", To: "2026-06-20"},
+		{ID: "a", Email: "ada@example.com", From: "2026-06-15", To: "2026-06-19", Version: 1,
+			Offers: []Offer{{Token: "tok", EventIDs: []string{"e1"}, Expires: time.Date(2026, 6, 10, 11, 0, 0, 0, time.UTC)}}},
+	} {
+		if err := store.Save(ctx, entry); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	if err := store.Save(ctx, Entry{ID: "b", Email: "bob@example.com"}); err != ErrEntryChanged {
+		t.Errorf("expected a stale version to be rejected, got %v", err)
+	}
+	if err := store.Delete(ctx, "b"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("reopening the store failed: %v", err)
+	}
+	entries, err := reopened.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].To != "2026-06-19" || !entries[0].Offered("e1") || entries[0].Offered("e2") {
+		t.Fatalf("unexpected entries after reopening: %+v", entries)
+	}
+}
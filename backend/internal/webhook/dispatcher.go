@@ -0,0 +1,270 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"ivmanto.com/backend/internal/config"
+)
+
+const (
+	// requestTimeout bounds one delivery attempt.
+	requestTimeout = 10 * time.Second
+	// maxBackoff caps the wait between two attempts.
+	maxBackoff = time.Minute
+	// retryWindow bounds how long a delivery is retried. Retries wait in
+	// memory, so they must finish within the lifetime of a Cloud Run
+	// instance; a retry that would start later is not made and the delivery
+	// is dead-lettered instead.
+	retryWindow = 5 * time.Minute
+)
+
+// Dispatcher sends events to the configured targets. A nil Dispatcher sends
+// nothing, so callers need not check whether webhooks are configured.
+type Dispatcher struct {
+	logger      *slog.Logger
+	targets     []config.WebhookTarget
+	maxAttempts int
+	backoff     time.Duration
+	client      *http.Client
+	now         func() time.Time
+
+	deadMu     sync.Mutex
+	deadLetter *os.File // nil logs dead letters only
+	deadPath   string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// DeadLetter is a delivery that failed for good, as written to the dead-letter
+// file.
+type DeadLetter struct {
+	Time     time.Time       `json:"time"`
+	URL      string          `json:"url"`
+	Attempts int             `json:"attempts"`
+	Error    string          `json:"error"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// NewDispatcher returns a dispatcher for cfg. It returns nil when no targets
+// are configured.
+func NewDispatcher(logger *slog.Logger, cfg config.WebhookConfig) (*Dispatcher, error) {
+	if len(cfg.Targets) == 0 {
+		return nil, nil
+	}
+	for i, target := range cfg.Targets {
+		if err := checkEvents(target.Events); err != nil {
+			return nil, fmt.Errorf("webhook target %d: %w", i+1, err)
+		}
+	}
+	d := &Dispatcher{
+		logger:      logger,
+		targets:     cfg.Targets,
+		maxAttempts: cfg.MaxAttempts,
+		backoff:     cfg.Backoff,
+		client:      &http.Client{Timeout: requestTimeout},
+		now:         time.Now,
+	}
+	if cfg.DeadLetterFile != "" {
+		file, err := os.OpenFile(cfg.DeadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("opening webhook dead-letter file: %w", err)
+		}
+		d.deadLetter = file
+		d.deadPath = cfg.DeadLetterFile
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	return d, nil
+}
+
+// Send delivers event with data, encoded as JSON, to every target that
+// subscribed to it. It does not wait for the deliveries.
+func (d *Dispatcher) Send(event Event, data any) {
+	if d == nil {
+		return
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		d.logger.Error("Could not encode webhook data", "webhook_event", event, "error", err)
+		return
+	}
+	id := uuid.NewString()
+	body, err := json.Marshal(Payload{ID: id, Type: event, CreatedAt: d.now().UTC(), Data: raw})
+	if err != nil {
+		d.logger.Error("Could not encode webhook payload", "webhook_event", event, "error", err)
+		return
+	}
+	for _, target := range d.targets {
+		if len(target.Events) > 0 && !slices.Contains(target.Events, string(event)) {
+			continue
+		}
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.deliver(target, event, id, body)
+		}()
+	}
+}
+
+// Stop abandons the retries still waiting, dead-lettering their deliveries,
+// and waits for the deliveries in flight.
+func (d *Dispatcher) Stop() {
+	if d == nil {
+		return
+	}
+	d.cancel()
+	d.wg.Wait()
+	if d.deadLetter != nil {
+		d.deadLetter.Close()
+	}
+}
+
+// deliver posts body to target until it is accepted, the attempts or the
+// retry window run out or the receiver rejects it.
+func (d *Dispatcher) deliver(target config.WebhookTarget, event Event, id string, body []byte) {
+	deadline := d.now().Add(retryWindow)
+	wait := d.backoff
+	for attempt := 1; ; attempt++ {
+		err := d.post(target, event, id, body)
+		if err == nil {
+			d.logger.Info("Delivered webhook", "webhook_event", event, "target", host(target.URL), "attempt", attempt)
+			return
+		}
+		var rejected rejectedError
+		if errors.As(err, &rejected) || attempt >= d.maxAttempts {
+			d.dead(target, event, body, attempt, err)
+			return
+		}
+		if d.now().Add(wait).After(deadline) {
+			d.dead(target, event, body, attempt, fmt.Errorf("retry window of %s exhausted: %w", retryWindow, err))
+			return
+		}
+		d.logger.Warn("Webhook delivery failed, retrying", "webhook_event", event, "target", host(target.URL), "attempt", attempt, "retry_in", wait, "error", err)
+		if !sleep(d.ctx, wait) {
+			d.dead(target, event, body, attempt, fmt.Errorf("shut down before retrying: %w", err))
+			return
+		}
+		wait = min(2*wait, maxBackoff)
+	}
+}
+
+// rejectedError is a failure that retrying will not change, e.g. a 400 or
+// 404 response.
+type rejectedError struct {
+	err error
+}
+
+func (e rejectedError) Error() string { return e.err.Error() }
+
+// post makes one delivery attempt, signed at the time it is sent.
+func (d *Dispatcher) post(target config.WebhookTarget, event Event, id string, body []byte) error {
+	ctx, cancel := context.WithTimeout(d.ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return rejectedError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(event))
+	req.Header.Set(DeliveryHeader, id)
+	req.Header.Set(SignatureHeader, Sign(target.Secret, body, d.now()))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return fmt.Errorf("receiver answered with status %d", resp.StatusCode)
+	default:
+		return rejectedError{fmt.Errorf("receiver rejected the delivery with status %d", resp.StatusCode)}
+	}
+}
+
+// dead records a delivery that failed for good in the dead-letter file, if
+// there is one, and in the log.
+func (d *Dispatcher) dead(target config.WebhookTarget, event Event, body []byte, attempts int, cause error) {
+	d.logger.Error("Webhook delivery failed, dead-lettered", "webhook_event", event, "target", host(target.URL), "attempts", attempts, "error", cause)
+	if d.deadLetter == nil {
+		d.logger.Error("Dead-lettered webhook payload", "webhook_event", event, "payload", string(body))
+		return
+	}
+	line, err := json.Marshal(DeadLetter{Time: d.now().UTC(), URL: target.URL, Attempts: attempts, Error: cause.Error(), Payload: body})
+	if err != nil {
+		d.logger.Error("Could not encode webhook dead letter", "error", err)
+		return
+	}
+	d.deadMu.Lock()
+	defer d.deadMu.Unlock()
+	if _, err := d.deadLetter.Write(append(line, '\n')); err != nil {
+		d.logger.Error("Could not write webhook dead letter", "error", err, "payload", string(body))
+	}
+}
+
+// LogsDeadLetters reports whether dead letters are written to the service
+// logs, because no dead-letter file is configured.
+func (d *Dispatcher) LogsDeadLetters() bool {
+	return d != nil && d.deadLetter == nil
+}
+
+// Forget deletes the dead letters whose payload contains text, compared
+// case-insensitively, e.g. an email address on an erasure request, and
+// returns how many it deleted.
+func (d *Dispatcher) Forget(ctx context.Context, text string) (int, error) {
+	if d == nil || d.deadLetter == nil {
+		return 0, nil
+	}
+	d.deadMu.Lock()
+	defer d.deadMu.Unlock()
+	data, err := os.ReadFile(d.deadPath)
+	if err != nil {
+		return 0, fmt.Errorf("reading webhook dead-letter file: %w", err)
+	}
+
+	needle := bytes.ToLower([]byte(text))
+	var out bytes.Buffer
+	forgotten := 0
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if !bytes.Contains(bytes.ToLower(line), needle) {
+			out.Write(line)
+			continue
+		}
+		forgotten++
+	}
+	if forgotten == 0 {
+		return 0, nil
+	}
+
+	tmp := d.deadPath + ".tmp"
+	if err := os.WriteFile(tmp, out.Bytes(), 0o600); err != nil {
+		return 0, fmt.Errorf("writing webhook dead-letter file: %w", err)
+	}
+	if err := os.Rename(tmp, d.deadPath); err != nil {
+		return 0, fmt.Errorf("replacing webhook dead-letter file: %w", err)
+	}
+	// Append to the new file from now on.
+	file, err := os.OpenFile(d.deadPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("reopening webhook dead-letter file: %w", err)
+	}
+	d.deadLetter.Close()
+	d.deadLetter = file
+	return forgotten, nil
+}
@@ -0,0 +1,134 @@
+// Package webhook delivers events such as new bookings and contact messages to
+// the receivers configured in WEBHOOK_TARGETS, e.g. a CRM or a chat tool.
+//
+// Every delivery is a JSON POST of a Payload, signed with the receiver's
+// secret (see Sign). Deliveries run in the background; failed ones are retried
+// with exponential backoff and, once the attempts run out, written to a
+// dead-letter log from which they can be replayed by hand.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is the type of an event sent to webhook receivers.
+type Event string
+
+const (
+	EventBookingCreated     Event = "booking.created"
+	EventBookingCancelled   Event = "booking.cancelled"
+	EventBookingRescheduled Event = "booking.rescheduled"
+	EventContactReceived    Event = "contact.received"
+	EventIdeasEmailed       Event = "ideas.emailed"
+)
+
+// Events are all event types, in the order they are documented.
+var Events = []Event{EventBookingCreated, EventBookingCancelled, EventBookingRescheduled, EventContactReceived, EventIdeasEmailed}
+
+// Headers set on every delivery.
+const (
+	// SignatureHeader carries "t=<unix seconds>,v1=<hex HMAC-SHA256>"; see Sign.
+	SignatureHeader = "X-Webhook-Signature"
+	// EventHeader repeats the event type of the payload.
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader repeats the payload ID. It stays the same across retries,
+	// so receivers can drop a delivery they have already processed.
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
+// Payload is the body of a delivery.
+type Payload struct {
+	ID        string          `json:"id"`
+	Type      Event           `json:"type"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// ErrSignature is returned by Verify for a delivery that was not signed with
+// the secret, or was signed outside the tolerance.
+var ErrSignature = errors.New("invalid webhook signature")
+
+// Sign returns the signature header of a delivery of body sent at t. The MAC
+// covers "<unix seconds>.<body>", so a captured delivery cannot be replayed
+// with a new timestamp.
+func Sign(secret string, body []byte, t time.Time) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(secret, ts, body))
+}
+
+// Verify checks the signature header of a delivery of body, as a receiver
+// does. Deliveries signed more than tolerance away from now are rejected.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sig = value
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrSignature
+	}
+	if diff := now.Sub(time.Unix(unix, 0)); diff > tolerance || diff < -tolerance {
+		return ErrSignature
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, mac(secret, ts, body)) {
+		return ErrSignature
+	}
+	return nil
+}
+
+func mac(secret, ts string, body []byte) []byte {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(ts + "."))
+	m.Write(body)
+	return m.Sum(nil)
+}
+
+// checkEvents returns an error naming the first unknown event type.
+func checkEvents(events []string) error {
+	for _, event := range events {
+		if !slices.Contains(Events, Event(event)) {
+			return fmt.Errorf("unknown webhook event %q", event)
+		}
+	}
+	return nil
+}
+
+// host names a target in logs without the path or query of its URL, which
+// may carry a token.
+func host(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// sleep waits for d, returning false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
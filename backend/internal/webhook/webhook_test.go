@@ -0,0 +1,188 @@
+package webhook_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/webhook"
+	"ivmanto.com/backend/internal/webhook/webhooktest"
+)
+
+func TestSignVerify(t *testing.T) {
+	secret := webhooktest.Secret
+	body := []byte(`{"id":"1"}`)
+	at := time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC)
+	header := webhook.Sign(secret, body, at)
+
+	if err := webhook.Verify(secret, header, body, at.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Errorf("expected the signature to verify, got %v", err)
+	}
+	for name, check := range map[string]func() error{
+		"altered body": func() error { return webhook.Verify(secret, header, []byte(`{"id":"2"}`), at, 5*time.Minute) },
+		"other secret": func() error { return webhook.Verify("other", header, body, at, 5*time.Minute) },
+		"too old":      func() error { return webhook.Verify(secret, header, body, at.Add(time.Hour), 5*time.Minute) },
+		"no header":    func() error { return webhook.Verify(secret, "", body, at, 5*time.Minute) },
+	} {
+		if err := check(); err != webhook.ErrSignature {
+			t.Errorf("%s: expected ErrSignature, got %v", name, err)
+		}
+	}
+}
+
+// TestDispatcher_RetriesUntilDelivered has the receiver fail twice and checks
+// that the third attempt delivers the same payload to the subscribed target
+// only.
+func TestDispatcher_RetriesUntilDelivered(t *testing.T) {
+	receiver := webhooktest.NewReceiver(t)
+	receiver.FailNext(2)
+	other := webhooktest.NewReceiver(t)
+	d, err := webhook.NewDispatcher(discardLogger(), config.WebhookConfig{
+		Targets:     []config.WebhookTarget{receiver.Target(), other.Target(webhook.EventContactReceived)},
+		MaxAttempts: 5,
+		Backoff:     time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewDispatcher failed: %v", err)
+	}
+
+	d.Send(webhook.EventBookingCreated, map[string]string{"eventId": "evt1"})
+	payloads := receiver.Wait(t, 1)
+	d.Stop()
+
+	if receiver.Requests() != 3 {
+		t.Errorf("expected 3 attempts, got %d", receiver.Requests())
+	}
+	var data map[string]string
+	if err := json.Unmarshal(payloads[0].Data, &data); err != nil || payloads[0].Type != webhook.EventBookingCreated || data["eventId"] != "evt1" {
+		t.Errorf("unexpected payload %+v", payloads[0])
+	}
+	if other.Requests() != 0 {
+		t.Errorf("expected the target subscribed to contact messages only to get nothing, got %d requests", other.Requests())
+	}
+}
+
+// TestDispatcher_DeadLetters checks that a delivery is dead-lettered once its
+// attempts run out, and at once when the receiver rejects it, and that dead
+// letters naming an address can be forgotten.
+func TestDispatcher_DeadLetters(t *testing.T) {
+	failing := webhooktest.NewReceiver(t)
+	failing.FailNext(100)
+	wrongSecret := webhooktest.NewReceiver(t)
+	target := wrongSecret.Target()
+	target.Secret = "another-secret-that-is-long-enough"
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	d, err := webhook.NewDispatcher(discardLogger(), config.WebhookConfig{
+		Targets:        []config.WebhookTarget{failing.Target(), target},
+		MaxAttempts:    3,
+		Backoff:        time.Millisecond,
+		DeadLetterFile: path,
+	})
+	if err != nil {
+		t.Fatalf("NewDispatcher failed: %v", err)
+	}
+
+	d.Send(webhook.EventContactReceived, map[string]string{"email": "ada@example.com"})
+	letters := waitForDeadLetters(t, path, 2)
+	if n, err := d.Forget(t.Context(), "bob@example.com"); n != 0 || err != nil {
+		t.Errorf("expected no dead letters to name another address, got %d (%v)", n, err)
+	}
+	if n, err := d.Forget(t.Context(), "Ada@Example.com"); n != 2 || err != nil {
+		t.Errorf("expected both dead letters to be forgotten, got %d (%v)", n, err)
+	}
+	if data, _ := os.ReadFile(path); len(data) != 0 {
+		t.Errorf("expected the dead-letter file to be empty, got %s", data)
+	}
+	d.Stop()
+
+	attempts := map[string]int{}
+	for _, letter := range letters {
+		attempts[letter.URL] = letter.Attempts
+		var payload webhook.Payload
+		if err := json.Unmarshal(letter.Payload, &payload); err != nil || payload.Type != webhook.EventContactReceived {
+			t.Errorf("expected the dead letter to keep the payload, got %s", letter.Payload)
+		}
+	}
+	if attempts[failing.Target().URL] != 3 || attempts[target.URL] != 1 {
+		t.Errorf("expected 3 attempts for the failing receiver and 1 for the rejecting one, got %v", attempts)
+	}
+	if wrongSecret.Rejected() != 1 || len(failing.Payloads()) != 0 {
+		t.Errorf("expected no delivery to be accepted")
+	}
+}
+
+// TestDispatcher_RetryWindow checks that a delivery whose next retry would
+// start after the retry window is dead-lettered instead of waiting for it.
+func TestDispatcher_RetryWindow(t *testing.T) {
+	failing := webhooktest.NewReceiver(t)
+	failing.FailNext(100)
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	d, err := webhook.NewDispatcher(discardLogger(), config.WebhookConfig{
+		Targets:        []config.WebhookTarget{failing.Target()},
+		MaxAttempts:    5,
+		Backoff:        time.Hour,
+		DeadLetterFile: path,
+	})
+	if err != nil {
+		t.Fatalf("NewDispatcher failed: %v", err)
+	}
+	defer d.Stop()
+
+	d.Send(webhook.EventContactReceived, map[string]string{"email": "ada@example.com"})
+	letters := waitForDeadLetters(t, path, 1)
+	if letters[0].Attempts != 1 || failing.Requests() != 1 {
+		t.Errorf("expected the delivery to be dead-lettered after one attempt, got %d attempts", letters[0].Attempts)
+	}
+}
+
+func TestNewDispatcher(t *testing.T) {
+	if d, err := webhook.NewDispatcher(discardLogger(), config.WebhookConfig{}); d != nil || err != nil {
+		t.Errorf("expected no dispatcher without targets, got %v (%v)", d, err)
+	}
+	_, err := webhook.NewDispatcher(discardLogger(), config.WebhookConfig{
+		Targets: []config.WebhookTarget{{URL: "https://example.com", Secret: webhooktest.Secret, Events: []string{"booking.moved"}}},
+	})
+	if err == nil {
+		t.Error("expected an error for an unknown event")
+	}
+	var none *webhook.Dispatcher
+	none.Send(webhook.EventBookingCreated, nil) // a nil dispatcher sends nothing
+	none.Stop()
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// waitForDeadLetters waits up to five seconds for n entries in the
+// dead-letter file at path.
+func waitForDeadLetters(t *testing.T, path string, n int) []webhook.DeadLetter {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var letters []webhook.DeadLetter
+		if file, err := os.Open(path); err == nil {
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				var letter webhook.DeadLetter
+				if json.Unmarshal(scanner.Bytes(), &letter) == nil {
+					letters = append(letters, letter)
+				}
+			}
+			file.Close()
+		}
+		if len(letters) >= n {
+			return letters
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d dead letters, got %d", n, len(letters))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,123 @@
+// Package webhooktest provides a local webhook receiver for tests. It checks
+// the signature of every delivery, like a real receiver would, and records
+// the payloads it accepts.
+package webhooktest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ivmanto.com/backend/internal/config"
+	"ivmanto.com/backend/internal/webhook"
+)
+
+// Secret is the signing secret of receivers created by NewReceiver.
+var Secret = strings.Repeat("w", 32)
+
+// Receiver is a local HTTP server accepting webhook deliveries.
+type Receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	payloads []webhook.Payload
+	failures int // requests still to answer with 503
+	rejected int // deliveries with a bad signature
+	requests int
+}
+
+// NewReceiver starts a receiver. It is closed when the test ends.
+func NewReceiver(t testing.TB) *Receiver {
+	r := &Receiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// Target returns the webhook target that delivers to r, optionally only the
+// given events.
+func (r *Receiver) Target(events ...webhook.Event) config.WebhookTarget {
+	target := config.WebhookTarget{URL: r.URL + "/hooks", Secret: Secret}
+	for _, event := range events {
+		target.Events = append(target.Events, string(event))
+	}
+	return target
+}
+
+// FailNext makes r answer the next n deliveries with 503 Service Unavailable.
+func (r *Receiver) FailNext(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = n
+}
+
+// Payloads returns the payloads accepted so far, in the order they arrived.
+func (r *Receiver) Payloads() []webhook.Payload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]webhook.Payload(nil), r.payloads...)
+}
+
+// Requests returns how many deliveries were attempted, accepted or not.
+func (r *Receiver) Requests() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests
+}
+
+// Rejected returns how many deliveries had a bad signature.
+func (r *Receiver) Rejected() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rejected
+}
+
+// Wait waits up to five seconds until r has accepted n payloads and returns
+// them. It fails the test if they do not arrive.
+func (r *Receiver) Wait(t testing.TB, n int) []webhook.Payload {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		payloads := r.Payloads()
+		if len(payloads) >= n {
+			return payloads
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d webhook deliveries, got %d", n, len(payloads))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (r *Receiver) serve(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	if err != nil {
+		http.Error(w, "could not read body", http.StatusBadRequest)
+		return
+	}
+	if err := webhook.Verify(Secret, req.Header.Get(webhook.SignatureHeader), body, time.Now(), 5*time.Minute); err != nil {
+		r.rejected++
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if r.failures > 0 {
+		r.failures--
+		http.Error(w, "try again later", http.StatusServiceUnavailable)
+		return
+	}
+	var payload webhook.Payload
+	if err := json.Unmarshal(body, &payload); err != nil || string(payload.Type) != req.Header.Get(webhook.EventHeader) || payload.ID != req.Header.Get(webhook.DeliveryHeader) {
+		http.Error(w, "malformed delivery", http.StatusBadRequest)
+		return
+	}
+	r.payloads = append(r.payloads, payload)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -19,7 +19,7 @@ export default defineNuxtConfig({
 
   sitemap: {
     sources: ['/api/__sitemap__/blog'],
-    exclude: ['/login', '/booking-demo', '/booking', '/booking/cancel'],
+    exclude: ['/login', '/booking-demo', '/booking', '/booking/cancel', '/booking/feedback'],
   },
 
   css: ['~/assets/css/main.css'],
@@ -64,6 +64,7 @@ export default defineNuxtConfig({
     // Client-only for dynamic pages
     '/booking': { prerender: true },
     '/booking/cancel': { prerender: true },
+    '/booking/feedback': { prerender: true },
     '/assessment': { prerender: true },
     // Blog: pre-rendered at generate time for SEO
     '/blog': { prerender: true },